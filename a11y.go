@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ---------- Accessibility ----------
+
+type a11yFacts struct {
+	hasLang      bool
+	title        string
+	inTitle      bool
+	headings     []int
+	links        []string
+	emptyLinks   []string
+	buttons      int
+	emptyButtons int
+	names        []string
+	labelDepth   int
+	labelFor     map[string]bool
+	unlabeled    []string
+	maybeLabeled []string
+	badAlts      []string
+}
+
+var lowInformationTitles = []string{"untitled", "untitled document", "new page", "document", "page", "index", "home page"}
+var lowInformationAlts = []string{"image", "img", "picture", "photo", "graphic", "icon", "spacer", "placeholder"}
+
+// Token records the accessibility relevant parts of a tag
+func (f *a11yFacts) Token(tokenType html.TokenType, token html.Token) {
+	start := tokenType != html.EndTagToken
+	switch token.Data {
+	case "html":
+		f.hasLang = f.hasLang || (start && len(strings.TrimSpace(GetAttr(token, "lang"))) != 0)
+	case "title":
+		f.inTitle = tokenType == html.StartTagToken
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if start {
+			f.headings = append(f.headings, int(token.Data[1]-'0'))
+		}
+	case "a":
+		if tokenType == html.StartTagToken && len(GetAttr(token, "href")) != 0 {
+			f.links = append(f.links, GetAttr(token, "href"))
+			f.names = append(f.names, accessibleName(token))
+		} else if tokenType == html.EndTagToken && len(f.links) != 0 {
+			if strings.TrimSpace(f.popName()) == "" {
+				f.emptyLinks = append(f.emptyLinks, f.links[len(f.links)-1])
+			}
+			f.links = f.links[:len(f.links)-1]
+		}
+	case "button":
+		if tokenType == html.StartTagToken {
+			f.buttons++
+			f.names = append(f.names, accessibleName(token))
+		} else if tokenType == html.EndTagToken && f.buttons != 0 {
+			if strings.TrimSpace(f.popName()) == "" {
+				f.emptyButtons++
+			}
+			f.buttons--
+		}
+	case "label":
+		if tokenType == html.StartTagToken {
+			f.labelDepth++
+		} else if tokenType == html.EndTagToken && f.labelDepth != 0 {
+			f.labelDepth--
+		}
+		if start {
+			if id := GetAttr(token, "for"); len(id) != 0 {
+				if f.labelFor == nil {
+					f.labelFor = map[string]bool{}
+				}
+				f.labelFor[id] = true
+			}
+		}
+	case "img":
+		if start {
+			alt, hasAlt := "", false
+			for _, attr := range token.Attr {
+				if attr.Key == "alt" {
+					alt, hasAlt = attr.Val, true
+				}
+			}
+			if len(f.names) != 0 {
+				f.names[len(f.names)-1] += " " + alt
+			}
+			if hasAlt && isLowInformationAlt(alt, GetAttr(token, "src")) {
+				f.badAlts = append(f.badAlts, alt)
+			}
+		}
+	case "input", "select", "textarea":
+		if !start {
+			return
+		}
+		kind := strings.ToLower(GetAttr(token, "type"))
+		if token.Data == "input" && (kind == "hidden" || kind == "submit" || kind == "button" || kind == "reset" || kind == "image") {
+			if len(f.names) != 0 {
+				f.names[len(f.names)-1] += " " + GetAttr(token, "value") + GetAttr(token, "alt")
+			}
+			return
+		}
+		if f.labelDepth != 0 || len(accessibleName(token)) != 0 {
+			return
+		}
+		field := GetAttr(token, "name")
+		if len(field) == 0 {
+			field = token.Data
+		}
+		if id := GetAttr(token, "id"); len(id) != 0 {
+			f.maybeLabeled = append(f.maybeLabeled, id)
+		} else {
+			f.unlabeled = append(f.unlabeled, field)
+		}
+	}
+}
+
+// Text records text content
+func (f *a11yFacts) Text(text string) {
+	if f.inTitle {
+		f.title += text
+	}
+	if len(f.names) != 0 {
+		f.names[len(f.names)-1] += text
+	}
+}
+
+func (f *a11yFacts) popName() string {
+	name := f.names[len(f.names)-1]
+	f.names = f.names[:len(f.names)-1]
+	if len(f.names) != 0 {
+		f.names[len(f.names)-1] += " " + name
+	}
+
+	return name
+}
+
+// Findings returns the accessibility problems found with WCAG references
+func (f a11yFacts) Findings() []string {
+	var findings []string
+	if !f.hasLang {
+		findings = append(findings, "missing lang attribute on html (WCAG 3.1.1)")
+	}
+
+	title := strings.ToLower(strings.Join(strings.Fields(f.title), " "))
+	if len(title) == 0 {
+		findings = append(findings, "missing page title (WCAG 2.4.2)")
+	} else if contains(lowInformationTitles, title) {
+		findings = append(findings, fmt.Sprintf("low-information title %q (WCAG 2.4.2)", f.title))
+	}
+
+	for _, href := range f.emptyLinks {
+		findings = append(findings, fmt.Sprintf("link without text to %v (WCAG 2.4.4)", href))
+	}
+	if f.emptyButtons != 0 {
+		findings = append(findings, fmt.Sprintf("%v button(s) without text (WCAG 4.1.2)", f.emptyButtons))
+	}
+
+	unlabeled := append([]string{}, f.unlabeled...)
+	for _, id := range f.maybeLabeled {
+		if !f.labelFor[id] {
+			unlabeled = append(unlabeled, id)
+		}
+	}
+	for _, field := range unlabeled {
+		findings = append(findings, fmt.Sprintf("form field %v without label (WCAG 1.3.1, 3.3.2)", field))
+	}
+
+	for i := 1; i < len(f.headings); i++ {
+		if f.headings[i] > f.headings[i-1]+1 {
+			findings = append(findings, fmt.Sprintf("heading order skips from h%v to h%v (WCAG 1.3.1)", f.headings[i-1], f.headings[i]))
+		}
+	}
+
+	for _, alt := range f.badAlts {
+		findings = append(findings, fmt.Sprintf("low-information alt text %q (WCAG 1.1.1)", alt))
+	}
+
+	return findings
+}
+
+// PrintAccessibility prints the accessibility findings of a page
+func PrintAccessibility(res result) {
+	for _, finding := range res.a11y {
+		fmt.Fprintf(logOutput, "A11y: %v %v\n", res.url, finding)
+	}
+}
+
+func accessibleName(token html.Token) string {
+	for _, key := range []string{"aria-label", "aria-labelledby", "title"} {
+		if name := strings.TrimSpace(GetAttr(token, key)); len(name) != 0 {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func isLowInformationAlt(alt string, src string) bool {
+	alt = strings.ToLower(strings.TrimSpace(alt))
+	if len(alt) == 0 {
+		return false
+	}
+
+	return contains(lowInformationAlts, alt) || alt == strings.ToLower(path.Base(src))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------- Active hours ----------
+
+// hourWindow is a daily window from one minute of the day to another,
+// wrapping past midnight if it ends before it starts
+type hourWindow struct {
+	from int
+	to   int
+}
+
+// activeHours holds requests outside daily time windows, such as the
+// off-peak hours of the crawled sites
+type activeHours struct {
+	mutex   sync.Mutex
+	windows []hourWindow
+	zone    *time.Location
+	waiting bool
+	verbose bool
+}
+
+// ParseActiveHours parses comma separated windows such as 22:00-06:00 in a
+// time zone, such as Europe/Stockholm, or local time if zone is empty
+func ParseActiveHours(list string, zone string, verbose bool) (*activeHours, error) {
+	if len(strings.TrimSpace(list)) == 0 {
+		return nil, nil
+	}
+
+	location := time.Local
+	if len(zone) != 0 {
+		var err error
+		location, err = time.LoadLocation(zone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a := &activeHours{zone: location, verbose: verbose}
+	for _, window := range strings.Split(list, ",") {
+		times := strings.Split(strings.TrimSpace(window), "-")
+		if len(times) != 2 {
+			return nil, fmt.Errorf("invalid active hours: %v", window)
+		}
+
+		var minutes [2]int
+		for i, clock := range times {
+			t, err := time.Parse("15:04", strings.TrimSpace(clock))
+			if err != nil {
+				return nil, fmt.Errorf("invalid active hours: %v", window)
+			}
+			minutes[i] = t.Hour()*60 + t.Minute()
+		}
+		a.windows = append(a.windows, hourWindow{minutes[0], minutes[1]})
+	}
+
+	return a, nil
+}
+
+// Active reports whether a time is inside a window. A window ending when
+// it starts lasts all day.
+func (a *activeHours) Active(t time.Time) bool {
+	t = t.In(a.zone)
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range a.windows {
+		switch {
+		case w.from == w.to:
+			return true
+		case w.from < w.to && minute >= w.from && minute < w.to:
+			return true
+		case w.from > w.to && (minute >= w.from || minute < w.to):
+			return true
+		}
+	}
+
+	return false
+}
+
+// Next returns when the next window after a time starts
+func (a *activeHours) Next(t time.Time) time.Time {
+	t = t.In(a.zone)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, a.zone)
+
+	var next time.Time
+	for _, w := range a.windows {
+		start := midnight.Add(time.Duration(w.from) * time.Minute)
+		if !start.After(t) {
+			start = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, a.zone).Add(time.Duration(w.from) * time.Minute)
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+
+	return next
+}
+
+// Wait blocks until the current time is inside a window
+func (a *activeHours) Wait() {
+	if a == nil {
+		return
+	}
+
+	for now := time.Now(); !a.Active(now); now = time.Now() {
+		next := a.Next(now)
+		a.mutex.Lock()
+		if !a.waiting && a.verbose {
+			fmt.Fprintf(logOutput, "Outside active hours, pausing until %v\n", next.Format("2006-01-02 15:04 MST"))
+		}
+		a.waiting = true
+		a.mutex.Unlock()
+
+		time.Sleep(time.Until(next))
+	}
+
+	a.mutex.Lock()
+	if a.waiting && a.verbose {
+		fmt.Fprintln(logOutput, "Inside active hours, resuming")
+	}
+	a.waiting = false
+	a.mutex.Unlock()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ---------- Anchors ----------
+
+type phraseCount struct {
+	phrase string
+	count  int
+}
+
+type anchorIndex struct {
+	urls    map[string]string
+	phrases map[string]map[string]int
+}
+
+// Add records a crawled page and the anchor text of its links
+func (a *anchorIndex) Add(res result) {
+	if a.urls == nil {
+		a.urls = map[string]string{}
+		a.phrases = map[string]map[string]int{}
+	}
+
+	if res.errClass == errorNone {
+		a.urls[URLKey(res.url)] = res.url
+	}
+
+	for _, anc := range res.anchors {
+		text := strings.ToLower(anc.text)
+		if len(text) == 0 {
+			continue
+		}
+
+		key := URLKey(anc.url)
+		if a.phrases[key] == nil {
+			a.phrases[key] = map[string]int{}
+		}
+		a.phrases[key][text]++
+	}
+}
+
+// Top returns the most used anchor phrases linking to a crawled page
+func (a *anchorIndex) Top(key string, n int) []phraseCount {
+	var counts []phraseCount
+	for phrase, count := range a.phrases[key] {
+		counts = append(counts, phraseCount{phrase, count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		return counts[i].phrase < counts[j].phrase
+	})
+
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+
+	return counts
+}
+
+// Print prints the top anchor phrases of every crawled page
+func (a *anchorIndex) Print(n int) {
+	var keys []string
+	for key := range a.urls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		top := a.Top(key, n)
+		if len(top) == 0 {
+			fmt.Fprintf(logOutput, "Anchors: %v (no anchor text)\n", a.urls[key])
+			continue
+		}
+
+		fmt.Fprintf(logOutput, "Anchors: %v\n", a.urls[key])
+		for _, p := range top {
+			fmt.Fprintf(logOutput, "  %v %q\n", p.count, p.phrase)
+		}
+	}
+}
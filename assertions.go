@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// ---------- Assertions ----------
+
+// assertion is a config rule every matching page must pass. With header set
+// the header must be present, equal a value, contain a value or be absent.
+// With scheme set the page URL must use that scheme.
+type assertion struct {
+	Name     string `json:"name"`
+	Match    string `json:"match"`
+	Header   string `json:"header"`
+	Equals   string `json:"equals"`
+	Contains string `json:"contains"`
+	Absent   bool   `json:"absent"`
+	Scheme   string `json:"scheme"`
+}
+
+type assertionFailure struct {
+	name   string
+	url    string
+	reason string
+}
+
+type assertionChecker struct {
+	rules    []assertion
+	passed   int
+	failures []assertionFailure
+}
+
+// Check evaluates the assertions matching a page and reports whether it
+// passed them. Header assertions are only evaluated for pages that were
+// fetched.
+func (c *assertionChecker) Check(res result) bool {
+	u, err := neturl.Parse(res.url)
+	if err != nil {
+		return true
+	}
+
+	passed := true
+	for _, rule := range c.rules {
+		if !MatchPattern(rule.Match, u) {
+			continue
+		}
+		if len(rule.Header) != 0 && res.errClass != errorNone {
+			continue
+		}
+
+		if reason := rule.Fail(u, res); len(reason) != 0 {
+			c.failures = append(c.failures, assertionFailure{rule.Describe(), res.url, reason})
+			passed = false
+		} else {
+			c.passed++
+		}
+	}
+
+	return passed
+}
+
+// Fail returns why a page fails an assertion or an empty string
+func (a assertion) Fail(u *neturl.URL, res result) string {
+	if len(a.Scheme) != 0 && !strings.EqualFold(u.Scheme, a.Scheme) {
+		return fmt.Sprintf("scheme %v", u.Scheme)
+	}
+
+	if len(a.Header) == 0 {
+		return ""
+	}
+
+	values, ok := res.header[http.CanonicalHeaderKey(a.Header)]
+	value := strings.Join(values, ", ")
+	switch {
+	case a.Absent && ok:
+		return fmt.Sprintf("%v: %v", a.Header, value)
+	case a.Absent:
+		return ""
+	case !ok:
+		return fmt.Sprintf("%v missing", a.Header)
+	case len(a.Equals) != 0 && !strings.EqualFold(strings.TrimSpace(value), a.Equals):
+		return fmt.Sprintf("%v: %v", a.Header, value)
+	case len(a.Contains) != 0 && !strings.Contains(strings.ToLower(value), strings.ToLower(a.Contains)):
+		return fmt.Sprintf("%v: %v", a.Header, value)
+	}
+
+	return ""
+}
+
+// Describe returns the name of an assertion or a description of it
+func (a assertion) Describe() string {
+	if len(a.Name) != 0 {
+		return a.Name
+	}
+
+	var parts []string
+	if len(a.Scheme) != 0 {
+		parts = append(parts, "scheme "+a.Scheme)
+	}
+	switch {
+	case len(a.Header) == 0:
+	case a.Absent:
+		parts = append(parts, "no "+a.Header)
+	case len(a.Equals) != 0:
+		parts = append(parts, a.Header+" = "+a.Equals)
+	case len(a.Contains) != 0:
+		parts = append(parts, a.Header+" contains "+a.Contains)
+	default:
+		parts = append(parts, a.Header+" present")
+	}
+
+	return a.Match + ": " + strings.Join(parts, ", ")
+}
+
+// Print prints the failed assertions and a summary, returning whether all
+// assertions passed
+func (c *assertionChecker) Print() bool {
+	if len(c.rules) == 0 {
+		return true
+	}
+
+	for _, f := range c.failures {
+		fmt.Fprintf(logOutput, "Assertion failed: %v %v (%v)\n", f.name, f.url, f.reason)
+	}
+	fmt.Fprintf(logOutput, "Assertions: %v passed, %v failed\n", c.passed, len(c.failures))
+
+	return len(c.failures) == 0
+}
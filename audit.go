@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ---------- Audit ----------
+
+var knownAudits = []string{"images", "a11y", "html", "spelling"}
+
+// ParseAudits parses a comma separated list of audits
+func ParseAudits(list string) (map[string]bool, error) {
+	audits := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+
+		known := false
+		for _, audit := range knownAudits {
+			known = known || audit == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown audit: %v", name)
+		}
+
+		audits[name] = true
+	}
+
+	return audits, nil
+}
+
+// HasFindings reports whether a page failed, failed an assertion or has
+// findings of the accessibility or HTML audits run
+func HasFindings(res result, audits map[string]bool, passed bool) bool {
+	return res.errClass != errorNone || !passed ||
+		(audits["a11y"] && len(res.a11y) != 0) || (audits["html"] && len(res.markup) != 0)
+}
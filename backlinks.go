@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// ---------- Backlinks ----------
+
+type backlink struct {
+	source string
+	anchor string
+}
+
+// LoadBacklinks returns the links to a URL in a run of a SQLite database,
+// matching links that only differ from the URL in normalization
+func LoadBacklinks(db *sql.DB, run int64, url string) ([]backlink, error) {
+	rows, err := db.Query("SELECT source, target, anchor FROM links WHERE run_id = ? ORDER BY source", run)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	key := URLKey(url)
+	var links []backlink
+	for rows.Next() {
+		var source, target, anchor string
+		if err := rows.Scan(&source, &target, &anchor); err != nil {
+			return nil, err
+		}
+		if target == url || URLKey(target) == key {
+			links = append(links, backlink{source, anchor})
+		}
+	}
+
+	return links, rows.Err()
+}
+
+// LatestRun returns the id of the latest finished run of a SQLite database
+func LatestRun(db *sql.DB) (int64, error) {
+	var run sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(id) FROM runs WHERE finished_at IS NOT NULL").Scan(&run); err != nil {
+		return 0, err
+	}
+	if !run.Valid {
+		return 0, fmt.Errorf("no finished runs")
+	}
+
+	return run.Int64, nil
+}
+
+// RunBacklinks prints the pages linking to a URL, with anchor text, from a
+// run of a SQLite output database
+func RunBacklinks(args []string) {
+	flags := flag.NewFlagSet("backlinks", flag.ExitOnError)
+	path := flags.String("db", "crawl.db", "Set SQLite database written by -output sqlite.")
+	run := flags.Int64("run", 0, "Set run to look in, defaults to the latest finished run.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: gocrawler backlinks [flags] <url>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	url := flags.Arg(0)
+
+	db, err := sql.Open("sqlite", *path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if *run == 0 {
+		if *run, err = LatestRun(db); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	links, err := LoadBacklinks(db, *run, url)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var class sql.NullString
+	db.QueryRow("SELECT error FROM pages WHERE run_id = ? AND url = ?", *run, url).Scan(&class)
+	switch {
+	case !class.Valid:
+		fmt.Fprintf(logOutput, "Backlinks: %v (not crawled, %v pages, run %v)\n", url, len(links), *run)
+	case len(class.String) != 0:
+		fmt.Fprintf(logOutput, "Backlinks: %v (%v, %v pages, run %v)\n", url, class.String, len(links), *run)
+	default:
+		fmt.Fprintf(logOutput, "Backlinks: %v (%v pages, run %v)\n", url, len(links), *run)
+	}
+	for _, link := range links {
+		fmt.Fprintf(logOutput, "  %v %q\n", link.source, link.anchor)
+	}
+}
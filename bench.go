@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ---------- Bench ----------
+
+// SyntheticSite serves a tree of pages where every page links to fanout
+// children and back to the root, down to the given depth
+func SyntheticSite(fanout int, depth int, latency time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil {
+			id = 0
+		}
+
+		time.Sleep(latency)
+
+		level := 1
+		for first, width := 0, 1; id >= first+width; first, width = first+width, width*fanout {
+			level++
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<html><body><a href=\"/\">root</a>")
+		if level < depth {
+			for i := 1; i <= fanout; i++ {
+				fmt.Fprintf(w, "<a href=\"/%v\">page %v</a>", id*fanout+i, id*fanout+i)
+			}
+		}
+		fmt.Fprintf(w, "</body></html>")
+	})
+}
+
+// RunBench crawls a synthetic site served in-process and prints throughput
+// and allocation statistics
+func RunBench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	fanout := flags.Int("fanout", 5, "Set number of links per page.")
+	depth := flags.Int("depth", 5, "Set depth of the synthetic site.")
+	latency := flags.Duration("latency", 0, "Set server latency per page.")
+	workers := flags.Int("workers", 0, "Set to > 0 to crawl with a fixed number of workers.")
+	flags.Parse(args)
+
+	server := httptest.NewServer(SyntheticSite(*fanout, *depth, *latency))
+	defer server.Close()
+
+	limit, _ := NewDepthLimit(server.URL+"/", *depth, depthHops)
+	opts := crawlOptions{limit: limit, workers: *workers}
+	client := NewClient()
+	client.Transport = &http.Transport{MaxIdleConnsPerHost: 100}
+	fetcher := fetcher{client: client}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	go Crawl([]string{server.URL + "/"}, opts, fetcher, false)
+	go Analyse(false, false)
+
+	pages := 0
+	for range results {
+		pages++
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	if pages == 0 {
+		fmt.Println("No pages crawled")
+		return
+	}
+
+	ideal := time.Duration(*depth) * *latency
+	fmt.Printf("Pages: %v\n", pages)
+	fmt.Printf("Elapsed: %v\n", elapsed)
+	fmt.Printf("Pages/sec: %.1f\n", float64(pages)/elapsed.Seconds())
+	fmt.Printf("Allocated: %v bytes (%v bytes/page)\n", after.TotalAlloc-before.TotalAlloc, (after.TotalAlloc-before.TotalAlloc)/uint64(pages))
+	fmt.Printf("Allocations: %v (%v/page)\n", after.Mallocs-before.Mallocs, (after.Mallocs-before.Mallocs)/uint64(pages))
+	fmt.Printf("GC cycles: %v\n", after.NumGC-before.NumGC)
+	fmt.Printf("Scheduling overhead: %v (elapsed minus %v critical path latency)\n", elapsed-ideal, ideal)
+}
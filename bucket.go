@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+// ---------- Bucket ----------
+
+type bucketWriter struct {
+	bucket *blob.Bucket
+	writer *blob.Writer
+}
+
+// IsBucketURL reports whether an output is an S3 or GCS location
+func IsBucketURL(output string) bool {
+	return strings.HasPrefix(output, "s3://") || strings.HasPrefix(output, "gs://")
+}
+
+// NewBucketWriter opens a streaming, multipart upload of an object named
+// name below the prefix of an s3://bucket/prefix or gs://bucket/prefix URL
+func NewBucketWriter(location string, name string, contentType string) (*bucketWriter, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	key := path.Join(strings.TrimPrefix(u.Path, "/"), name)
+	u.Path = ""
+
+	bucket, err := blob.OpenBucket(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := bucket.NewWriter(ctx, key, &blob.WriterOptions{ContentType: contentType})
+	if err != nil {
+		bucket.Close()
+		return nil, err
+	}
+
+	return &bucketWriter{bucket, writer}, nil
+}
+
+// CreateFile creates a local file, or streams an object to a bucket if
+// location is an s3://bucket/key or gs://bucket/key URL
+func CreateFile(location string, contentType string) (io.WriteCloser, error) {
+	if !IsBucketURL(location) {
+		return os.Create(location)
+	}
+
+	w, err := NewBucketWriter(location, "", contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write writes to the uploaded object
+func (w *bucketWriter) Write(p []byte) (int, error) {
+	return w.writer.Write(p)
+}
+
+// Close completes the upload and closes the bucket
+func (w *bucketWriter) Close() error {
+	err := w.writer.Close()
+	if closeErr := w.bucket.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
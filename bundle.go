@@ -0,0 +1,167 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ---------- Bundle ----------
+
+// bundle packages the results, manifest, link graph and optionally bodies of
+// a crawl into a single zstd compressed tar archive. Bodies are added as
+// they are crawled, the rest when the crawl is finished.
+type bundle struct {
+	file    io.WriteCloser
+	zw      *zstd.Encoder
+	tw      *tar.Writer
+	results *os.File
+	jsonl   *jsonlOutput
+	bodies  bool
+	added   map[string]bool
+}
+
+// NewBundle creates a bundle archive at a path, including bodies by their
+// SHA-256 if bodies is set
+func NewBundle(path string, bodies bool) (*bundle, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	file, err := CreateFile(path, "application/zstd")
+	if err != nil {
+		return nil, err
+	}
+
+	zw, err := zstd.NewWriter(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	results, err := ioutil.TempFile("", "gocrawler-bundle-")
+	if err != nil {
+		zw.Close()
+		file.Close()
+		return nil, err
+	}
+
+	jsonl, err := NewJSONLOutput(nopCloser{results}, "none", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bundle{file, zw, tar.NewWriter(zw), results, jsonl, bodies, map[string]bool{}}, nil
+}
+
+// Write adds a result to the bundle
+func (b *bundle) Write(res result) error {
+	if b == nil {
+		return nil
+	}
+
+	if b.bodies && len(res.body) != 0 {
+		sum := sha256.Sum256(res.body)
+		res.bodyHash = hex.EncodeToString(sum[:])
+		if !b.added[res.bodyHash] {
+			b.added[res.bodyHash] = true
+			if err := b.add("bodies/"+res.bodyHash, res.body); err != nil {
+				return err
+			}
+		}
+	}
+
+	return b.jsonl.Write(res)
+}
+
+// Close adds the results, manifest and link graph and closes the bundle
+func (b *bundle) Close(m *manifest, results int64, errors errorCounts, graph *linkGraph) error {
+	if b == nil {
+		return nil
+	}
+	defer os.Remove(b.results.Name())
+
+	err := b.finish(m, results, errors, graph)
+	if closeErr := b.tw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := b.zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := b.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func (b *bundle) finish(m *manifest, results int64, errors errorCounts, graph *linkGraph) error {
+	defer b.results.Close()
+
+	info, err := b.results.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := b.results.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	header := &tar.Header{Name: "results.jsonl", Mode: 0644, Size: info.Size(), ModTime: time.Now()}
+	if err := b.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(b.tw, b.results); err != nil {
+		return err
+	}
+
+	data, err := m.Encode(results, errors)
+	if err != nil {
+		return err
+	}
+	if err := b.add("manifest.json", data); err != nil {
+		return err
+	}
+
+	return b.add("links.tsv", GraphTSV(graph))
+}
+
+func (b *bundle) add(name string, data []byte) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := b.tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := b.tw.Write(data)
+	return err
+}
+
+// GraphTSV returns the internal links of a link graph as tab separated
+// source and target URLs, one link per line
+func GraphTSV(graph *linkGraph) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("from\tto\n")
+
+	edges := graph.Edges()
+	sources := make([]string, 0, len(edges))
+	for from := range edges {
+		sources = append(sources, from)
+	}
+	sort.Strings(sources)
+
+	for _, from := range sources {
+		targets := append([]string{}, edges[from]...)
+		sort.Strings(targets)
+		for _, to := range targets {
+			buffer.WriteString(graph.urls[from] + "\t" + graph.urls[to] + "\n")
+		}
+	}
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ---------- Cache ----------
+
+type cacheMode int
+
+const (
+	cacheOff cacheMode = iota
+	cacheRead
+	cacheWrite
+	cacheReadWrite
+)
+
+type entry struct {
+	url       string
+	status    int
+	header    http.Header
+	body      []byte
+	redirects []redirectHop
+}
+
+type cache struct {
+	dir     string
+	ttl     time.Duration
+	mode    cacheMode
+	store   *blobStore
+	headers bool
+}
+
+// bodyRefHeader refers to a body in the body store from a cache file
+const bodyRefHeader = "X-Gocrawler-Body-Sha256"
+
+// statusHeader records the status code of a response in a cache file
+const statusHeader = "X-Gocrawler-Status"
+
+// ParseCacheMode parses a cache mode flag value
+func ParseCacheMode(mode string) (cacheMode, error) {
+	switch mode {
+	case "off":
+		return cacheOff, nil
+	case "read":
+		return cacheRead, nil
+	case "write":
+		return cacheWrite, nil
+	case "read-write":
+		return cacheReadWrite, nil
+	}
+
+	return cacheOff, fmt.Errorf("unknown cache mode: %v", mode)
+}
+
+// NewCache creates an on-disk response cache
+func NewCache(dir string, ttl time.Duration, mode cacheMode) (*cache, error) {
+	if mode == cacheOff || len(dir) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &cache{dir: dir, ttl: ttl, mode: mode}, nil
+}
+
+// CanRead reports whether bodies may be read from the cache
+func (c *cache) CanRead() bool {
+	return c != nil && (c.mode == cacheRead || c.mode == cacheReadWrite)
+}
+
+// CanWrite reports whether bodies may be written to the cache
+func (c *cache) CanWrite() bool {
+	return c != nil && (c.mode == cacheWrite || c.mode == cacheReadWrite)
+}
+
+// Get returns a cached entry if it exists and has not expired, by its
+// caching headers if the cache respects them and they are set, otherwise by
+// the cache TTL
+func (c *cache) Get(url string) (entry, bool) {
+	finalURL, header, reader, info, ok := c.read(url)
+	if !ok {
+		return entry{}, false
+	}
+
+	ttl := c.ttl
+	if lifetime, ok := FreshnessLifetime(http.Header(header)); c.headers && ok {
+		ttl = lifetime
+		if ttl == 0 {
+			return entry{}, false
+		}
+	}
+	if ttl > 0 && time.Since(info.ModTime()) > ttl {
+		return entry{}, false
+	}
+
+	body, err := ioutil.ReadAll(reader.R)
+	if err != nil {
+		return entry{}, false
+	}
+
+	if hash := header.Get(bodyRefHeader); len(hash) != 0 {
+		if c.store == nil {
+			return entry{}, false
+		}
+		if body, err = c.store.Get(hash); err != nil {
+			return entry{}, false
+		}
+		header.Del(bodyRefHeader)
+	}
+
+	status, err := strconv.Atoi(header.Get(statusHeader))
+	if err != nil {
+		status = http.StatusOK
+	}
+	header.Del(statusHeader)
+
+	return entry{finalURL, status, http.Header(header), body, nil}, true
+}
+
+// Fresh reports whether the cached response of a URL is still fresh by its
+// Cache-Control or Expires header, ignoring the cache TTL
+func (c *cache) Fresh(url string) bool {
+	if c == nil {
+		return false
+	}
+
+	_, header, _, info, ok := c.read(url)
+	if !ok {
+		return false
+	}
+
+	lifetime, ok := FreshnessLifetime(http.Header(header))
+	return ok && time.Since(info.ModTime()) < lifetime
+}
+
+// read reads the final URL and header of a cache file, leaving the reader at
+// the body
+func (c *cache) read(url string) (string, textproto.MIMEHeader, *textproto.Reader, os.FileInfo, bool) {
+	path := c.path(url)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, nil, nil, false
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", nil, nil, nil, false
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+	finalURL, err := reader.ReadLine()
+	if err != nil {
+		return "", nil, nil, nil, false
+	}
+
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		return "", nil, nil, nil, false
+	}
+
+	return finalURL, header, reader, info, true
+}
+
+// Put stores an entry in the cache, unless it respects caching headers and
+// the response must not be stored
+func (c *cache) Put(url string, e entry) error {
+	if c.headers && contains(CacheDirectives(e.header), "no-store") {
+		return nil
+	}
+
+	tmp, err := ioutil.TempFile(c.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+
+	header, body := e.header.Clone(), e.body
+	header.Set(statusHeader, strconv.Itoa(e.status))
+	if c.store != nil {
+		hash, err := c.store.Put(e.body)
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		header.Set(bodyRefHeader, hash)
+		body = nil
+	}
+
+	var data bytes.Buffer
+	data.WriteString(e.url + "\r\n")
+	header.Write(&data)
+	data.WriteString("\r\n")
+	data.Write(body)
+
+	if _, err := tmp.Write(data.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(url))
+}
+
+func (c *cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseCacheMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		want cacheMode
+		ok   bool
+	}{
+		{"off", cacheOff, true},
+		{"read", cacheRead, true},
+		{"write", cacheWrite, true},
+		{"read-write", cacheReadWrite, true},
+		{"rw", cacheOff, false},
+		{"", cacheOff, false},
+	}
+
+	for _, test := range tests {
+		mode, err := ParseCacheMode(test.mode)
+		if mode != test.want || (err == nil) != test.ok {
+			t.Errorf("ParseCacheMode(%q) = %v, %v, want %v, ok %v", test.mode, mode, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestCacheModes(t *testing.T) {
+	tests := []struct {
+		mode     cacheMode
+		dir      string
+		canRead  bool
+		canWrite bool
+	}{
+		{cacheOff, t.TempDir(), false, false},
+		{cacheRead, t.TempDir(), true, false},
+		{cacheWrite, t.TempDir(), false, true},
+		{cacheReadWrite, t.TempDir(), true, true},
+		{cacheReadWrite, "", false, false},
+	}
+
+	for _, test := range tests {
+		c, err := NewCache(test.dir, time.Hour, test.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.CanRead() != test.canRead || c.CanWrite() != test.canWrite {
+			t.Errorf("cache mode %v in %q: read %v, write %v, want %v, %v",
+				test.mode, test.dir, c.CanRead(), c.CanWrite(), test.canRead, test.canWrite)
+		}
+	}
+}
+
+func TestCacheStoreAndReload(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewCache(dir, time.Hour, cacheReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.Get("http://example.com/"); ok {
+		t.Fatal("empty cache returned an entry")
+	}
+	stored := entry{
+		url:    "http://example.com/home",
+		header: http.Header{"Content-Type": {"text/html"}},
+		body:   []byte("<p>home</p>"),
+	}
+	if err := c.Put("http://example.com/", stored); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new cache over the same directory sees bodies of earlier runs
+	reloaded, err := NewCache(dir, time.Hour, cacheRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := reloaded.Get("http://example.com/")
+	if !ok || e.url != stored.url || string(e.body) != string(stored.body) || e.header.Get("Content-Type") != "text/html" {
+		t.Errorf("Get after reload = %+v, %v, want %+v, true", e, ok, stored)
+	}
+	if _, ok := reloaded.Get("http://example.com/other"); ok {
+		t.Error("Get of an uncached URL returned an entry")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		ttl   time.Duration
+		age   time.Duration
+		fresh bool
+	}{
+		{time.Hour, time.Minute, true},
+		{time.Hour, 2 * time.Hour, false},
+		{0, 1000 * time.Hour, true},
+	}
+
+	for _, test := range tests {
+		c, err := NewCache(t.TempDir(), test.ttl, cacheReadWrite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Put("http://example.com/", entry{url: "http://example.com/", header: http.Header{}, body: []byte("body")}); err != nil {
+			t.Fatal(err)
+		}
+		stored := time.Now().Add(-test.age)
+		if err := os.Chtimes(c.path("http://example.com/"), stored, stored); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := c.Get("http://example.com/"); ok != test.fresh {
+			t.Errorf("Get with TTL %v of a body stored %v ago = %v, want %v", test.ttl, test.age, ok, test.fresh)
+		}
+	}
+}
+
+func TestFreshSkipper(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		age    time.Duration
+		skip   bool
+	}{
+		{http.Header{"Cache-Control": {"max-age=3600"}}, time.Minute, true},
+		{http.Header{"Cache-Control": {"max-age=3600"}}, 2 * time.Hour, false},
+		{http.Header{"Cache-Control": {"no-cache"}}, 0, false},
+		// Without caching headers the cache TTL does not make a URL fresh
+		{http.Header{}, 0, false},
+	}
+
+	for _, test := range tests {
+		c, err := NewCache(t.TempDir(), time.Hour, cacheReadWrite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Put("http://example.com/", entry{url: "http://example.com/", header: test.header, body: []byte("body")}); err != nil {
+			t.Fatal(err)
+		}
+		stored := time.Now().Add(-test.age)
+		if err := os.Chtimes(c.path("http://example.com/"), stored, stored); err != nil {
+			t.Fatal(err)
+		}
+
+		f := NewFreshSkipper(c)
+		if skip := f.Skip("http://example.com/"); skip != test.skip {
+			t.Errorf("Skip of %v stored %v ago = %v, want %v", test.header, test.age, skip, test.skip)
+		}
+		if f.Skip("http://example.com/other") {
+			t.Errorf("Skip of an uncached URL = true, want false")
+		}
+		if skipped := f.skipped.Load(); (skipped == 1) != test.skip {
+			t.Errorf("skipped = %v after Skip = %v", skipped, test.skip)
+		}
+	}
+}
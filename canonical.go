@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ---------- Canonical ----------
+
+// URLKey maps a URL to the key used to detect already visited sites.
+// Override it to change how URLs are deduplicated.
+var URLKey = CanonicalKey
+
+// CanonicalKey builds a key from the scheme, host, normalized path and
+// sorted query of a URL
+func CanonicalKey(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+	if (scheme == "http" && strings.HasSuffix(host, ":80")) ||
+		(scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+
+	p := u.EscapedPath()
+	if len(p) == 0 {
+		p = "/"
+	} else {
+		trailing := strings.HasSuffix(p, "/")
+		p = path.Clean(p)
+		if trailing && p != "/" {
+			p += "/"
+		}
+	}
+
+	key := scheme + "://" + host + p
+	if query := SortQuery(u.RawQuery); len(query) != 0 {
+		key += "?" + query
+	}
+
+	return key
+}
+
+// TrackingParams lists query parameters stripped from URLs before they are
+// crawled. A trailing * matches any suffix.
+var TrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "gclsrc", "dclid", "msclkid",
+	"mc_cid", "mc_eid", "_ga", "phpsessid", "jsessionid", "sessionid",
+}
+
+var jsessionid = regexp.MustCompile(`(?i);jsessionid=[^?#/]*`)
+
+// StripTracking removes tracking and session parameters from a URL
+func StripTracking(link string) string {
+	if len(TrackingParams) == 0 {
+		return link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	if IsTrackingParam("jsessionid") {
+		u.Path = jsessionid.ReplaceAllString(u.Path, "")
+		u.RawPath = jsessionid.ReplaceAllString(u.RawPath, "")
+	}
+
+	if len(u.RawQuery) != 0 {
+		var kept []string
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			name := strings.SplitN(param, "=", 2)[0]
+			if unescaped, err := url.QueryUnescape(name); err == nil {
+				name = unescaped
+			}
+			if !IsTrackingParam(name) {
+				kept = append(kept, param)
+			}
+		}
+		u.RawQuery = strings.Join(kept, "&")
+	}
+
+	return u.String()
+}
+
+// IsTrackingParam reports whether a query parameter is in TrackingParams
+func IsTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, param := range TrackingParams {
+		param = strings.ToLower(param)
+		if strings.HasSuffix(param, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(param, "*")) {
+				return true
+			}
+		} else if name == param {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SortQuery sorts the parameters of a raw query string
+func SortQuery(rawQuery string) string {
+	if len(rawQuery) == 0 {
+		return ""
+	}
+
+	params := strings.Split(rawQuery, "&")
+	sort.Strings(params)
+
+	var kept []string
+	for _, param := range params {
+		if len(param) != 0 {
+			kept = append(kept, param)
+		}
+	}
+
+	return strings.Join(kept, "&")
+}
+
+// ---------- Canonical tags ----------
+
+type canonicalConflict struct {
+	page      string
+	canonical string
+	reason    string
+}
+
+type canonicalChecker map[string]result
+
+// Add records a crawled page
+func (c canonicalChecker) Add(res result) {
+	c[URLKey(res.url)] = res
+}
+
+// Conflicts returns pages whose canonical points to a broken, redirected,
+// noindex or non-canonical page
+func (c canonicalChecker) Conflicts() []canonicalConflict {
+	var conflicts []canonicalConflict
+	for key, res := range c {
+		if len(res.canonical) == 0 || URLKey(res.canonical) == key {
+			continue
+		}
+
+		target, ok := c[URLKey(res.canonical)]
+		if !ok {
+			continue
+		}
+
+		reason := ""
+		switch {
+		case target.errClass != errorNone:
+			reason = fmt.Sprintf("target failed (%v)", target.errClass)
+		case len(target.redirect) != 0:
+			reason = fmt.Sprintf("target redirects to %v", target.redirect)
+		case target.noindex:
+			reason = "target is noindex"
+		case len(target.canonical) != 0 && URLKey(target.canonical) != URLKey(target.url):
+			reason = fmt.Sprintf("target canonicalizes to %v", target.canonical)
+		default:
+			continue
+		}
+
+		conflicts = append(conflicts, canonicalConflict{res.url, res.canonical, reason})
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].page < conflicts[j].page
+	})
+
+	return conflicts
+}
+
+// Print prints canonical conflicts
+func (c canonicalChecker) Print() {
+	for _, conflict := range c.Conflicts() {
+		fmt.Fprintf(logOutput, "Canonical: %v -> %v: %v\n", conflict.page, conflict.canonical, conflict.reason)
+	}
+}
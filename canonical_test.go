@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestCanonicalKey(t *testing.T) {
+	tests := []struct {
+		link string
+		key  string
+	}{
+		{"http://example.com", "http://example.com/"},
+		{"HTTP://Example.COM/Path", "http://example.com/Path"},
+		{"http://example.com:80/", "http://example.com/"},
+		{"https://example.com:443/", "https://example.com/"},
+		{"https://example.com:8443/", "https://example.com:8443/"},
+		{"http://example.com/a/./b/../c", "http://example.com/a/c"},
+		{"http://example.com/a//b/", "http://example.com/a/b/"},
+		{"http://example.com/?b=2&a=1", "http://example.com/?a=1&b=2"},
+		{"http://example.com/?b=2&&a=1", "http://example.com/?a=1&b=2"},
+		{"http://example.com/#top", "http://example.com/"},
+		{"http://example.com/a%20b", "http://example.com/a%20b"},
+		{"://bad", "://bad"},
+	}
+
+	for _, test := range tests {
+		if key := CanonicalKey(test.link); key != test.key {
+			t.Errorf("CanonicalKey(%q) = %q, want %q", test.link, key, test.key)
+		}
+	}
+}
+
+func TestStripTracking(t *testing.T) {
+	tests := []struct {
+		link     string
+		stripped string
+	}{
+		{"http://example.com/", "http://example.com/"},
+		{"http://example.com/?utm_source=x&utm_medium=y", "http://example.com/"},
+		{"http://example.com/?id=1&utm_source=x", "http://example.com/?id=1"},
+		{"http://example.com/?fbclid=1&id=2&gclid=3", "http://example.com/?id=2"},
+		{"http://example.com/?UTM_Source=x&id=1", "http://example.com/?id=1"},
+		{"http://example.com/?utm%5Fsource=x&id=1", "http://example.com/?id=1"},
+		{"http://example.com/?PHPSESSID=abc&page=2", "http://example.com/?page=2"},
+		{"http://example.com/cart;jsessionid=ABC123?item=1", "http://example.com/cart?item=1"},
+		{"http://example.com/?utm=1", "http://example.com/?utm=1"},
+		{"http://example.com/?b=2&a=1", "http://example.com/?b=2&a=1"},
+		{"://bad?utm_source=x", "://bad?utm_source=x"},
+	}
+
+	for _, test := range tests {
+		if stripped := StripTracking(test.link); stripped != test.stripped {
+			t.Errorf("StripTracking(%q) = %q, want %q", test.link, stripped, test.stripped)
+		}
+	}
+}
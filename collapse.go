@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ---------- Parameter collapse ----------
+
+// paramGroup is the content seen for the values of a query parameter on
+// otherwise identical URLs
+type paramGroup struct {
+	hash   string
+	values map[string]bool
+	mixed  bool
+}
+
+// paramLearner learns which query parameters of a host do not change the
+// content of its pages
+type paramLearner struct {
+	mutex     sync.Mutex
+	threshold int
+	groups    map[string]*paramGroup
+	learned   map[string]map[string]int
+}
+
+// NewParamLearner creates a learner that ignores a parameter once threshold
+// of its values return the same content, or nil for threshold <= 0
+func NewParamLearner(threshold int) *paramLearner {
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &paramLearner{threshold: threshold, groups: map[string]*paramGroup{}, learned: map[string]map[string]int{}}
+}
+
+// Observe records the content fetched for a URL
+func (l *paramLearner) Observe(link string, body []byte) {
+	if l == nil {
+		return
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || len(u.RawQuery) == 0 {
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	params := u.Query()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for name, values := range params {
+		if l.learned[u.Host][name] != 0 || len(values) != 1 {
+			continue
+		}
+
+		without := *u
+		rest := url.Values{}
+		for other, vals := range params {
+			if other != name {
+				rest[other] = vals
+			}
+		}
+		without.RawQuery = rest.Encode()
+
+		key := u.Host + "\x00" + name + "\x00" + URLKey(without.String())
+		group, ok := l.groups[key]
+		if !ok {
+			group = &paramGroup{hash: hash, values: map[string]bool{}}
+			l.groups[key] = group
+		}
+		group.mixed = group.mixed || group.hash != hash
+		group.values[values[0]] = true
+
+		if !group.mixed && len(group.values) >= l.threshold {
+			if l.learned[u.Host] == nil {
+				l.learned[u.Host] = map[string]int{}
+			}
+			l.learned[u.Host][name] = len(group.values)
+		}
+	}
+}
+
+// Strip removes the parameters learned for the host of a URL
+func (l *paramLearner) Strip(link string) string {
+	if l == nil {
+		return link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || len(u.RawQuery) == 0 {
+		return link
+	}
+
+	l.mutex.Lock()
+	learned := l.learned[u.Host]
+	var kept []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		name := strings.SplitN(param, "=", 2)[0]
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if learned[name] == 0 {
+			kept = append(kept, param)
+		}
+	}
+	l.mutex.Unlock()
+
+	if len(kept) == len(strings.Split(u.RawQuery, "&")) {
+		return link
+	}
+	u.RawQuery = strings.Join(kept, "&")
+
+	return u.String()
+}
+
+// Print prints the learned parameters by host
+func (l *paramLearner) Print() {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var rules []string
+	for host, params := range l.learned {
+		for name, values := range params {
+			rules = append(rules, fmt.Sprintf("Learned: %v ignores %v (%v values with the same content)", host, name, values))
+		}
+	}
+	sort.Strings(rules)
+
+	for _, rule := range rules {
+		fmt.Fprintln(logOutput, rule)
+	}
+}
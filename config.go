@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ---------- Config ----------
+
+type headerRule struct {
+	Match     string            `json:"match"`
+	UserAgent string            `json:"user_agent"`
+	Headers   map[string]string `json:"headers"`
+}
+
+type config struct {
+	Headers    []headerRule `json:"headers"`
+	Assertions []assertion  `json:"assertions"`
+	Rules      crawlRules   `json:"rules"`
+}
+
+// LoadConfig reads a JSON config file
+func LoadConfig(path string) (config, error) {
+	var c config
+	if len(path) == 0 {
+		return c, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return c, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		return c, err
+	}
+
+	return c, c.Rules.compile()
+}
+
+// ApplyHeaders sets the headers of every rule matching the request URL,
+// later rules overriding earlier ones
+func ApplyHeaders(req *http.Request, rules []headerRule) {
+	for _, rule := range rules {
+		if !MatchPattern(rule.Match, req.URL) {
+			continue
+		}
+
+		if len(rule.UserAgent) != 0 {
+			req.Header.Set("User-Agent", rule.UserAgent)
+		}
+		for key, value := range rule.Headers {
+			req.Header.Set(key, value)
+		}
+	}
+}
+
+// MatchPattern matches a URL against a pattern where * matches any
+// characters. Patterns without a slash match the host, patterns with a
+// leading slash match the path and other patterns match host and path.
+func MatchPattern(pattern string, u *neturl.URL) bool {
+	subject := u.Host + u.Path
+	switch {
+	case !strings.Contains(pattern, "/"):
+		subject = u.Host
+	case strings.HasPrefix(pattern, "/"):
+		subject = u.Path
+	}
+
+	quoted := strings.Split(pattern, "*")
+	for i := range quoted {
+		quoted[i] = regexp.QuoteMeta(quoted[i])
+	}
+
+	matched, _ := regexp.MatchString("^(?i:"+strings.Join(quoted, ".*")+")$", subject)
+	return matched
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	neturl "net/url"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		link    string
+		match   bool
+	}{
+		{"example.com", "http://example.com/a", true},
+		{"example.com", "http://www.example.com/", false},
+		{"*.example.com", "http://www.example.com/", true},
+		{"*.example.com", "http://example.com/", false},
+		{"EXAMPLE.com", "http://example.com/", true},
+		{"/blog/*", "http://example.com/blog/post", true},
+		{"/blog/*", "http://other.com/blog/", true},
+		{"/blog/*", "http://example.com/news/blog/", false},
+		{"/blog", "http://example.com/blog/post", false},
+		{"example.com/shop/*", "http://example.com/shop/cart", true},
+		{"example.com/shop/*", "http://other.com/shop/cart", false},
+		{"/a.b", "http://example.com/axb", false},
+		{"/*.pdf", "http://example.com/files/report.pdf", true},
+	}
+
+	for _, test := range tests {
+		u, err := neturl.Parse(test.link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match := MatchPattern(test.pattern, u); match != test.match {
+			t.Errorf("MatchPattern(%q, %q) = %v, want %v", test.pattern, test.link, match, test.match)
+		}
+	}
+}
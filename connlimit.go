@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ---------- Connection limits ----------
+
+// connLimiter bounds the number of open connections of a transport. Dials
+// wait for a connection to close, and also while the process is close to
+// its file descriptor limit, instead of failing with too many open files.
+type connLimiter struct {
+	mutex     sync.Mutex
+	max       int
+	open      int
+	fdLimit   int
+	transport *http.Transport
+	verbose   bool
+}
+
+type limitedConn struct {
+	net.Conn
+	limiter *connLimiter
+	once    sync.Once
+}
+
+// fdPoll is how often a dial waiting for file descriptors checks again
+const fdPoll = 100 * time.Millisecond
+
+// LimitConnections limits the open connections of a client to max in total
+// and perHost per host, where zero means no limit. Dials are also held back
+// while more than nine tenths of the file descriptor limit is in use.
+func LimitConnections(client *http.Client, max int, perHost int, verbose bool) {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	transport.MaxConnsPerHost = perHost
+
+	l := &connLimiter{max: max, fdLimit: FileLimit(), transport: transport, verbose: verbose}
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	transport.DialContext = l.wrap(dial)
+	if transport.DialTLSContext != nil {
+		transport.DialTLSContext = l.wrap(transport.DialTLSContext)
+	}
+	client.Transport = transport
+}
+
+func (l *connLimiter) wrap(dial func(ctx context.Context, network string, addr string) (net.Conn, error)) func(ctx context.Context, network string, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		if err := l.acquire(ctx); err != nil {
+			return nil, err
+		}
+
+		for {
+			conn, err := dial(ctx, network, addr)
+			if err == nil {
+				return &limitedConn{Conn: conn, limiter: l}, nil
+			}
+			if !errors.Is(err, syscall.EMFILE) && !errors.Is(err, syscall.ENFILE) {
+				l.release()
+				return nil, err
+			}
+
+			if l.verbose {
+				fmt.Fprintf(logOutput, "Out of file descriptors, waiting to dial %v\n", addr)
+			}
+			l.transport.CloseIdleConnections()
+			select {
+			case <-ctx.Done():
+				l.release()
+				return nil, ctx.Err()
+			case <-time.After(fdPoll):
+			}
+		}
+	}
+}
+
+// acquire waits for a free connection slot and file descriptors. Idle
+// connections are closed while waiting since they would otherwise hold
+// slots other hosts need.
+func (l *connLimiter) acquire(ctx context.Context) error {
+	for {
+		l.mutex.Lock()
+		full := l.max > 0 && l.open >= l.max
+		if !full && !l.exhausted() {
+			l.open++
+			l.mutex.Unlock()
+			return nil
+		}
+		l.mutex.Unlock()
+
+		l.transport.CloseIdleConnections()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(fdPoll):
+		}
+	}
+}
+
+func (l *connLimiter) release() {
+	l.mutex.Lock()
+	l.open--
+	l.mutex.Unlock()
+}
+
+// exhausted reports whether nine tenths of the file descriptor limit are in
+// use, if both are known
+func (l *connLimiter) exhausted() bool {
+	if l.fdLimit <= 0 {
+		return false
+	}
+
+	open := OpenFiles()
+	return open >= 0 && open >= l.fdLimit/10*9
+}
+
+// Close closes the connection and frees its slot
+func (c *limitedConn) Close() error {
+	c.once.Do(c.limiter.release)
+	return c.Conn.Close()
+}
+
+// OpenFiles returns the number of open file descriptors of the process, or
+// -1 if it cannot be counted
+func OpenFiles() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+
+	return len(entries)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ---------- Contacts ----------
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+
+// phonePattern matches numbers in international form or with an area code
+// in parentheses, to avoid matching dates and other numbers in text
+var phonePattern = regexp.MustCompile(`(?:\+|\b00)\d{1,3}[\s.-]?(?:\(\d+\)[\s.-]?)?\d[\d\s.-]{5,}\d|\(\d{2,5}\)\s?\d[\d\s.-]{4,}\d`)
+
+// ExtractContacts returns the email addresses and phone numbers of a page
+// found in mailto: and tel: links and in its text
+func ExtractContacts(res result) ([]string, []string) {
+	emails := map[string]bool{}
+	phones := map[string]bool{}
+
+	for _, anc := range res.anchors {
+		scheme := strings.ToLower(strings.SplitN(anc.url, ":", 2)[0])
+		value := strings.SplitN(strings.TrimPrefix(anc.url[len(scheme):], ":"), "?", 2)[0]
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+
+		switch scheme {
+		case "mailto":
+			for _, addr := range strings.Split(value, ",") {
+				if addr = strings.TrimSpace(addr); len(addr) != 0 {
+					emails[strings.ToLower(addr)] = true
+				}
+			}
+		case "tel":
+			if phone := NormalizePhone(value); len(phone) != 0 {
+				phones[phone] = true
+			}
+		}
+	}
+
+	for _, addr := range emailPattern.FindAllString(res.text, -1) {
+		emails[strings.ToLower(addr)] = true
+	}
+	for _, number := range phonePattern.FindAllString(res.text, -1) {
+		if phone := NormalizePhone(number); len(phone) != 0 {
+			phones[phone] = true
+		}
+	}
+
+	return sortedKeys(emails), sortedKeys(phones)
+}
+
+// NormalizePhone keeps the digits and a leading plus of a phone number
+func NormalizePhone(number string) string {
+	number = strings.TrimSpace(number)
+	var b strings.Builder
+	if strings.HasPrefix(number, "+") {
+		b.WriteByte('+')
+	} else if strings.HasPrefix(number, "00") {
+		b.WriteByte('+')
+		number = number[2:]
+	}
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+
+	if b.Len() < 7 {
+		return ""
+	}
+
+	return b.String()
+}
+
+type contactIndex struct {
+	emails map[string]map[string]bool
+	phones map[string]map[string]bool
+}
+
+// Add records the contacts found on a page
+func (c *contactIndex) Add(res result) {
+	if c.emails == nil {
+		c.emails = map[string]map[string]bool{}
+		c.phones = map[string]map[string]bool{}
+	}
+
+	for _, email := range res.emails {
+		if c.emails[email] == nil {
+			c.emails[email] = map[string]bool{}
+		}
+		c.emails[email][res.url] = true
+	}
+	for _, phone := range res.phones {
+		if c.phones[phone] == nil {
+			c.phones[phone] = map[string]bool{}
+		}
+		c.phones[phone][res.url] = true
+	}
+}
+
+// Print prints every contact and the pages it was found on
+func (c *contactIndex) Print() {
+	printContacts("Email", c.emails)
+	printContacts("Phone", c.phones)
+}
+
+func printContacts(kind string, contacts map[string]map[string]bool) {
+	keys := map[string]bool{}
+	for contact := range contacts {
+		keys[contact] = true
+	}
+
+	for _, contact := range sortedKeys(keys) {
+		fmt.Fprintf(logOutput, "%v: %v (%v pages)\n", kind, contact, len(contacts[contact]))
+		for _, page := range sortedKeys(contacts[contact]) {
+			fmt.Fprintf(logOutput, "  %v\n", page)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ---------- Cookies ----------
+
+// ResponseCookies returns the cookies set by response headers
+func ResponseCookies(header http.Header) []*http.Cookie {
+	return (&http.Response{Header: header}).Cookies()
+}
+
+// PrintCookies prints the cookies set by a page and whether they are first-party
+func PrintCookies(res result) {
+	if len(res.cookies) == 0 {
+		return
+	}
+
+	host := hostOf(res.url)
+	first, third := 0, 0
+	for _, c := range res.cookies {
+		if IsFirstParty(c, host) {
+			first++
+		} else {
+			third++
+		}
+	}
+
+	fmt.Fprintf(logOutput, "Cookies: %v (first-party: %v, third-party: %v)\n", res.url, first, third)
+	for _, c := range res.cookies {
+		domain := c.Domain
+		if len(domain) == 0 {
+			domain = host
+		}
+
+		party := "first-party"
+		if !IsFirstParty(c, host) {
+			party = "third-party"
+		}
+
+		fmt.Fprintf(logOutput, "  %v domain=%v expires=%v samesite=%v secure=%v httponly=%v (%v)\n",
+			c.Name, domain, cookieExpiry(c), sameSiteName(c.SameSite), c.Secure, c.HttpOnly, party)
+	}
+}
+
+// IsFirstParty reports whether a cookie belongs to the site of a host
+func IsFirstParty(c *http.Cookie, host string) bool {
+	if len(c.Domain) == 0 {
+		return true
+	}
+
+	return SiteDomain(strings.TrimPrefix(c.Domain, ".")) == SiteDomain(host)
+}
+
+func cookieExpiry(c *http.Cookie) string {
+	switch {
+	case c.MaxAge < 0:
+		return "deleted"
+	case c.MaxAge > 0:
+		return fmt.Sprintf("max-age %vs", c.MaxAge)
+	case !c.Expires.IsZero():
+		return c.Expires.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	return "session"
+}
+
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+
+	return "unset"
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsFirstParty(t *testing.T) {
+	tests := []struct {
+		domain string
+		host   string
+		first  bool
+	}{
+		{"", "www.example.com", true},
+		{"example.com", "www.example.com", true},
+		{".example.com", "shop.example.com", true},
+		{"static.example.com", "www.example.com", true},
+		{"tracker.net", "www.example.com", false},
+		{"example.co.uk", "www.example.co.uk", true},
+		{"other.co.uk", "www.example.co.uk", false},
+	}
+
+	for _, test := range tests {
+		c := &http.Cookie{Name: "id", Domain: test.domain}
+		if first := IsFirstParty(c, test.host); first != test.first {
+			t.Errorf("IsFirstParty(domain=%q, %q) = %v, want %v", test.domain, test.host, first, test.first)
+		}
+	}
+}
@@ -0,0 +1,1004 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: coordinator.proto
+
+package main
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type JoinRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinRequest) Reset() {
+	*x = JoinRequest{}
+	mi := &file_coordinator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinRequest) ProtoMessage() {}
+
+func (x *JoinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinRequest.ProtoReflect.Descriptor instead.
+func (*JoinRequest) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *JoinRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// JoinReply sets up a worker to crawl like the coordinator was told to,
+// including its identity and politeness settings
+type JoinReply struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Worker    string                 `protobuf:"bytes,1,opt,name=worker,proto3" json:"worker,omitempty"`
+	Heartbeat *durationpb.Duration   `protobuf:"bytes,2,opt,name=heartbeat,proto3" json:"heartbeat,omitempty"`
+	Css       bool                   `protobuf:"varint,3,opt,name=css,proto3" json:"css,omitempty"`
+	Images    bool                   `protobuf:"varint,4,opt,name=images,proto3" json:"images,omitempty"`
+	Js        bool                   `protobuf:"varint,5,opt,name=js,proto3" json:"js,omitempty"`
+	MaxBody   int64                  `protobuf:"varint,6,opt,name=max_body,json=maxBody,proto3" json:"max_body,omitempty"`
+	Agent     string                 `protobuf:"bytes,7,opt,name=agent,proto3" json:"agent,omitempty"`
+	From      string                 `protobuf:"bytes,8,opt,name=from,proto3" json:"from,omitempty"`
+	Robots    bool                   `protobuf:"varint,9,opt,name=robots,proto3" json:"robots,omitempty"`
+	HostDelay *durationpb.Duration   `protobuf:"bytes,10,opt,name=host_delay,json=hostDelay,proto3" json:"host_delay,omitempty"`
+	// Bandwidth is in bytes per second, 0 for no limit
+	Bandwidth     float64       `protobuf:"fixed64,11,opt,name=bandwidth,proto3" json:"bandwidth,omitempty"`
+	Headers       []*HeaderRule `protobuf:"bytes,12,rep,name=headers,proto3" json:"headers,omitempty"`
+	Rules         []*CrawlRule  `protobuf:"bytes,13,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinReply) Reset() {
+	*x = JoinReply{}
+	mi := &file_coordinator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinReply) ProtoMessage() {}
+
+func (x *JoinReply) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinReply.ProtoReflect.Descriptor instead.
+func (*JoinReply) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *JoinReply) GetWorker() string {
+	if x != nil {
+		return x.Worker
+	}
+	return ""
+}
+
+func (x *JoinReply) GetHeartbeat() *durationpb.Duration {
+	if x != nil {
+		return x.Heartbeat
+	}
+	return nil
+}
+
+func (x *JoinReply) GetCss() bool {
+	if x != nil {
+		return x.Css
+	}
+	return false
+}
+
+func (x *JoinReply) GetImages() bool {
+	if x != nil {
+		return x.Images
+	}
+	return false
+}
+
+func (x *JoinReply) GetJs() bool {
+	if x != nil {
+		return x.Js
+	}
+	return false
+}
+
+func (x *JoinReply) GetMaxBody() int64 {
+	if x != nil {
+		return x.MaxBody
+	}
+	return 0
+}
+
+func (x *JoinReply) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *JoinReply) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *JoinReply) GetRobots() bool {
+	if x != nil {
+		return x.Robots
+	}
+	return false
+}
+
+func (x *JoinReply) GetHostDelay() *durationpb.Duration {
+	if x != nil {
+		return x.HostDelay
+	}
+	return nil
+}
+
+func (x *JoinReply) GetBandwidth() float64 {
+	if x != nil {
+		return x.Bandwidth
+	}
+	return 0
+}
+
+func (x *JoinReply) GetHeaders() []*HeaderRule {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *JoinReply) GetRules() []*CrawlRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+// HeaderRule and CrawlRule are the headers and rules of a -config file
+type HeaderRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Match         string                 `protobuf:"bytes,1,opt,name=match,proto3" json:"match,omitempty"`
+	UserAgent     string                 `protobuf:"bytes,2,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	Headers       map[string]string      `protobuf:"bytes,3,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeaderRule) Reset() {
+	*x = HeaderRule{}
+	mi := &file_coordinator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeaderRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeaderRule) ProtoMessage() {}
+
+func (x *HeaderRule) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeaderRule.ProtoReflect.Descriptor instead.
+func (*HeaderRule) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HeaderRule) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+func (x *HeaderRule) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *HeaderRule) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+type CrawlRule struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Match          string                 `protobuf:"bytes,1,opt,name=match,proto3" json:"match,omitempty"`
+	Depth          *int32                 `protobuf:"varint,2,opt,name=depth,proto3,oneof" json:"depth,omitempty"`
+	Delay          string                 `protobuf:"bytes,3,opt,name=delay,proto3" json:"delay,omitempty"`
+	Headers        map[string]string      `protobuf:"bytes,4,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Accept         string                 `protobuf:"bytes,5,opt,name=accept,proto3" json:"accept,omitempty"`
+	AcceptLanguage string                 `protobuf:"bytes,6,opt,name=accept_language,json=acceptLanguage,proto3" json:"accept_language,omitempty"`
+	Method         string                 `protobuf:"bytes,7,opt,name=method,proto3" json:"method,omitempty"`
+	Body           string                 `protobuf:"bytes,8,opt,name=body,proto3" json:"body,omitempty"`
+	Parser         string                 `protobuf:"bytes,9,opt,name=parser,proto3" json:"parser,omitempty"`
+	StoreBody      *bool                  `protobuf:"varint,10,opt,name=store_body,json=storeBody,proto3,oneof" json:"store_body,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CrawlRule) Reset() {
+	*x = CrawlRule{}
+	mi := &file_coordinator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrawlRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrawlRule) ProtoMessage() {}
+
+func (x *CrawlRule) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrawlRule.ProtoReflect.Descriptor instead.
+func (*CrawlRule) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CrawlRule) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+func (x *CrawlRule) GetDepth() int32 {
+	if x != nil && x.Depth != nil {
+		return *x.Depth
+	}
+	return 0
+}
+
+func (x *CrawlRule) GetDelay() string {
+	if x != nil {
+		return x.Delay
+	}
+	return ""
+}
+
+func (x *CrawlRule) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *CrawlRule) GetAccept() string {
+	if x != nil {
+		return x.Accept
+	}
+	return ""
+}
+
+func (x *CrawlRule) GetAcceptLanguage() string {
+	if x != nil {
+		return x.AcceptLanguage
+	}
+	return ""
+}
+
+func (x *CrawlRule) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *CrawlRule) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *CrawlRule) GetParser() string {
+	if x != nil {
+		return x.Parser
+	}
+	return ""
+}
+
+func (x *CrawlRule) GetStoreBody() bool {
+	if x != nil && x.StoreBody != nil {
+		return *x.StoreBody
+	}
+	return false
+}
+
+type LeaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Worker        string                 `protobuf:"bytes,1,opt,name=worker,proto3" json:"worker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseRequest) Reset() {
+	*x = LeaseRequest{}
+	mi := &file_coordinator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseRequest) ProtoMessage() {}
+
+func (x *LeaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseRequest.ProtoReflect.Descriptor instead.
+func (*LeaseRequest) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LeaseRequest) GetWorker() string {
+	if x != nil {
+		return x.Worker
+	}
+	return ""
+}
+
+type CrawlTask struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Depth         int32                  `protobuf:"varint,3,opt,name=depth,proto3" json:"depth,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CrawlTask) Reset() {
+	*x = CrawlTask{}
+	mi := &file_coordinator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrawlTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrawlTask) ProtoMessage() {}
+
+func (x *CrawlTask) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrawlTask.ProtoReflect.Descriptor instead.
+func (*CrawlTask) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CrawlTask) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *CrawlTask) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CrawlTask) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+type LeaseReply struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Task is unset when no site is queued for now
+	Task *CrawlTask `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	// Done is set once the crawl is finished
+	Done          bool `protobuf:"varint,2,opt,name=done,proto3" json:"done,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseReply) Reset() {
+	*x = LeaseReply{}
+	mi := &file_coordinator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseReply) ProtoMessage() {}
+
+func (x *LeaseReply) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseReply.ProtoReflect.Descriptor instead.
+func (*LeaseReply) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LeaseReply) GetTask() *CrawlTask {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+func (x *LeaseReply) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+type HeartbeatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Worker        string                 `protobuf:"bytes,1,opt,name=worker,proto3" json:"worker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_coordinator_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HeartbeatRequest) GetWorker() string {
+	if x != nil {
+		return x.Worker
+	}
+	return ""
+}
+
+type HeartbeatReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatReply) Reset() {
+	*x = HeartbeatReply{}
+	mi := &file_coordinator_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatReply) ProtoMessage() {}
+
+func (x *HeartbeatReply) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatReply.ProtoReflect.Descriptor instead.
+func (*HeartbeatReply) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{8}
+}
+
+type CompleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Worker        string                 `protobuf:"bytes,1,opt,name=worker,proto3" json:"worker,omitempty"`
+	Task          int64                  `protobuf:"varint,2,opt,name=task,proto3" json:"task,omitempty"`
+	Result        *WorkerResult          `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompleteRequest) Reset() {
+	*x = CompleteRequest{}
+	mi := &file_coordinator_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteRequest) ProtoMessage() {}
+
+func (x *CompleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteRequest.ProtoReflect.Descriptor instead.
+func (*CompleteRequest) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CompleteRequest) GetWorker() string {
+	if x != nil {
+		return x.Worker
+	}
+	return ""
+}
+
+func (x *CompleteRequest) GetTask() int64 {
+	if x != nil {
+		return x.Task
+	}
+	return 0
+}
+
+func (x *CompleteRequest) GetResult() *WorkerResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type CompleteReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompleteReply) Reset() {
+	*x = CompleteReply{}
+	mi := &file_coordinator_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteReply) ProtoMessage() {}
+
+func (x *CompleteReply) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteReply.ProtoReflect.Descriptor instead.
+func (*CompleteReply) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{10}
+}
+
+// WorkerResult is the part of a result sent from workers to the coordinator
+type WorkerResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Error is the error class, empty on success
+	Error         string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Status        int32    `protobuf:"varint,3,opt,name=status,proto3" json:"status,omitempty"`
+	Redirect      string   `protobuf:"bytes,4,opt,name=redirect,proto3" json:"redirect,omitempty"`
+	Canonical     string   `protobuf:"bytes,5,opt,name=canonical,proto3" json:"canonical,omitempty"`
+	Noindex       bool     `protobuf:"varint,6,opt,name=noindex,proto3" json:"noindex,omitempty"`
+	Lang          string   `protobuf:"bytes,7,opt,name=lang,proto3" json:"lang,omitempty"`
+	Title         string   `protobuf:"bytes,8,opt,name=title,proto3" json:"title,omitempty"`
+	ContentType   string   `protobuf:"bytes,9,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Links         []string `protobuf:"bytes,10,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkerResult) Reset() {
+	*x = WorkerResult{}
+	mi := &file_coordinator_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkerResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkerResult) ProtoMessage() {}
+
+func (x *WorkerResult) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinator_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkerResult.ProtoReflect.Descriptor instead.
+func (*WorkerResult) Descriptor() ([]byte, []int) {
+	return file_coordinator_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WorkerResult) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *WorkerResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *WorkerResult) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *WorkerResult) GetRedirect() string {
+	if x != nil {
+		return x.Redirect
+	}
+	return ""
+}
+
+func (x *WorkerResult) GetCanonical() string {
+	if x != nil {
+		return x.Canonical
+	}
+	return ""
+}
+
+func (x *WorkerResult) GetNoindex() bool {
+	if x != nil {
+		return x.Noindex
+	}
+	return false
+}
+
+func (x *WorkerResult) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *WorkerResult) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *WorkerResult) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *WorkerResult) GetLinks() []string {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+var File_coordinator_proto protoreflect.FileDescriptor
+
+const file_coordinator_proto_rawDesc = "" +
+	"\n" +
+	"\x11coordinator.proto\x12\fgocrawler.v1\x1a\x1egoogle/protobuf/duration.proto\"!\n" +
+	"\vJoinRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\xae\x03\n" +
+	"\tJoinReply\x12\x16\n" +
+	"\x06worker\x18\x01 \x01(\tR\x06worker\x127\n" +
+	"\theartbeat\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\theartbeat\x12\x10\n" +
+	"\x03css\x18\x03 \x01(\bR\x03css\x12\x16\n" +
+	"\x06images\x18\x04 \x01(\bR\x06images\x12\x0e\n" +
+	"\x02js\x18\x05 \x01(\bR\x02js\x12\x19\n" +
+	"\bmax_body\x18\x06 \x01(\x03R\amaxBody\x12\x14\n" +
+	"\x05agent\x18\a \x01(\tR\x05agent\x12\x12\n" +
+	"\x04from\x18\b \x01(\tR\x04from\x12\x16\n" +
+	"\x06robots\x18\t \x01(\bR\x06robots\x128\n" +
+	"\n" +
+	"host_delay\x18\n" +
+	" \x01(\v2\x19.google.protobuf.DurationR\thostDelay\x12\x1c\n" +
+	"\tbandwidth\x18\v \x01(\x01R\tbandwidth\x122\n" +
+	"\aheaders\x18\f \x03(\v2\x18.gocrawler.v1.HeaderRuleR\aheaders\x12-\n" +
+	"\x05rules\x18\r \x03(\v2\x17.gocrawler.v1.CrawlRuleR\x05rules\"\xbe\x01\n" +
+	"\n" +
+	"HeaderRule\x12\x14\n" +
+	"\x05match\x18\x01 \x01(\tR\x05match\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x02 \x01(\tR\tuserAgent\x12?\n" +
+	"\aheaders\x18\x03 \x03(\v2%.gocrawler.v1.HeaderRule.HeadersEntryR\aheaders\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x90\x03\n" +
+	"\tCrawlRule\x12\x14\n" +
+	"\x05match\x18\x01 \x01(\tR\x05match\x12\x19\n" +
+	"\x05depth\x18\x02 \x01(\x05H\x00R\x05depth\x88\x01\x01\x12\x14\n" +
+	"\x05delay\x18\x03 \x01(\tR\x05delay\x12>\n" +
+	"\aheaders\x18\x04 \x03(\v2$.gocrawler.v1.CrawlRule.HeadersEntryR\aheaders\x12\x16\n" +
+	"\x06accept\x18\x05 \x01(\tR\x06accept\x12'\n" +
+	"\x0faccept_language\x18\x06 \x01(\tR\x0eacceptLanguage\x12\x16\n" +
+	"\x06method\x18\a \x01(\tR\x06method\x12\x12\n" +
+	"\x04body\x18\b \x01(\tR\x04body\x12\x16\n" +
+	"\x06parser\x18\t \x01(\tR\x06parser\x12\"\n" +
+	"\n" +
+	"store_body\x18\n" +
+	" \x01(\bH\x01R\tstoreBody\x88\x01\x01\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\b\n" +
+	"\x06_depthB\r\n" +
+	"\v_store_body\"&\n" +
+	"\fLeaseRequest\x12\x16\n" +
+	"\x06worker\x18\x01 \x01(\tR\x06worker\"C\n" +
+	"\tCrawlTask\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x14\n" +
+	"\x05depth\x18\x03 \x01(\x05R\x05depth\"M\n" +
+	"\n" +
+	"LeaseReply\x12+\n" +
+	"\x04task\x18\x01 \x01(\v2\x17.gocrawler.v1.CrawlTaskR\x04task\x12\x12\n" +
+	"\x04done\x18\x02 \x01(\bR\x04done\"*\n" +
+	"\x10HeartbeatRequest\x12\x16\n" +
+	"\x06worker\x18\x01 \x01(\tR\x06worker\"\x10\n" +
+	"\x0eHeartbeatReply\"q\n" +
+	"\x0fCompleteRequest\x12\x16\n" +
+	"\x06worker\x18\x01 \x01(\tR\x06worker\x12\x12\n" +
+	"\x04task\x18\x02 \x01(\x03R\x04task\x122\n" +
+	"\x06result\x18\x03 \x01(\v2\x1a.gocrawler.v1.WorkerResultR\x06result\"\x0f\n" +
+	"\rCompleteReply\"\x85\x02\n" +
+	"\fWorkerResult\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\x05R\x06status\x12\x1a\n" +
+	"\bredirect\x18\x04 \x01(\tR\bredirect\x12\x1c\n" +
+	"\tcanonical\x18\x05 \x01(\tR\tcanonical\x12\x18\n" +
+	"\anoindex\x18\x06 \x01(\bR\anoindex\x12\x12\n" +
+	"\x04lang\x18\a \x01(\tR\x04lang\x12\x14\n" +
+	"\x05title\x18\b \x01(\tR\x05title\x12!\n" +
+	"\fcontent_type\x18\t \x01(\tR\vcontentType\x12\x14\n" +
+	"\x05links\x18\n" +
+	" \x03(\tR\x05links2\x9b\x02\n" +
+	"\vCoordinator\x12:\n" +
+	"\x04Join\x12\x19.gocrawler.v1.JoinRequest\x1a\x17.gocrawler.v1.JoinReply\x12=\n" +
+	"\x05Lease\x12\x1a.gocrawler.v1.LeaseRequest\x1a\x18.gocrawler.v1.LeaseReply\x12I\n" +
+	"\tHeartbeat\x12\x1e.gocrawler.v1.HeartbeatRequest\x1a\x1c.gocrawler.v1.HeartbeatReply\x12F\n" +
+	"\bComplete\x12\x1d.gocrawler.v1.CompleteRequest\x1a\x1b.gocrawler.v1.CompleteReplyB'Z%github.com/tobiasbrodd/GoCrawler;mainb\x06proto3"
+
+var (
+	file_coordinator_proto_rawDescOnce sync.Once
+	file_coordinator_proto_rawDescData []byte
+)
+
+func file_coordinator_proto_rawDescGZIP() []byte {
+	file_coordinator_proto_rawDescOnce.Do(func() {
+		file_coordinator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_coordinator_proto_rawDesc), len(file_coordinator_proto_rawDesc)))
+	})
+	return file_coordinator_proto_rawDescData
+}
+
+var file_coordinator_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_coordinator_proto_goTypes = []any{
+	(*JoinRequest)(nil),         // 0: gocrawler.v1.JoinRequest
+	(*JoinReply)(nil),           // 1: gocrawler.v1.JoinReply
+	(*HeaderRule)(nil),          // 2: gocrawler.v1.HeaderRule
+	(*CrawlRule)(nil),           // 3: gocrawler.v1.CrawlRule
+	(*LeaseRequest)(nil),        // 4: gocrawler.v1.LeaseRequest
+	(*CrawlTask)(nil),           // 5: gocrawler.v1.CrawlTask
+	(*LeaseReply)(nil),          // 6: gocrawler.v1.LeaseReply
+	(*HeartbeatRequest)(nil),    // 7: gocrawler.v1.HeartbeatRequest
+	(*HeartbeatReply)(nil),      // 8: gocrawler.v1.HeartbeatReply
+	(*CompleteRequest)(nil),     // 9: gocrawler.v1.CompleteRequest
+	(*CompleteReply)(nil),       // 10: gocrawler.v1.CompleteReply
+	(*WorkerResult)(nil),        // 11: gocrawler.v1.WorkerResult
+	nil,                         // 12: gocrawler.v1.HeaderRule.HeadersEntry
+	nil,                         // 13: gocrawler.v1.CrawlRule.HeadersEntry
+	(*durationpb.Duration)(nil), // 14: google.protobuf.Duration
+}
+var file_coordinator_proto_depIdxs = []int32{
+	14, // 0: gocrawler.v1.JoinReply.heartbeat:type_name -> google.protobuf.Duration
+	14, // 1: gocrawler.v1.JoinReply.host_delay:type_name -> google.protobuf.Duration
+	2,  // 2: gocrawler.v1.JoinReply.headers:type_name -> gocrawler.v1.HeaderRule
+	3,  // 3: gocrawler.v1.JoinReply.rules:type_name -> gocrawler.v1.CrawlRule
+	12, // 4: gocrawler.v1.HeaderRule.headers:type_name -> gocrawler.v1.HeaderRule.HeadersEntry
+	13, // 5: gocrawler.v1.CrawlRule.headers:type_name -> gocrawler.v1.CrawlRule.HeadersEntry
+	5,  // 6: gocrawler.v1.LeaseReply.task:type_name -> gocrawler.v1.CrawlTask
+	11, // 7: gocrawler.v1.CompleteRequest.result:type_name -> gocrawler.v1.WorkerResult
+	0,  // 8: gocrawler.v1.Coordinator.Join:input_type -> gocrawler.v1.JoinRequest
+	4,  // 9: gocrawler.v1.Coordinator.Lease:input_type -> gocrawler.v1.LeaseRequest
+	7,  // 10: gocrawler.v1.Coordinator.Heartbeat:input_type -> gocrawler.v1.HeartbeatRequest
+	9,  // 11: gocrawler.v1.Coordinator.Complete:input_type -> gocrawler.v1.CompleteRequest
+	1,  // 12: gocrawler.v1.Coordinator.Join:output_type -> gocrawler.v1.JoinReply
+	6,  // 13: gocrawler.v1.Coordinator.Lease:output_type -> gocrawler.v1.LeaseReply
+	8,  // 14: gocrawler.v1.Coordinator.Heartbeat:output_type -> gocrawler.v1.HeartbeatReply
+	10, // 15: gocrawler.v1.Coordinator.Complete:output_type -> gocrawler.v1.CompleteReply
+	12, // [12:16] is the sub-list for method output_type
+	8,  // [8:12] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_coordinator_proto_init() }
+func file_coordinator_proto_init() {
+	if File_coordinator_proto != nil {
+		return
+	}
+	file_coordinator_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_coordinator_proto_rawDesc), len(file_coordinator_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_coordinator_proto_goTypes,
+		DependencyIndexes: file_coordinator_proto_depIdxs,
+		MessageInfos:      file_coordinator_proto_msgTypes,
+	}.Build()
+	File_coordinator_proto = out.File
+	file_coordinator_proto_goTypes = nil
+	file_coordinator_proto_depIdxs = nil
+}
@@ -1,22 +1,63 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	neturl "net/url"
+	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/net/html"
+	"golang.org/x/sync/singleflight"
 )
 
 // ---------- Crawler ----------
 
 type site struct {
-	url   string
-	depth int
+	url    string
+	depth  int
+	queued time.Time
+	nav    bool
+	// external is the number of hops the site is outside the scope hosts
+	external int
+	// finish, if set, is called once the site is crawled or dropped
+	finish func(crawled bool)
+}
+
+// Finish tells the source of a site that it was crawled, or dropped
+// without being crawled
+func (s site) Finish(crawled bool) {
+	if s.finish != nil {
+		s.finish(crawled)
+	}
+}
+
+type crawlOptions struct {
+	limit     depthLimit
+	workers   int
+	maxPages  int
+	memory    *watchdog
+	pause     *pauseGate
+	languages map[string]bool
+	sampler   *sampler
+	frontier  *kafkaFrontier
+	collapse  *paramLearner
+	robots    *robotsPolicy
+	finals    *finalURLs
+	links     *linkClassifier
+	content   bool
+	skipTraps bool
+	fresh     *freshSkipper
+	quotas    *quotas
+	hosts     *canonicalHosts
 }
 
 var responses = make(chan response)
@@ -38,74 +79,266 @@ func IncreaseSitesLeft() {
 
 // DecreaseSitesLeft decreases sites left
 func DecreaseSitesLeft() {
-	atomic.AddInt64(&sitesLeft, -1)
-	if atomic.LoadInt64(&sitesLeft) == 0 {
+	if atomic.AddInt64(&sitesLeft, -1) == 0 {
 		close(sites)
 	}
 }
 
-// SitesHandler handles the sites channel
-func SitesHandler(verbose bool) {
+// SitesHandler handles the seeds, in order, and then the sites channel,
+// visiting at most maxPages sites when maxPages > 0
+func SitesHandler(seeds []site, collapse *paramLearner, hosts *canonicalHosts, maxPages int, verbose bool) {
 	visited := map[string]bool{}
 
-	for s := range sites {
+	handle := func(s site) {
+		s.url = NormalizeLink(collapse, hosts, s.url)
 		url := s.url
-		if _, ok := visited[url]; ok {
+		key := URLKey(url)
+		if _, ok := visited[key]; ok || hosts.Reached(url) {
+			if verbose {
+				fmt.Fprintf(logOutput, "Already visited %v\n", url)
+			}
+			s.Finish(false)
+			DecreaseSitesLeft()
+		} else if maxPages > 0 && len(visited) >= maxPages {
 			if verbose {
-				fmt.Printf("Already visited %v\n", url)
+				fmt.Fprintf(logOutput, "Reached max pages: %v\n", url)
 			}
+			s.Finish(false)
+			DecreaseSitesLeft()
 		} else {
-			visited[url] = true
-			IncreaseSitesLeft()
+			visited[key] = true
+			visitedCount.Add(1)
+			s.queued = time.Now()
 			visit <- s
 		}
 	}
 
+	for _, s := range seeds {
+		handle(s)
+	}
+	for s := range sites {
+		handle(s)
+	}
+
 	close(visit)
 }
 
+// NormalizeLink rewrites a link the way SitesHandler rewrites sites before
+// crawling them, so links match the results of the pages they lead to
+func NormalizeLink(collapse *paramLearner, hosts *canonicalHosts, link string) string {
+	return hosts.Rewrite(collapse.Strip(StripTracking(link)))
+}
+
 // Crawler crawls a site
-func Crawler(s site, depth int, fetcher Fetcher, verbose bool) {
+func Crawler(s site, opts crawlOptions, fetcher Fetcher, verbose bool) {
+	limit := opts.limit
+
+	if opts.fresh.Skip(s.url) {
+		if verbose {
+			fmt.Fprintf(logOutput, "Still fresh: %v\n", s.url)
+		}
+		DecreaseSitesLeft()
+		return
+	}
+
+	if !opts.robots.Allowed(s.url) {
+		if verbose {
+			fmt.Fprintf(logOutput, "Disallowed by robots.txt: %v\n", s.url)
+		}
+		responses <- response{url: s.url, urls: []string{}, err: robotsError{}}
+		DecreaseSitesLeft()
+		return
+	}
+
+	if reason, ok := IsTrapURL(s.url); ok && opts.skipTraps {
+		if verbose {
+			fmt.Fprintf(logOutput, "Skipped crawler trap: %v (%v)\n", s.url, reason)
+		}
+		responses <- response{url: s.url, urls: []string{}, err: trapError{reason}}
+		DecreaseSitesLeft()
+		return
+	}
+
+	if !opts.finals.Claim(s.url) {
+		if verbose {
+			fmt.Fprintf(logOutput, "Already crawled as a redirect target: %v\n", s.url)
+		}
+		DecreaseSitesLeft()
+		return
+	}
+
 	if verbose {
-		fmt.Printf("Crawling URL: %v\n", s.url)
+		fmt.Fprintf(logOutput, "Crawling URL: %v\n", s.url)
 	}
 
-	resp, err := fetcher.Fetch(s.url)
+	stages.Add("queue", time.Since(s.queued))
+
+	host := hostOf(s.url)
+	inflight.Add(host, 1)
+	start := time.Now()
+	resp, err := fetcher.Fetch(NewFetchRequest(s.url))
+	stages.Add("fetch", time.Since(start))
+	inflight.Add(host, -1)
 
 	if err != nil {
 		if verbose {
-			fmt.Printf("Error on %v: %v\n", s.url, err)
+			fmt.Fprintf(logOutput, "Error on %v: %v\n", s.url, err)
+		}
+		responses <- resp
+		DecreaseSitesLeft()
+		return
+	}
+
+	for _, hop := range resp.redirects {
+		opts.hosts.Learn(hop.from, hop.to)
+	}
+	if len(resp.redirects) == 0 && len(resp.redirect) != 0 && !IsRedirect(resp.status) {
+		opts.hosts.Learn(s.url, resp.redirect)
+	}
+
+	if len(resp.redirect) != 0 && !IsRedirect(resp.status) && !opts.finals.Claim(resp.redirect) {
+		if verbose {
+			fmt.Fprintf(logOutput, "Redirect target already crawled: %v -> %v\n", s.url, resp.redirect)
+		}
+		DecreaseSitesLeft()
+		return
+	}
+
+	if !AllowLanguage(opts.languages, resp.lang) {
+		if verbose {
+			fmt.Fprintf(logOutput, "Skipping language %v: %v\n", resp.lang, s.url)
 		}
 		DecreaseSitesLeft()
 		return
 	}
 
+	resp.navLinks = opts.links.Classify(s.url, resp.anchors)
+
 	responses <- resp
 
-	if s.depth >= depth {
+	if !limit.Expand(s) {
 		if verbose {
-			fmt.Printf("Reached max depth: %v\n", depth)
+			fmt.Fprintf(logOutput, "Reached max depth: %v\n", limit.Max(s.url))
 		}
 		DecreaseSitesLeft()
 		return
 	}
 
-	for _, url := range resp.urls {
-		sites <- site{url, s.depth + 1}
+	nav := map[string]bool{}
+	for _, link := range resp.navLinks {
+		nav[URLKey(link)] = true
+	}
+	urls := opts.sampler.Pick(resp.urls)
+	if opts.content {
+		urls = ContentFirst(urls, nav)
+	}
+
+	var next []site
+	for _, url := range urls {
+		if !IsCrawlable(url) {
+			if verbose {
+				fmt.Fprintf(logOutput, "Unsupported scheme: %v\n", url)
+			}
+			continue
+		}
+		if !limit.Allow(url) {
+			if verbose {
+				fmt.Fprintf(logOutput, "Outside max depth: %v\n", url)
+			}
+			continue
+		}
+		external, ok := limit.ExternalHops(s, url)
+		if !ok {
+			if verbose {
+				fmt.Fprintf(logOutput, "Outside external depth: %v\n", url)
+			}
+			continue
+		}
+		if opts.frontier != nil {
+			next = append(next, site{url: url, depth: s.depth + 1, external: external})
+			continue
+		}
+		IncreaseSitesLeft()
+		sites <- site{url: url, depth: s.depth + 1, nav: opts.content && nav[URLKey(url)], external: external}
+	}
+
+	if len(next) != 0 {
+		if err := opts.frontier.Push(next); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on frontier: %v\n", err)
+		}
 	}
 
 	DecreaseSitesLeft()
 }
 
-// Crawl the web
-func Crawl(baseURL string, depth int, verbose bool) {
-	fetcher := fetcher{}
+// Crawl the web, using one goroutine per site or, with workers > 0, a fixed
+// set of workers that each handle the sites of a stable set of hosts
+func Crawl(seeds []string, opts crawlOptions, fetcher Fetcher, verbose bool) {
+	workers := opts.workers
+
+	var seedSites []site
+	for _, seed := range seeds {
+		seedSites = append(seedSites, site{url: seed, depth: 1})
+	}
+
+	if opts.frontier != nil {
+		// The consumer holds a site until the frontier fails, so a
+		// continuous crawl does not end when the local queue runs empty
+		IncreaseSitesLeft()
+		if err := opts.frontier.Push(seedSites); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on frontier: %v\n", err)
+		}
+		go SitesHandler(nil, opts.collapse, opts.hosts, opts.maxPages, verbose)
+		go opts.frontier.Consume(verbose)
+	} else {
+		// Seeds are queued before any link found on them, so a
+		// deterministic crawl visits them in the order given
+		atomic.AddInt64(&sitesLeft, int64(len(seeds)))
+		go SitesHandler(seedSites, opts.collapse, opts.hosts, opts.maxPages, verbose)
+	}
+
+	if workers <= 0 {
+		for s := range visit {
+			opts.memory.Wait()
+			opts.pause.Wait()
+			// A crawler takes its slot in its own goroutine, so this loop
+			// keeps receiving the sites that crawlers holding a slot send
+			go func(s site) {
+				opts.quotas.Acquire()
+				defer opts.quotas.Release()
+				Crawler(s, opts, fetcher, verbose)
+				s.Finish(true)
+			}(s)
+		}
+
+		close(responses)
+		return
+	}
 
-	go SitesHandler(verbose)
+	shards := make([]*shard, workers)
+	for i := range shards {
+		shards[i] = NewShard()
+		activeShards.Lock()
+		activeShards.shards = append(activeShards.shards, shards[i])
+		activeShards.Unlock()
+		go func(sh *shard) {
+			for s, ok := sh.Pop(); ok; s, ok = sh.Pop() {
+				opts.pause.Wait()
+				opts.quotas.Acquire()
+				Crawler(s, opts, fetcher, verbose)
+				opts.quotas.Release()
+				s.Finish(true)
+			}
+		}(shards[i])
+	}
 
-	sites <- site{baseURL, 1}
 	for s := range visit {
-		go Crawler(s, depth, fetcher, verbose)
+		opts.memory.Wait()
+		shards[HostShard(s.url, workers)].Push(s)
+	}
+
+	for _, sh := range shards {
+		sh.Close()
 	}
 
 	close(responses)
@@ -114,20 +347,28 @@ func Crawl(baseURL string, depth int, verbose bool) {
 // Analyser converts a response to a result
 func Analyser(resp response, parser Parser, verbose bool) {
 	if verbose {
-		fmt.Printf("Analysing response from: %v\n", resp.url)
+		fmt.Fprintf(logOutput, "Analysing response from: %v\n", resp.url)
 	}
 
-	results <- parser.Parse(resp)
+	start := time.Now()
+	res := parser.Parse(resp)
+	stages.Add("parse", time.Since(start))
+
+	results <- res
 	waitGroup.Done()
 }
 
-// Analyse responses
-func Analyse(verbose bool) {
+// Analyse responses, one at a time in arrival order if sequential
+func Analyse(sequential bool, verbose bool) {
 	parser := parser{}
 
 	for resp := range responses {
 		waitGroup.Add(1)
-		go Analyser(resp, parser, verbose)
+		if sequential {
+			Analyser(resp, parser, verbose)
+		} else {
+			go Analyser(resp, parser, verbose)
+		}
 	}
 
 	waitGroup.Wait()
@@ -135,103 +376,1708 @@ func Analyse(verbose bool) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "daemon" {
+		RunDaemon(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "job" {
+		RunJob(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		RunBench(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		RunHistory(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backlinks" {
+		RunBacklinks(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "parse-links" {
+		RunParseLinks(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "policy" {
+		RunPolicy(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "coordinator" {
+		RunCoordinator(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		RunWorker(os.Args[2:])
+		return
+	}
+
 	url := flag.String("url", "https://golang.org/", "Set starting URL.")
+	phaseName := flag.String("phase", "all", "Set crawl phase: all, discover to only find URLs, requesting files without links with HEAD and writing -inventory, or fetch to crawl -seeds without following links.")
+	inventoryPath := flag.String("inventory", "", "Set CSV file to write a URL inventory with status, content type, error, redirect, title and link count to, usable as -seeds.")
+	seedsWhere := flag.String("seeds-where", "", "Set comma separated column=pattern filters on CSV -seeds, e.g. status=200,content_type=text/html*.")
+	seedFile := flag.String("seeds", "", "Set file of starting URLs, one per line or CSV with a url column whose other columns are copied to results.")
+	configPath := flag.String("config", "", "Set JSON config file.")
 	depth := flag.Int("depth", 1, "Set to >= 1 to specify depth.")
+	externalDepth := flag.Int("external-depth", -1, "Set to >= 0 to fetch pages on hosts other than the starting hosts at most that many hops away from them, -1 for no limit.")
+	boostDepth := flag.String("boost-depth", "", "Set comma separated pattern=+n rules giving matching URLs n extra depth, e.g. /docs/*=+2.")
+	depthMode := flag.String("depth-mode", "hops", "Set depth mode: hops (links followed) or path (directories below the starting URL).")
 	verbose := flag.Bool("verbose", true, "Set to false to disable printing.")
+	workers := flag.Int("workers", 0, "Set to > 0 to crawl with a fixed number of workers sharded by host.")
+	maxPages := flag.Int("max-pages", 0, "Set to > 0 to crawl at most that many pages.")
+	dryRun := flag.Bool("dry-run", false, "Set to true to print the URLs that would be fetched at depth 1 and 2, using sitemaps, without fetching pages.")
+	classifyLinks := flag.Bool("classify-links", false, "Set to true to classify links as navigation or content by their position and repetition across pages.")
+	contentFirst := flag.Bool("content-first", false, "Set to true to crawl content links before navigation links, implies -classify-links.")
+	ordered := flag.Bool("ordered", false, "Set to true to buffer results and output them in breadth-first discovery order instead of completion order.")
+	stopWhen := flag.String("stop-when", "", "Set a goal ending the crawl once met, e.g. 'found(\"login\") or pages > 1000', using found(text), url(pattern), pages, errors, elapsed, comparisons, and, or and not.")
+	deterministic := flag.Bool("deterministic", false, "Set to true to crawl and analyse one site at a time in a fixed order for reproducible output.")
+	cacheDir := flag.String("cache-dir", "", "Set directory to cache responses in.")
+	cacheTTL := flag.Duration("cache-ttl", 24*time.Hour, "Set how long cached responses stay fresh.")
+	cacheHeaders := flag.Bool("cache-headers", false, "Set to true to keep cached responses fresh for as long as their Cache-Control or Expires headers allow instead of -cache-ttl.")
+	skipFresh := flag.Bool("skip-fresh", false, "Set to true to skip URLs whose response in -cache-dir is still fresh by its Cache-Control or Expires headers.")
+	cacheMode := flag.String("cache-mode", "read-write", "Set cache mode: off, read, write or read-write.")
+	maxBody := flag.Int64("max-body", 0, "Set to > 0 to limit response body size in bytes.")
+	maxMemory := flag.String("max-memory", "", "Set heap size, e.g. 2GB, at which the crawl pauses until memory is freed. Bodies of results buffered for -link-status and -ordered then go to a temp file.")
+	maxGoroutines := flag.Int("max-goroutines", 0, "Set to > 0 to limit the sites crawled at once.")
+	maxBuffers := flag.String("max-buffer-memory", "", "Set total size, e.g. 256MB, of bodies held while downloading and parsing, after which downloads wait.")
+	maxBandwidth := flag.String("max-bandwidth", "", "Set to limit total download rate, e.g. 5MB/s or 500KiB/s.")
+	parseJS := flag.Bool("parse-js", false, "Set to true to extract URL and path literals from inline and external scripts.")
+	parseCSS := flag.Bool("parse-css", false, "Set to true to extract links from stylesheets and style attributes.")
+	pagerank := flag.Bool("pagerank", false, "Set to true to print PageRank and link degrees of crawled pages.")
+	orphans := flag.Bool("orphans", false, "Set to true to compare crawled pages against sitemaps.")
+	sitemaps := flag.String("sitemap", "", "Set comma separated sitemap URLs, defaults to robots.txt Sitemap entries or /sitemap.xml on the starting host.")
+	sitemapSeeds := flag.Bool("sitemap-seeds", false, "Set to true to also crawl URLs from sitemaps listed in robots.txt.")
+	parseImages := flag.Bool("parse-images", false, "Set to true to extract image links from img, srcset and picture sources.")
+	linkStatus := flag.Bool("link-status", false, "Set to true to attach the status of outgoing links to each result, writing results when the crawl ends.")
+	thirdParty := flag.Bool("trackers", false, "Set whether to print third-party scripts, iframes and pixels and the pages loading them.")
+	frontierURI := flag.String("frontier", "", "Set kafka://broker,broker/topic to keep the crawl queue in Kafka for continuous crawls that survive restarts.")
+	frontierGroup := flag.String("frontier-group", "gocrawler", "Set Kafka consumer group of the frontier, shared by crawlers splitting the work.")
+	frontierVisited := flag.String("frontier-visited", "", "Set file recording the URLs crawled from the frontier, so a restarted crawler skips them. Defaults to <topic>-visited.txt.")
+	sampleRate := flag.Float64("sample", 1, "Set to < 1 to follow each discovered link with that probability.")
+	randomWalk := flag.Int("random-walk", 0, "Set to > 0 to follow at most that many random links per page.")
+	accept := flag.String("accept", "", "Set Accept header sent with every page request, e.g. text/html or application/json.")
+	acceptLanguage := flag.String("accept-language", "", "Set Accept-Language header sent with every page request, e.g. \"de-DE,de;q=0.9\".")
+	identify := flag.String("identify", "", "Set User-Agent identifying the crawler, e.g. \"MyCrawler/1.0 (+https://example.com/bot)\".")
+	from := flag.String("from", "", "Set From header with a contact address for site operators.")
+	storeDir := flag.String("store-dir", "", "Set directory to store bodies in by content hash, shared by the cache, outputs and runs.")
+	policyTTL := flag.Duration("policy-ttl", 24*time.Hour, "Set how long robots.txt policies stored in -store-dir are used before fetching them again, 0 to not store them.")
+	warcFiles := flag.String("warc", "", "Set comma separated WARC files to replay responses from instead of fetching them.")
+	schemes := flag.String("schemes", "", "Set comma separated non-HTTP schemes whose links are crawled: ftp (checked, not downloaded), s3 and gs. Links of other schemes are not crawled.")
+	tlsFingerprint := flag.String("tls-fingerprint", "go", "Set TLS ClientHello to send: go, chrome, firefox, safari, edge or ios, for own sites behind firewalls blocking Go's.")
+	hosts := hostMap{}
+	flag.Var(hosts, "host-map", "Set host=address to connect to a host at another address, keeping Host headers and TLS names (repeatable).")
+	frames := flag.String("frames", "none", "Set how frame and iframe sources are crawled: none, separate (own pages one level deeper) or merged (content counts as the parent page).")
+	languages := flag.String("languages", "", "Set comma separated languages of pages to follow and report (default all).")
+	search := flag.String("search", "", "Set a regular expression to search the text of each page for.")
+	searchContext := flag.Int("search-context", 1, "Set number of lines of context around search matches.")
+	searchIndexPath := flag.String("search-index", "", "Set JSON file to write a client-side search index of page URLs, titles and text to, for Lunr, MiniSearch or Fuse.")
+	searchIndexLimit := flag.Int("search-index-limit", 10000, "Set maximum bytes of text per page in the search index, 0 for all.")
+	harvest := flag.Bool("contacts", false, "Set whether to collect email addresses and phone numbers from pages.")
+	infra := flag.Bool("infra", false, "Set to true to add the IP, reverse DNS and CDN or hosting provider of each page's host to results.")
+	lookupASN := flag.Bool("asn", false, "Set to true to also look up the autonomous system of each host's IP over DNS, implies -infra.")
+	cookieReport := flag.Bool("cookies", false, "Set whether to print the cookies set by each page and whether they are first- or third-party to the site of the page.")
+	anchorTop := flag.Int("anchor-report", 0, "Set to > 0 to print that many top anchor phrases linking to each crawled page.")
+	duplicateExamples := flag.Int("duplicate-report", 0, "Set to > 0 to print pages sharing a title or meta description, with that many example URLs per cluster.")
+	auditList := flag.String("audit", "", "Set comma separated audits to run: images, a11y, html, spelling.")
+	dictName := flag.String("dict", "en_US", "Set dictionary name or word list file for the spelling audit.")
+	customWords := flag.String("custom-words", "", "Set file of extra words, one per line, accepted by the spelling audit.")
+	maxImageSize := flag.String("max-image-size", "300KB", "Set image size above which the images audit reports an image.")
+	checkIcons := flag.Bool("check-icons", false, "Set to true to verify and print favicons and web app manifests per host.")
+	followVariants := flag.Bool("follow-variants", false, "Set to true to crawl AMP and mobile alternate pages.")
+	output := flag.String("output", "text", "Set output: text, jsonl, proto, neo4j, parquet, sqlite, s3://bucket/prefix or gs://bucket/prefix.")
+	storeBodies := flag.String("store-bodies", "all", "Set which pages keep their body in outputs and bundles: all, or findings for failed pages and pages with assertion or audit findings. Rule store_body overrides it.")
+	includeBody := flag.String("include-body", "none", "Set body included in jsonl output: none, snippet or full.")
+	bodyLimit := flag.Int("body-limit", 1<<20, "Set maximum bytes of a full body included in jsonl output.")
+	hostDisplayMode := flag.String("display-hosts", "keep", "Set how internationalized hosts are written to outputs: keep, unicode or punycode.")
+	pathDisplayMode := flag.String("display-paths", "keep", "Set how non-ASCII path characters are written to outputs: keep, escape or preserve.")
+	stageReport := flag.Bool("stage-report", false, "Set to true to print the time pages spend queued, fetched, parsed and output, and the slowest stage.")
+	extractorList := flag.String("extractors", "", "Set comma separated Go plugin (.so) or WASM (.wasm) extractors whose values are added to results.")
+	resultTemplate := flag.String("template", "", "Set a text/template printing each result on a line with text output, e.g. '{{.URL}} {{.Status}} {{.Title}}'.")
+	learnParams := flag.Int("learn-params", 0, "Set to > 0 to stop crawling a query parameter of a host once that many of its values return the same content.")
+	detectSoft404 := flag.Bool("soft-404", false, "Set to true to report pages that return success but look like the 404 page of their host, and count them as broken.")
+	preset := flag.String("preset", "", "Set a bundle of flag defaults: polite, aggressive or archive. Flags given explicitly override the preset.")
+	activeHoursList := flag.String("active-hours", "", "Set comma separated daily windows, e.g. 22:00-06:00, outside which no page requests are sent and the crawl waits.")
+	activeHoursZone := flag.String("active-hours-zone", "", "Set time zone of -active-hours, e.g. Europe/Stockholm, defaults to local time.")
+	hostDelay := flag.Duration("host-delay", 0, "Set minimum time between requests to the same host.")
+	skipTraps := flag.Bool("skip-traps", true, "Set to false to crawl URLs that look like crawler traps, with a path segment occurring more than twice or over 2048 characters long.")
+	obeyRobots := flag.Bool("robots", false, "Set to true to skip URLs disallowed by the robots.txt of their host for the -identify user agent.")
+	warcOutput := flag.String("warc-output", "", "Set WARC file or s3:// or gs:// object, compressed if it ends in .gz, to record fetched responses in.")
+	requestLogPath := flag.String("request-log", "", "Set file to append a JSON line to for every HTTP request sent, with time, method, URL, status and bytes.")
+	requestLogSize := flag.String("request-log-max-size", "100MB", "Set size at which the request log is renamed with a timestamp suffix and a new one started, 0 to never rotate.")
+	harDir := flag.String("har-dir", "", "Set directory to write a HAR file per page to, timing the requests sent to fetch it.")
+	redirectScopeName := flag.String("redirect-scope", "any", "Set which redirects are followed: any, or same-host to stop at redirects to other hosts.")
+	onlyNewerThan := flag.String("only-newer-than", "", "Set date, such as 2024-01-01, to only output pages modified or published since. Pages without a date are kept.")
+	maxRetryAfter := flag.Duration("max-retry-after", 5*time.Minute, "Set longest time a 503 response with Retry-After pauses its host for before the page is retried, 0 to not retry.")
+	redirectKey := flag.String("redirect-key", "requested", "Set URL pages are deduplicated by: requested, or final to crawl pages reached through redirects once under their final URL.")
+	consolidateHosts := flag.Bool("consolidate-hosts", true, "Set to false to keep crawling URLs on hosts that redirect to another scheme or to the www or apex form of the host.")
+	permanentRedirects := flag.Bool("permanent-redirects", false, "Set to true to print links to URLs that redirect permanently (301 or 308) and should be updated.")
+	maxConns := flag.Int("max-conns", 0, "Set maximum open connections in total, 0 for no limit. Requests wait for a free connection.")
+	maxHostConns := flag.Int("max-host-conns", 0, "Set maximum open connections per host, 0 for no limit. Requests wait for a free connection.")
+	bundlePath := flag.String("bundle", "", "Set zstd compressed tar file or s3:// or gs:// object, such as out.tar.zst, to bundle results, manifest and link graph in.")
+	bundleBodies := flag.Bool("bundle-bodies", false, "Set to true to include bodies in the bundle.")
+	manifestPath := flag.String("manifest", "", "Set file to write a JSON manifest of the crawl's configuration, seeds, version and summary counts to.")
+	outputFile := flag.String("output-file", "", "Set file for jsonl and proto output, defaults to standard output, or the SQLite database, defaults to crawl.db.")
+	outputMaxSize := flag.String("output-max-size", "", "Set size, e.g. 100MB, at which jsonl and proto output starts a new numbered segment of -output-file, such as results-0002.jsonl.")
+	outputMaxResults := flag.Int("output-max-results", 0, "Set to > 0 to start a new numbered segment of -output-file after that many results.")
+	outputDir := flag.String("output-dir", "results", "Set directory for file outputs.")
+	partition := flag.String("partition", "host", "Set parquet partitioning: host or date.")
+	stripTracking := flag.Bool("strip-tracking", true, "Set to false to keep tracking and session parameters in URLs.")
+	stripParams := flag.String("strip-params", "", "Set comma separated extra query parameters to strip, e.g. ref,source_*.")
+	loginURL := flag.String("login-url", "", "Set login page to submit before crawling.")
+	loginData := flag.String("login-data", "", "Set URL encoded login form values, e.g. user=me&password=secret.")
+	debugAddr := flag.String("debug-addr", "", "Set address to serve pprof and expvar debug endpoints on, e.g. :6060.")
+	dashboardAddr := flag.String("dashboard-addr", "", "Set address to serve a live dashboard on, e.g. :8080.")
+	dashboardLinger := flag.Duration("dashboard-linger", 0, "Set how long the dashboard is still served after the crawl finishes, before exiting.")
+	neo4jURI := flag.String("neo4j-uri", "bolt://localhost:7687", "Set Neo4j URI for neo4j output.")
+	neo4jUser := flag.String("neo4j-user", "neo4j", "Set Neo4j user for neo4j output.")
+	neo4jPassword := flag.String("neo4j-password", "", "Set Neo4j password for neo4j output.")
 
 	flag.Parse()
 
-	go Crawl(*url, *depth, *verbose)
-	go Analyse(*verbose)
+	if err := ApplyPreset(*preset); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	for res := range results {
-		fmt.Printf("Result: %v\n", res.url)
+	conf, err := LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error on config: %v\n", err)
+		os.Exit(2)
 	}
-}
 
-// ---------- Fetcher ----------
+	mode, err := ParseCacheMode(*cacheMode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-type response struct {
-	url  string
-	urls []string
-}
+	phase, err := ParsePhase(*phaseName)
+	if err == nil && phase == phaseDiscover && len(*inventoryPath) == 0 {
+		err = fmt.Errorf("-phase discover needs -inventory")
+	}
+	if err == nil && phase == phaseFetch && len(*seedFile) == 0 {
+		err = fmt.Errorf("-phase fetch needs -seeds")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if phase == phaseFetch {
+		*depth = 1
+		*sitemapSeeds = false
+	}
 
-// Fetcher fetches responses
-type Fetcher interface {
-	Fetch(url string) (resp response, err error)
-}
+	filters, err := ParseSeedFilters(*seedsWhere)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-type fetcher struct{}
+	var seedMeta seedMetadata
+	seeds := []string{*url}
+	if len(*seedFile) != 0 {
+		seeds, seedMeta, err = LoadSeeds(*seedFile)
+		if err == nil {
+			seeds = FilterSeeds(seeds, seedMeta, filters)
+		}
+		if err == nil && len(seeds) == 0 {
+			err = fmt.Errorf("no seeds in %v", *seedFile)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on seeds: %v\n", err)
+			os.Exit(2)
+		}
+		*url = seeds[0]
+	}
 
-// Fetch fetches URLs
-func (f fetcher) Fetch(url string) (response, error) {
-	resp, err := http.Get(url)
+	dMode, err := ParseDepthMode(*depthMode)
 	if err != nil {
-		return response{url, []string{}}, err
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	return response{url, GetAllLinks(url, resp.Body)}, nil
-}
-
-// ---------- Parser ----------
+	fMode, err := ParseFrameMode(*frames)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-type result struct {
-	url string
-}
+	limit, err := NewDepthLimit(*url, *depth, dMode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-// Parser parses responses
-type Parser interface {
-	Parse(resp response) (res result)
-}
+	limit.rules = conf.Rules
+	limit.external = *externalDepth
+	limit.boosts, err = ParseDepthBoosts(*boostDepth)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-type parser struct{}
+	cache, err := NewCache(*cacheDir, *cacheTTL, mode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-// Parse parses responses
-func (p parser) Parse(resp response) result {
-	return result{resp.url}
-}
+	store, err := NewBlobStore(*storeDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if cache != nil {
+		cache.headers = *cacheHeaders
+		cache.store = store
+	}
+	if *skipFresh && cache == nil {
+		fmt.Fprintln(os.Stderr, "-skip-fresh requires -cache-dir")
+		os.Exit(2)
+	}
 
-// ---------- Links ----------
+	policies, err = NewPolicyStore(*storeDir, *policyTTL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-// GetAllLinks retrieves all links from a HTML body
-func GetAllLinks(baseURL string, body io.Reader) []string {
-	var links []string
-	page := html.NewTokenizer(body)
-	for {
-		tokenType := page.Next()
+	if len(*resultTemplate) != 0 && *output != "text" {
+		fmt.Fprintln(os.Stderr, "-template requires text output")
+		os.Exit(2)
+	}
 
-		switch tokenType {
-		case html.ErrorToken:
-			return links
-		case html.StartTagToken, html.EndTagToken:
-			token := page.Token()
-			if "a" == token.Data {
-				for _, attr := range token.Attr {
-					if attr.Key == "href" {
-						link := TrimLink(attr.Val)
-						if len(link) != 0 {
-							link = FixLink(baseURL, link)
-							links = append(links, link)
-						}
-					}
-				}
+	var out Output
+	switch {
+	case *dryRun:
+		// A dry run writes no results, so existing outputs are left alone
+		out = textOutput{}
+		*bundlePath = ""
+		*warcOutput = ""
+	case IsBucketURL(*output):
+		var w *bucketWriter
+		w, err = NewBucketWriter(*output, "results.jsonl", "application/x-ndjson")
+		if err == nil {
+			out, err = NewJSONLOutput(w, *includeBody, *bodyLimit)
+		}
+	case *output == "text" && len(*resultTemplate) != 0:
+		out, err = NewTemplateOutput(*resultTemplate, os.Stdout)
+	case *output == "text":
+		out = textOutput{}
+	case *output == "jsonl" || *output == "proto":
+		var w io.WriteCloser
+		switch {
+		case (len(*outputMaxSize) != 0 || *outputMaxResults > 0) && len(*outputFile) == 0:
+			err = fmt.Errorf("-output-max-size and -output-max-results need -output-file")
+		case len(*outputMaxSize) != 0 || *outputMaxResults > 0:
+			maxSize := 0.0
+			if len(*outputMaxSize) != 0 {
+				maxSize, err = ParseBytes(*outputMaxSize)
 			}
+			if err == nil {
+				w, err = CreateSegmentedFile(*outputFile, int64(maxSize), *outputMaxResults)
+			}
+		case len(*outputFile) != 0:
+			w, err = os.Create(*outputFile)
+		default:
+			w = StdoutResults()
+		}
+		if err == nil && *output == "jsonl" {
+			out, err = NewJSONLOutput(w, *includeBody, *bodyLimit)
+		} else if err == nil {
+			out = NewProtoOutput(w)
 		}
+	case *output == "neo4j":
+		out, err = NewNeo4jOutput(*neo4jURI, *neo4jUser, *neo4jPassword)
+	case *output == "parquet":
+		out, err = NewParquetOutput(*outputDir, *partition)
+	case *output == "sqlite":
+		path := *outputFile
+		if len(path) == 0 {
+			path = "crawl.db"
+		}
+		out, err = NewSQLiteOutput(path, *url)
+	default:
+		err = fmt.Errorf("unknown output: %v", *output)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-}
 
-// TrimLink removes characters in links
-func TrimLink(link string) string {
-	link = strings.TrimSpace(link)
-	link = strings.SplitN(link, "#", 2)[0]
-	link = strings.Trim(link, "#")
-	link = strings.TrimSpace(link)
+	inventory, err := CreateInventory(*inventoryPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-	return link
-}
+	crawlBundle, err := NewBundle(*bundlePath, *bundleBodies)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if !*stripTracking {
+		TrackingParams = nil
+	}
+	if len(*stripParams) != 0 {
+		TrackingParams = append(TrackingParams, strings.Split(*stripParams, ",")...)
+	}
+
+	bandwidth := 0.0
+	if len(*maxBandwidth) != 0 {
+		bandwidth, err = ParseBandwidth(*maxBandwidth)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	memoryLimit := 0.0
+	if len(*maxMemory) != 0 {
+		memoryLimit, err = ParseBytes(*maxMemory)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	bufferLimit := 0.0
+	if len(*maxBuffers) != 0 {
+		bufferLimit, err = ParseBytes(*maxBuffers)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	crawlQuotas := NewQuotas(*maxGoroutines, int64(bufferLimit))
+
+	var seed int64
+	if *deterministic {
+		seed = 1
+	}
+	sample, err := NewSampler(*sampleRate, *randomWalk, seed)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var pattern *regexp.Regexp
+	if len(*search) != 0 {
+		pattern, err = regexp.Compile(*search)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	display, err := ParseURLDisplay(*hostDisplayMode, *pathDisplayMode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	audits, err := ParseAudits(*auditList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var dict dictionary
+	if audits["spelling"] {
+		dict, err = LoadDictionary(*dictName, *customWords)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	imageLimit, err := ParseBytes(*maxImageSize)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if err := EnableSchemes(*schemes); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	fingerprint, err := ParseTLSFingerprint(*tlsFingerprint)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var since time.Time
+	if len(*onlyNewerThan) != 0 {
+		date, ok := ParseDate(*onlyNewerThan)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "invalid date: %v\n", *onlyNewerThan)
+			os.Exit(2)
+		}
+		since = date
+	}
+
+	redirectScope, err := ParseRedirectScope(*redirectScopeName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	stopGoal, err := ParseGoal(*stopWhen)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	hours, err := ParseActiveHours(*activeHoursList, *activeHoursZone, *verbose)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *storeBodies != "all" && *storeBodies != "findings" {
+		fmt.Fprintf(os.Stderr, "unknown body storage: %v\n", *storeBodies)
+		os.Exit(2)
+	}
+	if *redirectKey != "requested" && *redirectKey != "final" {
+		fmt.Fprintf(os.Stderr, "unknown redirect key: %v\n", *redirectKey)
+		os.Exit(2)
+	}
+
+	client := NewClient()
+	ApplyRedirectPolicy(client, redirectScope)
+	hosts.Apply(client)
+	ApplyTLSFingerprint(client, fingerprint)
+	LimitConnections(client, *maxConns, *maxHostConns, *verbose)
+	if len(*harDir) != 0 {
+		RecordHAR(client)
+	}
+	Identify(client, *identify, *from)
+	if len(*requestLogPath) != 0 {
+		maxSize, err := ParseBytes(*requestLogSize)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		requestLogFile, err := OpenRotatingFile(*requestLogPath, int64(maxSize))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer requestLogFile.Close()
+		LogRequests(client, requestLogFile)
+	}
+	if warning := IdentityWarning(*url, *identify, conf.Headers); len(warning) != 0 && len(*warcFiles) == 0 {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+	if len(*loginURL) != 0 && !*dryRun {
+		values, err := neturl.ParseQuery(*loginData)
+		if err == nil {
+			err = Login(client, *loginURL, values)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on login: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// The debug server is up before robots.txt is fetched, so a crawl stuck
+	// on it can be inspected
+	pause := NewPauseGate(*verbose)
+	HandlePauseSignals(pause)
+
+	if len(*debugAddr) != 0 {
+		go func() {
+			if err := ServeDebug(*debugAddr, pause); err != nil {
+				fmt.Fprintf(os.Stderr, "Error on debug server: %v\n", err)
+			}
+		}()
+	}
+
+	var robotsTxt robots
+	if len(*warcFiles) == 0 {
+		robotsTxt, err = FetchRobots(client, *url)
+		if err != nil && *verbose {
+			fmt.Fprintf(logOutput, "Error on %v: %v\n", RobotsURL(*url), err)
+		}
+	}
+
+	if *sitemapSeeds && len(robotsTxt.sitemaps) != 0 {
+		sitemapURLs, err := GetSitemapURLs(robotsTxt.sitemaps)
+		if err != nil && *verbose {
+			fmt.Fprintf(logOutput, "Error on sitemap: %v\n", err)
+		}
+		seeds = append(seeds, sitemapURLs...)
+	}
+
+	limit.SetScope(seeds)
+
+	var board *dashboard
+	if len(*dashboardAddr) != 0 {
+		board = NewDashboard(*url)
+		go func() {
+			if err := board.Serve(*dashboardAddr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error on dashboard: %v\n", err)
+			}
+		}()
+	}
+
+	collapse := NewParamLearner(*learnParams)
+	maintenance.max = *maxRetryAfter
+	maintenance.verbose = *verbose
+
+	pacer := NewHostPacer(*hostDelay)
+	if pacer == nil && conf.Rules.Paced() {
+		pacer = &hostPacer{next: map[string]time.Time{}}
+	}
+
+	fetcher := fetcher{
+		client:   client,
+		headers:  conf.Headers,
+		cache:    cache,
+		inflight: &singleflight.Group{},
+		throttle: NewThrottle(bandwidth),
+		keepBody: *includeBody != "none" || (len(*bundlePath) != 0 && *bundleBodies),
+		maxBody:  *maxBody,
+		links:    linkOptions{*parseCSS, *parseImages, *followVariants, fMode, *parseJS},
+		store:    store,
+		collapse: collapse,
+		quotas:   crawlQuotas,
+		pacer:    pacer,
+		rules:    conf.Rules,
+		harDir:   *harDir,
+	}
+
+	fetcher.negotiated = negotiate(nil, *accept, *acceptLanguage)
+	if phase == phaseDiscover {
+		fetcher.discover = true
+		fetcher.keepBody = false
+	}
+	fetcher.hours = hours
+
+	fetcher.warcOut, err = CreateWARC(*warcOutput)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	defer fetcher.warcOut.Close()
+
+	fetcher.extractors, err = LoadExtractors(*extractorList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(*warcFiles) != 0 {
+		fetcher.archive, err = OpenWARC(strings.Split(*warcFiles, ","))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on archive: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	if *deterministic {
+		*workers = 1
+	}
+
+	opts := crawlOptions{
+		limit:     limit,
+		workers:   *workers,
+		maxPages:  *maxPages,
+		memory:    NewWatchdog(uint64(memoryLimit), time.Second/2, *verbose),
+		pause:     pause,
+		languages: ParseLanguages(*languages),
+		sampler:   sample,
+		collapse:  collapse,
+		quotas:    crawlQuotas,
+		skipTraps: *skipTraps,
+	}
+	if *skipFresh {
+		opts.fresh = NewFreshSkipper(cache)
+	}
+	if *redirectKey == "final" {
+		opts.finals = NewFinalURLs()
+	}
+	if *consolidateHosts {
+		opts.hosts = NewCanonicalHosts(*verbose)
+		opts.limit.hosts = opts.hosts
+	}
+	if *classifyLinks || *contentFirst {
+		opts.links = NewLinkClassifier()
+		opts.content = *contentFirst
+	}
+
+	if *obeyRobots && len(*warcFiles) == 0 {
+		agent := *identify
+		if len(agent) == 0 {
+			agent = "Go-http-client"
+		}
+		opts.robots = NewRobotsPolicy(client, agent)
+	}
+
+	if len(*frontierURI) != 0 {
+		opts.frontier, err = NewKafkaFrontier(*frontierURI, *frontierGroup, *frontierVisited)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer opts.frontier.Close()
+	}
+
+	if *dryRun {
+		agent := *identify
+		if len(agent) == 0 {
+			agent = "Go-http-client"
+		}
+		DryRun(client, seeds, opts, agent, conf.Rules)
+		return
+	}
+
+	crawlManifest := NewManifest(seeds, conf)
+
+	go Crawl(seeds, opts, fetcher, *verbose)
+	go Analyse(*deterministic, *verbose)
+
+	counts := errorCounts{}
+	hreflangs := hreflangChecker{}
+	canonicals := canonicalChecker{}
+	orphaned := orphanChecker{}
+	normalize := func(link string) string {
+		return NormalizeLink(collapse, opts.hosts, link)
+	}
+	graph := linkGraph{normalize: normalize}
+	icons := iconChecker{}
+	anchors := anchorIndex{}
+	duplicates := metadataClusters{}
+	trackers := trackerInventory{}
+	redirectReport := redirectChecker{}
+	contacts := contactIndex{}
+	checks := assertionChecker{rules: conf.Assertions}
+	imageAudit := NewImageAuditor(client, int64(imageLimit))
+	soft404s := NewSoft404Detector(client)
+	var pageIndex *searchIndex
+	if len(*searchIndexPath) != 0 {
+		pageIndex = NewSearchIndex(*searchIndexLimit)
+	}
+	var infraHosts *infraLookup
+	if *infra || *lookupASN {
+		infraHosts = NewInfraLookup(hosts, *lookupASN)
+	}
+	write := func(res result) {
+		if !since.IsZero() && !IsNewerThan(res, since) {
+			return
+		}
+		res = display.Result(res)
+		if err := out.Write(res); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on output: %v\n", err)
+		}
+		if err := crawlBundle.Write(res); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on bundle: %v\n", err)
+		}
+	}
+
+	var buffered []result
+	spilled := NewSpill()
+	for res := range results {
+		start := time.Now()
+		resultsCount.Add(1)
+		res.meta = seedMeta.Lookup(res.url)
+		if *detectSoft404 {
+			if reason := soft404s.Check(res); len(reason) != 0 {
+				fmt.Fprintf(logOutput, "Soft 404: %v (%v)\n", res.url, reason)
+				res.errClass = errorSoft404
+			}
+		}
+		if *harvest {
+			res.emails, res.phones = ExtractContacts(res)
+			contacts.Add(res)
+		}
+		infraHosts.Enrich(&res)
+		pageIndex.Add(display.Result(res))
+		if err := inventory.Write(res); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on inventory: %v\n", err)
+		}
+		passed := checks.Check(res)
+		if *storeBodies == "findings" && conf.Rules.For(res.url).storeBody == nil && !HasFindings(res, audits, passed) {
+			res.body = nil
+		}
+		if *linkStatus || *ordered {
+			buffered = append(buffered, res)
+			if opts.memory.Tripped() {
+				if err := spilled.Hold(buffered); err != nil {
+					fmt.Fprintf(os.Stderr, "Error on spill: %v\n", err)
+				}
+			}
+		} else {
+			write(res)
+		}
+		counts.Add(res.errClass)
+		hreflangs.Add(res)
+		canonicals.Add(res)
+		orphaned.Add(res)
+		graph.Add(res)
+		icons.Add(res)
+		anchors.Add(res)
+		duplicates.Add(res)
+		trackers.Add(res)
+		if *permanentRedirects {
+			redirectReport.Add(res)
+		}
+		if audits["images"] {
+			imageAudit.Print(res)
+		}
+		if audits["a11y"] {
+			PrintAccessibility(res)
+		}
+		if audits["html"] {
+			PrintValidation(res)
+		}
+		if audits["spelling"] {
+			PrintMisspellings(dict, res)
+		}
+		if *cookieReport {
+			PrintCookies(res)
+		}
+		if pattern != nil {
+			PrintMatches(pattern, res, *searchContext)
+		}
+		if board != nil {
+			board.Add(res)
+		}
+		stages.Add("output", time.Since(start))
+
+		stopGoal.Observe(res)
+		if stopGoal.Met() {
+			// Sites being crawled are left unfinished and their results dropped
+			fmt.Fprintf(logOutput, "Goal met after %v pages: %v\n", resultsCount.Value(), stopGoal.source)
+			pause.Pause()
+			break
+		}
+	}
+
+	if board != nil {
+		board.Finish()
+	}
+
+	if *ordered {
+		OrderResults(buffered, seeds, normalize)
+	}
+	if *linkStatus {
+		AttachLinkStatuses(buffered, normalize)
+	}
+	for _, res := range buffered {
+		if err := spilled.Restore(&res); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on spill: %v\n", err)
+		}
+		write(res)
+	}
+	if err := spilled.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on spill: %v\n", err)
+	}
+
+	if err := out.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on output: %v\n", err)
+	}
+	if err := inventory.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on inventory: %v\n", err)
+	}
+
+	counts.Print()
+	collapse.Print()
+	maintenance.Print()
+	opts.hosts.Print()
+	opts.fresh.Print()
+	if *stageReport {
+		stages.Print(*workers, bandwidth > 0, *deterministic)
+	}
+	if len(*manifestPath) != 0 {
+		if err := crawlManifest.Write(*manifestPath, resultsCount.Value(), counts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on manifest: %v\n", err)
+		}
+	}
+	if err := pageIndex.Write(*searchIndexPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on search index: %v\n", err)
+	}
+	if err := crawlBundle.Close(crawlManifest, resultsCount.Value(), counts, &graph); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on bundle: %v\n", err)
+	}
+	if *linkStatus {
+		PrintBrokenLinks(buffered)
+	}
+	hreflangs.Print()
+	canonicals.Print()
+	if *permanentRedirects {
+		redirectReport.Print()
+	}
+
+	if *checkIcons {
+		icons.Print(client)
+	}
+
+	if *anchorTop > 0 {
+		anchors.Print(*anchorTop)
+	}
+
+	if *duplicateExamples > 0 {
+		duplicates.Print(*duplicateExamples)
+	}
+
+	if *thirdParty {
+		trackers.Print()
+	}
+
+	if *harvest {
+		contacts.Print()
+	}
+
+	infraHosts.Print()
+
+	if *pagerank {
+		graph.PrintPageRank()
+	}
+
+	if *orphans {
+		locations := strings.Split(*sitemaps, ",")
+		if len(*sitemaps) == 0 {
+			locations = robotsTxt.sitemaps
+		}
+		if len(locations) == 0 {
+			locations = []string{DefaultSitemap(*url)}
+		}
+
+		sitemapURLs, err := GetSitemapURLs(locations)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on sitemap: %v\n", err)
+		}
+		orphaned.Print(sitemapURLs)
+	}
+
+	code := 0
+	if !checks.Print() {
+		code = 3
+	}
+
+	if board != nil && *dashboardLinger > 0 {
+		fmt.Fprintf(os.Stderr, "Crawl finished, dashboard still served on %v for %v\n", *dashboardAddr, *dashboardLinger)
+		time.Sleep(*dashboardLinger)
+	}
+
+	if code != 0 {
+		os.Exit(code)
+	}
+}
+
+// ---------- Fetcher ----------
+
+type response struct {
+	url         string
+	urls        []string
+	anchors     []anchor
+	alternates  []alternate
+	canonical   string
+	amp         string
+	mobile      string
+	icons       []string
+	manifest    string
+	images      []imageTag
+	forms       []form
+	resources   []string
+	cookies     []*http.Cookie
+	a11y        []string
+	markup      []string
+	lang        string
+	text        string
+	variant     string
+	redirect    string
+	noindex     bool
+	contentType string
+	bodyHash    string
+	status      int
+	title       string
+	description string
+	navLinks    []string
+	published   time.Time
+	modified    time.Time
+	redirects   []redirectHop
+	header      http.Header
+	extracted   map[string]string
+	body        []byte
+	err         error
+}
+
+// fetchRequest is what a Fetcher fetches. Unset fields take the method,
+// headers and body of the rules matching the URL, and a GET by default.
+type fetchRequest struct {
+	url     string
+	method  string
+	headers map[string]string
+	body    []byte
+}
+
+// NewFetchRequest creates a request for a URL with the default method,
+// headers and body
+func NewFetchRequest(url string) fetchRequest {
+	return fetchRequest{url: url}
+}
+
+// Fetcher fetches responses
+type Fetcher interface {
+	Fetch(req fetchRequest) (resp response, err error)
+}
+
+type fetcher struct {
+	client     *http.Client
+	headers    []headerRule
+	negotiated map[string]string
+	rules      crawlRules
+	cache      *cache
+	inflight   *singleflight.Group
+	throttle   *throttle
+	maxBody    int64
+	keepBody   bool
+	links      linkOptions
+	archive    *warcArchive
+	warcOut    *warcWriter
+	harDir     string
+	pacer      *hostPacer
+	hours      *activeHours
+	discover   bool
+	store      *blobStore
+	collapse   *paramLearner
+	quotas     *quotas
+	extractors []Extractor
+}
+
+// Fetch fetches requests
+func (f fetcher) Fetch(req fetchRequest) (response, error) {
+	url := req.url
+	e, err := f.get(req)
+	if err != nil {
+		return response{url: url, urls: []string{}, status: ErrorStatus(err), err: err}, err
+	}
+
+	f.quotas.Reserve(int64(len(e.body)))
+	defer f.quotas.Free(int64(len(e.body)))
+
+	f.collapse.Observe(url, e.body)
+
+	resp := response{url: url, urls: []string{}, status: e.status, redirects: e.redirects, contentType: e.header.Get("Content-Type")}
+	resp.cookies = ResponseCookies(e.header)
+	resp.header = e.header
+	resp.modified = LastModified(e.header)
+	behavior := f.rules.For(url)
+	storeBody := behavior.storeBody == nil || *behavior.storeBody
+	if (f.keepBody && storeBody) || (behavior.storeBody != nil && *behavior.storeBody) {
+		resp.body = e.body
+	}
+	if e.url != url {
+		resp.redirect = e.url
+	}
+	if f.store != nil && storeBody {
+		if resp.bodyHash, err = f.store.Put(e.body); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on body store: %v\n", err)
+		}
+	}
+	if len(f.extractors) != 0 {
+		resp.extracted = RunExtractors(f.extractors, e.url, resp.contentType, e.body)
+	}
+
+	contentType := e.header.Get("Content-Type")
+	switch behavior.parser {
+	case "none":
+		return resp, nil
+	case "html":
+		contentType = "text/html"
+	case "css":
+		contentType = "text/css"
+	case "js":
+		contentType = "text/javascript"
+	}
+
+	if IsCSS(contentType) {
+		if f.links.css || behavior.parser == "css" {
+			resp.urls = GetCSSLinks(e.url, string(e.body))
+		}
+		return resp, nil
+	}
+
+	if IsJavaScript(contentType) {
+		if f.links.js || behavior.parser == "js" {
+			resp.urls = GetJSLinks(e.url, string(e.body))
+		}
+		return resp, nil
+	}
+
+	if IsJSON(contentType) {
+		resp.urls = GetJSONLinks(e.url, e.header, e.body)
+		return resp, nil
+	}
+
+	if IsXML(contentType) && !IsXHTML(contentType) {
+		resp.urls, resp.text = GetXMLLinks(e.url, e.body)
+		resp.lang = DetectLanguage("", resp.text)
+		return resp, nil
+	}
+
+	if IsPDF(contentType) {
+		resp.urls, resp.text = ParsePDF(e.url, e.body)
+		resp.lang = DetectLanguage("", resp.text)
+		return resp, nil
+	}
+
+	doc := ParseDocument(e.url, bytes.NewReader(e.body), f.links)
+	resp.urls = doc.links
+	resp.anchors = doc.anchors
+	resp.alternates = doc.alternates
+	resp.canonical = doc.canonical
+	resp.amp = doc.amp
+	resp.mobile = doc.mobile
+	resp.icons = doc.icons
+	resp.manifest = doc.manifest
+	resp.images = doc.images
+	resp.resources = doc.resources
+	resp.a11y = doc.a11y.Findings()
+	resp.markup = doc.markup.Findings()
+	resp.title = strings.Join(strings.Fields(doc.a11y.title), " ")
+	resp.forms = doc.forms.forms
+	resp.description = strings.Join(strings.Fields(doc.description), " ")
+	// Last-Modified is only a fallback for dates missing from the page
+	if !doc.dates.published.IsZero() {
+		resp.published = doc.dates.published
+	}
+	if !doc.dates.modified.IsZero() {
+		resp.modified = doc.dates.modified
+	}
+	resp.lang = DetectLanguage(doc.lang, doc.text)
+	resp.text = doc.text
+	if f.links.frames == framesMerged {
+		f.mergeFrames(&resp, doc.frames, map[string]bool{URLKey(e.url): true}, 1)
+	}
+	if IsXHTML(contentType) {
+		xmlLinks, _ := GetXMLLinks(e.url, e.body)
+		resp.urls = MergeLinks(resp.urls, xmlLinks)
+	}
+	if doc.isAMP {
+		resp.variant = "amp"
+	}
+	resp.noindex = doc.noindex || HasNoindex(e.header.Get("X-Robots-Tag"))
+
+	return resp, nil
+}
+
+// resolve fills in the method and body of a request from the rules
+// matching its URL
+func (f fetcher) resolve(req fetchRequest) fetchRequest {
+	behavior := f.rules.For(req.url)
+	if len(req.method) == 0 {
+		req.method = behavior.method
+	}
+	if req.body == nil && len(behavior.body) != 0 {
+		req.body = []byte(behavior.body)
+	}
+
+	// Discovery only needs the status and type of files without links
+	if len(req.method) == 0 && f.discover && IsLeafURL(req.url) {
+		req.method = http.MethodHead
+	}
+	if len(req.method) == 0 {
+		req.method = http.MethodGet
+	}
+
+	return req
+}
+
+func (f fetcher) get(req fetchRequest) (entry, error) {
+	req = f.resolve(req)
+	url := req.url
+
+	// Only GET and HEAD requests without a body or headers of their own are
+	// cached, archived and shared by concurrent fetches of a URL, since
+	// neither is part of the cache and inflight keys
+	plain := (req.method == http.MethodGet || req.method == http.MethodHead) && len(req.body) == 0 && len(req.headers) == 0
+
+	if handler, ok := SchemeHandlers[schemeOf(url)]; ok {
+		if !plain {
+			return entry{}, unplainError(req, schemeOf(url)+" links")
+		}
+		if limited, ok := handler.(LimitedSchemeHandler); ok {
+			return limited.FetchLimited(url, f.maxBody)
+		}
+		return handler.Fetch(url)
+	}
+
+	if f.archive != nil {
+		if !plain {
+			return entry{}, unplainError(req, "an archive")
+		}
+		return f.archive.Get(url)
+	}
+
+	if !plain {
+		return f.download(req)
+	}
+
+	if f.cache.CanRead() {
+		if e, ok := f.cache.Get(url); ok {
+			return e, nil
+		}
+	}
+
+	if f.inflight == nil {
+		return f.download(req)
+	}
+
+	e, err, _ := f.inflight.Do(req.method+" "+URLKey(url), func() (interface{}, error) {
+		return f.download(req)
+	})
+
+	return e.(entry), err
+}
+
+// unplainError explains why a request cannot be sent to a target that only
+// takes plain GET and HEAD requests
+func unplainError(req fetchRequest, target string) error {
+	if req.method != http.MethodGet && req.method != http.MethodHead {
+		return fmt.Errorf("cannot send %v requests to %v", req.method, target)
+	}
+
+	return fmt.Errorf("cannot send requests with a body or headers to %v", target)
+}
+
+func (f fetcher) download(spec fetchRequest) (entry, error) {
+	url, method := spec.url, spec.method
+	req, err := http.NewRequest(method, url, bytes.NewReader(spec.body))
+	if err != nil {
+		return entry{}, err
+	}
+	for key, value := range f.negotiated {
+		req.Header.Set(key, value)
+	}
+	ApplyHeaders(req, f.headers)
+	behavior := f.rules.For(url)
+	for key, value := range behavior.headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range spec.headers {
+		req.Header.Set(key, value)
+	}
+
+	var redirects []redirectHop
+	req = req.WithContext(WithRedirectChain(req.Context(), &redirects))
+
+	if len(f.harDir) != 0 {
+		rec := &harRecorder{}
+		req = req.WithContext(WithHARRecorder(req.Context(), rec))
+		defer func() {
+			if err := WriteHAR(f.harDir, url, rec); err != nil {
+				fmt.Fprintf(os.Stderr, "Error on HAR: %v\n", err)
+			}
+		}()
+	}
+
+	host := hostOf(url)
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		f.hours.Wait()
+		maintenance.Wait(host)
+		if behavior.delay != nil {
+			f.pacer.WaitFor(host, *behavior.delay)
+		} else {
+			f.pacer.Wait(host)
+		}
+		redirects = redirects[:0]
+		if attempt > 0 && req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return entry{}, err
+			}
+		}
+		resp, err = f.client.Do(req)
+		if err != nil {
+			return entry{}, err
+		}
+
+		answered := hostOf(resp.Request.URL.String())
+		if resp.StatusCode != http.StatusServiceUnavailable || attempt == maintenanceRetries || !maintenance.Pause(answered, resp.Header) {
+			break
+		}
+		resp.Body.Close()
+		maintenance.Wait(answered)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return entry{}, statusError{resp.StatusCode}
+	}
+
+	reader := f.throttle.Reader(resp.Body)
+	if f.maxBody > 0 {
+		reader = io.LimitReader(reader, f.maxBody+1)
+	}
+
+	// Bodies of unknown length are reserved at the body limit, if any
+	expected := resp.ContentLength
+	if f.maxBody > 0 && (expected < 0 || expected > f.maxBody+1) {
+		expected = f.maxBody + 1
+	}
+	f.quotas.Reserve(expected)
+	defer f.quotas.Free(expected)
+
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return entry{}, err
+	}
+
+	if f.maxBody > 0 && int64(len(body)) > f.maxBody {
+		return entry{}, tooLargeError{f.maxBody}
+	}
+
+	if method == http.MethodHead {
+		return entry{resp.Request.URL.String(), resp.StatusCode, resp.Header, nil, redirects}, nil
+	}
+
+	if err := f.warcOut.Write(resp, body); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on WARC output: %v\n", err)
+	}
+
+	finalURL := resp.Request.URL.String()
+	if IsRedirect(resp.StatusCode) {
+		if location, err := resp.Location(); err == nil {
+			finalURL = location.String()
+		}
+	}
+
+	e := entry{finalURL, resp.StatusCode, resp.Header, body, redirects}
+	if f.cache.CanWrite() && method == http.MethodGet && len(spec.body) == 0 {
+		f.cache.Put(url, e)
+	}
+
+	return e, nil
+}
+
+// ---------- Parser ----------
+
+type result struct {
+	url          string
+	links        []string
+	linkStatuses []linkStatus
+	anchors      []anchor
+	alternates   []alternate
+	canonical    string
+	amp          string
+	mobile       string
+	icons        []string
+	manifest     string
+	images       []imageTag
+	forms        []form
+	resources    []string
+	cookies      []*http.Cookie
+	a11y         []string
+	markup       []string
+	lang         string
+	text         string
+	emails       []string
+	phones       []string
+	ip           string
+	reverseDNS   string
+	asn          string
+	provider     string
+	meta         map[string]string
+	variant      string
+	desktop      string
+	redirect     string
+	noindex      bool
+	contentType  string
+	bodyHash     string
+	status       int
+	title        string
+	description  string
+	navLinks     []string
+	published    time.Time
+	modified     time.Time
+	redirects    []redirectHop
+	header       http.Header
+	extracted    map[string]string
+	body         []byte
+	errClass     errorClass
+}
+
+// Parser parses responses
+type Parser interface {
+	Parse(resp response) (res result)
+}
+
+type parser struct{}
+
+// Parse parses responses
+func (p parser) Parse(resp response) result {
+	return result{
+		url:         resp.url,
+		links:       resp.urls,
+		anchors:     resp.anchors,
+		alternates:  resp.alternates,
+		canonical:   resp.canonical,
+		amp:         resp.amp,
+		mobile:      resp.mobile,
+		icons:       resp.icons,
+		manifest:    resp.manifest,
+		images:      resp.images,
+		forms:       resp.forms,
+		resources:   resp.resources,
+		cookies:     resp.cookies,
+		a11y:        resp.a11y,
+		markup:      resp.markup,
+		lang:        resp.lang,
+		text:        resp.text,
+		variant:     resp.variant,
+		desktop:     desktopOf(resp),
+		redirect:    resp.redirect,
+		noindex:     resp.noindex,
+		contentType: resp.contentType,
+		bodyHash:    resp.bodyHash,
+		status:      resp.status,
+		title:       resp.title,
+		description: resp.description,
+		navLinks:    resp.navLinks,
+		published:   resp.published,
+		modified:    resp.modified,
+		redirects:   resp.redirects,
+		header:      resp.header,
+		extracted:   resp.extracted,
+		body:        resp.body,
+		errClass:    ClassifyError(resp.err),
+	}
+}
+
+func desktopOf(resp response) string {
+	if len(resp.variant) == 0 || URLKey(resp.canonical) == URLKey(resp.url) {
+		return ""
+	}
+
+	return resp.canonical
+}
+
+// ---------- Links ----------
+
+type linkOptions struct {
+	css      bool
+	images   bool
+	variants bool
+	frames   frameMode
+	js       bool
+}
+
+type anchor struct {
+	url  string
+	text string
+	nav  bool
+}
+
+type document struct {
+	links       []string
+	anchors     []anchor
+	alternates  []alternate
+	canonical   string
+	amp         string
+	mobile      string
+	icons       []string
+	manifest    string
+	images      []imageTag
+	resources   []string
+	frames      []string
+	lang        string
+	text        string
+	description string
+	a11y        a11yFacts
+	markup      markupFacts
+	dates       dateFacts
+	forms       formFacts
+	context     linkContext
+	isAMP       bool
+	noindex     bool
+}
+
+// GetAllLinks retrieves all links from a HTML body
+func GetAllLinks(baseURL string, body io.Reader, opts linkOptions) []string {
+	return ParseDocument(baseURL, body, opts).links
+}
+
+// ParseDocument retrieves links and page metadata from a HTML body
+func ParseDocument(baseURL string, body io.Reader, opts linkOptions) document {
+	var doc document
+	var links []string
+	inStyle := false
+	inScript := false
+	inPicture := false
+	inAnchor := false
+	hidden := false
+	var text []string
+	var lines []string
+	page := html.NewTokenizer(body)
+	for {
+		tokenType := page.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			doc.links = links
+			doc.text = strings.Join(lines, "\n")
+			return doc
+		case html.DoctypeToken:
+			doc.markup.Doctype()
+		case html.TextToken:
+			raw := string(page.Text())
+			doc.a11y.Text(raw)
+			doc.dates.Text(raw)
+			if inAnchor {
+				text = append(text, raw)
+			}
+			if inStyle {
+				links = append(links, GetCSSLinks(baseURL, raw)...)
+			}
+			if inScript {
+				links = append(links, GetJSLinks(baseURL, raw)...)
+			}
+			if line := strings.Join(strings.Fields(raw), " "); !hidden && len(line) != 0 {
+				lines = append(lines, line)
+			}
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			token := page.Token()
+			doc.a11y.Token(tokenType, token)
+			doc.markup.Token(tokenType, token)
+			doc.dates.Token(tokenType, token)
+			doc.forms.Token(baseURL, tokenType, token)
+			doc.context.Token(tokenType, token)
+			if "a" == token.Data {
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						link := TrimLink(attr.Val)
+						if len(link) != 0 {
+							link = FixLink(baseURL, link)
+							links = append(links, link)
+							doc.anchors = append(doc.anchors, anchor{url: link, nav: doc.context.InBoilerplate()})
+							inAnchor = tokenType == html.StartTagToken
+							text = nil
+						}
+					}
+				}
+
+				if tokenType == html.EndTagToken && inAnchor {
+					doc.anchors[len(doc.anchors)-1].text = strings.Join(strings.Fields(strings.Join(text, " ")), " ")
+					inAnchor = false
+				}
+			}
+
+			if "script" == token.Data || "style" == token.Data || "noscript" == token.Data || "template" == token.Data {
+				hidden = tokenType == html.StartTagToken
+			}
+
+			if "html" == token.Data && tokenType != html.EndTagToken {
+				doc.lang = GetAttr(token, "lang")
+				for _, attr := range token.Attr {
+					if attr.Key == "amp" || attr.Key == "⚡" {
+						doc.isAMP = true
+					}
+				}
+			}
+
+			if "link" == token.Data && tokenType != html.EndTagToken &&
+				(HasAttr(token, "rel", "icon") || HasAttr(token, "rel", "apple-touch-icon")) {
+				if link := TrimLink(GetAttr(token, "href")); len(link) != 0 && !strings.HasPrefix(link, "data:") {
+					doc.icons = append(doc.icons, FixLink(baseURL, link))
+				}
+			}
+
+			if "link" == token.Data && tokenType != html.EndTagToken && HasAttr(token, "rel", "manifest") {
+				if link := TrimLink(GetAttr(token, "href")); len(link) != 0 {
+					doc.manifest = FixLink(baseURL, link)
+				}
+			}
+
+			if "link" == token.Data && tokenType != html.EndTagToken && HasAttr(token, "rel", "amphtml") {
+				if link := TrimLink(GetAttr(token, "href")); len(link) != 0 {
+					doc.amp = FixLink(baseURL, link)
+					if opts.variants {
+						links = append(links, doc.amp)
+					}
+				}
+			}
+
+			if "link" == token.Data && tokenType != html.EndTagToken && HasAttr(token, "rel", "alternate") &&
+				len(GetAttr(token, "media")) != 0 && len(GetAttr(token, "hreflang")) == 0 {
+				if link := TrimLink(GetAttr(token, "href")); len(link) != 0 {
+					doc.mobile = FixLink(baseURL, link)
+					if opts.variants {
+						links = append(links, doc.mobile)
+					}
+				}
+			}
+
+			if "link" == token.Data && tokenType != html.EndTagToken && HasAttr(token, "rel", "alternate") {
+				lang := strings.TrimSpace(GetAttr(token, "hreflang"))
+				if link := TrimLink(GetAttr(token, "href")); len(lang) != 0 && len(link) != 0 {
+					link = FixLink(baseURL, link)
+					doc.alternates = append(doc.alternates, alternate{strings.ToLower(lang), link})
+					links = append(links, link)
+				}
+			}
+
+			if "link" == token.Data && tokenType != html.EndTagToken && HasAttr(token, "rel", "canonical") {
+				if link := TrimLink(GetAttr(token, "href")); len(link) != 0 {
+					doc.canonical = FixLink(baseURL, link)
+					links = append(links, doc.canonical)
+				}
+			}
+
+			if "meta" == token.Data && strings.EqualFold(GetAttr(token, "name"), "robots") {
+				doc.noindex = doc.noindex || HasNoindex(GetAttr(token, "content"))
+			}
+
+			if "meta" == token.Data && tokenType != html.EndTagToken && strings.EqualFold(GetAttr(token, "name"), "description") && len(doc.description) == 0 {
+				doc.description = GetAttr(token, "content")
+			}
+
+			if opts.js && "script" == token.Data {
+				inScript = tokenType == html.StartTagToken && IsScriptType(GetAttr(token, "type"))
+				if link := TrimLink(GetAttr(token, "src")); tokenType != html.EndTagToken && len(link) != 0 && !strings.HasPrefix(link, "data:") {
+					links = append(links, FixLink(baseURL, link))
+				}
+			}
+
+			if opts.css {
+				if "style" == token.Data {
+					inStyle = tokenType == html.StartTagToken
+				}
+				if "link" == token.Data && HasAttr(token, "rel", "stylesheet") {
+					if link := TrimLink(GetAttr(token, "href")); len(link) != 0 {
+						links = append(links, FixLink(baseURL, link))
+					}
+				}
+				if style := GetAttr(token, "style"); len(style) != 0 {
+					links = append(links, GetCSSLinks(baseURL, style)...)
+				}
+			}
+
+			if "img" == token.Data && tokenType != html.EndTagToken {
+				if src := TrimLink(GetAttr(token, "src")); len(src) != 0 && !strings.HasPrefix(src, "data:") {
+					img := imageTag{src: FixLink(baseURL, src)}
+					for _, attr := range token.Attr {
+						switch attr.Key {
+						case "alt":
+							img.alt = attr.Val
+							img.hasAlt = true
+						case "width":
+							img.hasWidth = true
+						case "height":
+							img.hasHeight = true
+						}
+					}
+					doc.images = append(doc.images, img)
+				}
+			}
+
+			if ("frame" == token.Data || "iframe" == token.Data) && tokenType != html.EndTagToken {
+				if src := TrimLink(GetAttr(token, "src")); len(src) != 0 && !strings.HasPrefix(src, "data:") && !strings.HasPrefix(src, "about:") {
+					doc.frames = append(doc.frames, FixLink(baseURL, src))
+					if opts.frames == framesSeparate {
+						links = append(links, FixLink(baseURL, src))
+					}
+				}
+			}
+
+			if ("script" == token.Data || "iframe" == token.Data) && tokenType != html.EndTagToken {
+				if src := TrimLink(GetAttr(token, "src")); len(src) != 0 && !strings.HasPrefix(src, "data:") {
+					doc.resources = append(doc.resources, FixLink(baseURL, src))
+				}
+			}
+
+			if "img" == token.Data && tokenType != html.EndTagToken && IsPixel(token) {
+				if src := TrimLink(GetAttr(token, "src")); len(src) != 0 && !strings.HasPrefix(src, "data:") {
+					doc.resources = append(doc.resources, FixLink(baseURL, src))
+				}
+			}
+
+			if opts.images && tokenType != html.EndTagToken {
+				if "img" == token.Data || ("source" == token.Data && inPicture) {
+					var refs []string
+					if "img" == token.Data {
+						refs = append(refs, GetAttr(token, "src"))
+					}
+					refs = append(refs, ParseSrcset(GetAttr(token, "srcset"))...)
+					for _, ref := range refs {
+						if link := TrimLink(ref); len(link) != 0 && !strings.HasPrefix(link, "data:") {
+							links = append(links, FixLink(baseURL, link))
+						}
+					}
+				}
+			}
+			if "picture" == token.Data {
+				inPicture = tokenType == html.StartTagToken
+			}
+		}
+	}
+}
+
+// IsPixel reports whether an img tag is a tracking pixel
+func IsPixel(token html.Token) bool {
+	width, height := strings.TrimSpace(GetAttr(token, "width")), strings.TrimSpace(GetAttr(token, "height"))
+	hidden := strings.Contains(strings.ReplaceAll(GetAttr(token, "style"), " ", ""), "display:none")
+
+	return hidden || ((width == "0" || width == "1") && (height == "0" || height == "1"))
+}
+
+// GetAttr returns the value of a token attribute
+func GetAttr(token html.Token, key string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+// HasNoindex reports whether a robots directive list contains noindex
+func HasNoindex(directives string) bool {
+	for _, directive := range strings.Split(directives, ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.EqualFold(directive, "noindex") || strings.EqualFold(directive, "none") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasAttr reports whether a space separated token attribute contains a value
+func HasAttr(token html.Token, key string, value string) bool {
+	for _, field := range strings.Fields(GetAttr(token, key)) {
+		if strings.EqualFold(field, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TrimLink removes characters in links
+func TrimLink(link string) string {
+	link = strings.TrimSpace(link)
+	link = strings.SplitN(link, "#", 2)[0]
+	link = strings.Trim(link, "#")
+	link = strings.TrimSpace(link)
+
+	return link
+}
+
+// FixLink fixes broken links
+func FixLink(baseURL string, link string) string {
+	if base, err := neturl.Parse(baseURL); err == nil {
+		if ref, err := neturl.Parse(link); err == nil {
+			return base.ResolveReference(ref).String()
+		}
+	}
 
-// FixLink fixes broken links
-func FixLink(baseURL string, link string) string {
 	baseURL = strings.TrimRight(baseURL, "/")
 	if len(link) > 1 && link[0:2] == "//" {
 		link = strings.TrimLeft(link, "/")
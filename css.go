@@ -0,0 +1,31 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ---------- CSS ----------
+
+var cssURL = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+var cssImport = regexp.MustCompile(`@import\s+['"]([^'"]+)['"]`)
+
+// GetCSSLinks retrieves all url() and @import references from CSS
+func GetCSSLinks(baseURL string, css string) []string {
+	var links []string
+	for _, re := range []*regexp.Regexp{cssURL, cssImport} {
+		for _, match := range re.FindAllStringSubmatch(css, -1) {
+			link := TrimLink(match[1])
+			if len(link) != 0 && !strings.HasPrefix(link, "data:") {
+				links = append(links, FixLink(baseURL, link))
+			}
+		}
+	}
+
+	return links
+}
+
+// IsCSS reports whether a content type is a stylesheet
+func IsCSS(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), "text/css")
+}
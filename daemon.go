@@ -0,0 +1,741 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative daemon.proto
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// ---------- Daemon ----------
+
+// The daemon runs crawl jobs submitted over gRPC, see daemon.proto. Every
+// job is crawled by a process of its own writing JSONL results, so jobs
+// never share frontiers, visited sets or any other crawl state, and quotas
+// limit the workers and pages of each job.
+
+type crawlJob struct {
+	mutex     sync.Mutex
+	id        string
+	seq       int
+	url       string
+	workers   int32
+	maxPages  int32
+	cmd       *exec.Cmd
+	debugAddr string
+	results   []*JobResult
+	errors    int64
+	state     JobState
+	err       string
+	paused    bool
+	cancelled bool
+	finished  time.Time
+	changed   chan struct{}
+	board     *dashboard
+}
+
+// update changes the job and wakes its streams
+func (j *crawlJob) update(change func()) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	change()
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// Progress describes the state of the job
+func (j *crawlJob) Progress() *JobProgress {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return &JobProgress{
+		Id:       j.id,
+		State:    j.state,
+		Pages:    int64(len(j.results)),
+		Errors:   j.errors,
+		Error:    j.err,
+		Url:      j.url,
+		Workers:  j.workers,
+		MaxPages: j.maxPages,
+		Paused:   j.paused,
+	}
+}
+
+// From returns the results from index i on, whether no more will come, and
+// a channel closed on the next change
+func (j *crawlJob) From(i int) ([]*JobResult, bool, <-chan struct{}) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.results[i:], j.state != JobState_JOB_STATE_RUNNING, j.changed
+}
+
+// Running reports whether the process of the job still runs
+func (j *crawlJob) Running() bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.state == JobState_JOB_STATE_RUNNING
+}
+
+// FinishedBefore reports whether the job finished before t
+func (j *crawlJob) FinishedBefore(t time.Time) bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.state != JobState_JOB_STATE_RUNNING && j.finished.Before(t)
+}
+
+// Cancel kills the process of a running job
+func (j *crawlJob) Cancel() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.state == JobState_JOB_STATE_RUNNING && !j.cancelled {
+		j.cancelled = true
+		j.cmd.Process.Kill()
+	}
+}
+
+// Queue fetches the queue of the running process of the job from its debug
+// server
+func (j *crawlJob) Queue(ctx context.Context, n int) (queueState, error) {
+	var state queueState
+	res, err := j.debug(ctx, http.MethodGet, "/debug/queue?n="+strconv.Itoa(n))
+	if err != nil {
+		return state, err
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(&state); err != nil {
+		return state, status.Errorf(codes.Unavailable, "job %v: %v", j.id, err)
+	}
+
+	return state, nil
+}
+
+// SetPaused pauses or resumes the running process of the job through its
+// debug server
+func (j *crawlJob) SetPaused(ctx context.Context, paused bool) error {
+	path := "/debug/resume"
+	if paused {
+		path = "/debug/pause"
+	}
+	res, err := j.debug(ctx, http.MethodPost, path)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	j.update(func() {
+		j.paused = paused
+	})
+
+	return nil
+}
+
+// debug calls the debug server of the running process of the job
+func (j *crawlJob) debug(ctx context.Context, method string, path string) (*http.Response, error) {
+	if !j.Running() {
+		return nil, status.Errorf(codes.FailedPrecondition, "job %v is not running", j.id)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://"+j.debugAddr+path, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "job %v: %v", j.id, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, status.Errorf(codes.Unavailable, "job %v: %v", j.id, res.Status)
+	}
+
+	return res, nil
+}
+
+// start starts the process of the job and reads its results until it exits
+func (j *crawlJob) start() error {
+	stderr := &bytes.Buffer{}
+	j.cmd.Stderr = stderr
+	stdout, err := j.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := j.cmd.Start(); err != nil {
+		return err
+	}
+
+	go j.read(stdout, stderr)
+
+	return nil
+}
+
+func (j *crawlJob) read(stdout io.Reader, stderr *bytes.Buffer) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		// Summaries follow the results as plain text
+		line := scanner.Bytes()
+		if !bytes.HasPrefix(line, []byte("{")) {
+			continue
+		}
+
+		var res jsonResult
+		if err := json.Unmarshal(line, &res); err != nil {
+			continue
+		}
+		j.update(func() {
+			j.results = append(j.results, &JobResult{
+				Url:       res.URL,
+				Error:     res.Error,
+				Redirect:  res.Redirect,
+				Canonical: res.Canonical,
+				Noindex:   res.Noindex,
+				Links:     res.Links,
+			})
+			if len(res.Error) != 0 {
+				j.errors++
+			}
+		})
+		j.board.Add(result{url: res.URL, errClass: errorClass(res.Error)})
+	}
+	io.Copy(io.Discard, stdout)
+
+	err := j.cmd.Wait()
+	j.board.Finish()
+	j.update(func() {
+		j.finished = time.Now()
+		switch {
+		case j.cancelled:
+			j.state = JobState_JOB_STATE_CANCELLED
+		case err == nil:
+			j.state = JobState_JOB_STATE_DONE
+		default:
+			j.state = JobState_JOB_STATE_FAILED
+			j.err = err.Error()
+			if lines := strings.Split(strings.TrimSpace(stderr.String()), "\n"); len(lines[len(lines)-1]) != 0 {
+				j.err = lines[len(lines)-1]
+			}
+		}
+	})
+}
+
+// daemonQuotas limit the jobs of a daemon. Zero means no limit.
+type daemonQuotas struct {
+	// workers is the number of workers of jobs that do not ask for one
+	workers    int32
+	maxWorkers int32
+	maxPages   int32
+	maxJobs    int
+}
+
+type daemon struct {
+	UnimplementedDaemonServer
+	mutex   sync.Mutex
+	command []string
+	quotas  daemonQuotas
+	jobs    map[string]*crawlJob
+	nextJob int
+}
+
+// NewDaemon creates a daemon crawling jobs by running command with the
+// flags of each job
+func NewDaemon(command []string, quotas daemonQuotas) *daemon {
+	return &daemon{command: command, quotas: quotas, jobs: map[string]*crawlJob{}}
+}
+
+func (d *daemon) job(id string) (*crawlJob, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	j, ok := d.jobs[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no job %v", id)
+	}
+
+	return j, nil
+}
+
+// Evict removes the jobs that finished before t
+func (d *daemon) Evict(t time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for id, j := range d.jobs {
+		if j.FinishedBefore(t) {
+			delete(d.jobs, id)
+		}
+	}
+}
+
+// Submit starts a job
+func (d *daemon) Submit(ctx context.Context, req *SubmitRequest) (*SubmitReply, error) {
+	if u, err := neturl.Parse(req.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid url: %v", req.Url)
+	}
+	depth := req.Depth
+	if depth == 0 {
+		depth = 1
+	}
+	if depth < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid depth: %v", req.Depth)
+	}
+	depthMode := req.DepthMode
+	if len(depthMode) == 0 {
+		depthMode = "hops"
+	}
+	if _, err := ParseDepthMode(depthMode); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	workers := req.Workers
+	if workers == 0 {
+		workers = d.quotas.workers
+	}
+	if d.quotas.maxWorkers > 0 && workers > d.quotas.maxWorkers {
+		workers = d.quotas.maxWorkers
+	}
+	if workers < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid workers: %v", workers)
+	}
+	maxPages := req.MaxPages
+	if maxPages < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid max pages: %v", req.MaxPages)
+	}
+	if d.quotas.maxPages > 0 && (maxPages == 0 || maxPages > d.quotas.maxPages) {
+		maxPages = d.quotas.maxPages
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	running := 0
+	for _, j := range d.jobs {
+		if j.Running() {
+			running++
+		}
+	}
+	if d.quotas.maxJobs > 0 && running >= d.quotas.maxJobs {
+		return nil, status.Errorf(codes.ResourceExhausted, "%v jobs already running", running)
+	}
+
+	debugAddr, err := freeAddr()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	args := append(d.command[1:len(d.command):len(d.command)],
+		"-url", req.Url,
+		"-depth", strconv.Itoa(int(depth)),
+		"-depth-mode", depthMode,
+		"-workers", strconv.Itoa(int(workers)),
+		"-max-pages", strconv.Itoa(int(maxPages)),
+		"-output", "jsonl",
+		"-verbose=false",
+		"-debug-addr", debugAddr,
+	)
+	j := &crawlJob{
+		seq:       d.nextJob + 1,
+		url:       req.Url,
+		workers:   workers,
+		maxPages:  maxPages,
+		cmd:       exec.Command(d.command[0], args...),
+		debugAddr: debugAddr,
+		state:     JobState_JOB_STATE_RUNNING,
+		changed:   make(chan struct{}),
+		board:     NewDashboard(req.Url),
+	}
+	j.id = "job-" + strconv.Itoa(j.seq)
+	if err := j.start(); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	d.nextJob++
+	d.jobs[j.id] = j
+
+	return &SubmitReply{Id: j.id}, nil
+}
+
+// List describes every job in order of submission
+func (d *daemon) List(ctx context.Context, req *ListRequest) (*ListReply, error) {
+	d.mutex.Lock()
+	var jobs []*crawlJob
+	for _, j := range d.jobs {
+		jobs = append(jobs, j)
+	}
+	d.mutex.Unlock()
+
+	sort.Slice(jobs, func(a, b int) bool {
+		return jobs[a].seq < jobs[b].seq
+	})
+	reply := &ListReply{}
+	for _, j := range jobs {
+		reply.Jobs = append(reply.Jobs, j.Progress())
+	}
+
+	return reply, nil
+}
+
+// Cancel stops a job. Its streams end with the results completed so far.
+func (d *daemon) Cancel(ctx context.Context, req *JobRequest) (*JobProgress, error) {
+	j, err := d.job(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	j.Cancel()
+
+	return j.Progress(), nil
+}
+
+// Inspect describes the queue of a running job
+func (d *daemon) Inspect(ctx context.Context, req *InspectRequest) (*JobQueue, error) {
+	j, err := d.job(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	n := int(req.Next)
+	if n <= 0 {
+		n = 20
+	}
+	state, err := j.Queue(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := &JobQueue{
+		Id:          j.id,
+		Visited:     state.Visited,
+		Results:     state.Results,
+		SitesLeft:   state.SitesLeft,
+		Queued:      state.Queued,
+		Next:        state.Next,
+		Inflight:    map[string]int32{},
+		PausedHosts: map[string]string{},
+	}
+	for host, count := range state.Inflight {
+		queue.Inflight[host] = int32(count)
+	}
+	for host, until := range state.Paused {
+		queue.PausedHosts[host] = until.Format(time.RFC3339)
+	}
+
+	return queue, nil
+}
+
+// Pause stops a running job from starting new fetches
+func (d *daemon) Pause(ctx context.Context, req *JobRequest) (*JobProgress, error) {
+	return d.setPaused(ctx, req.Id, true)
+}
+
+// Resume lets a paused job continue
+func (d *daemon) Resume(ctx context.Context, req *JobRequest) (*JobProgress, error) {
+	return d.setPaused(ctx, req.Id, false)
+}
+
+func (d *daemon) setPaused(ctx context.Context, id string, paused bool) (*JobProgress, error) {
+	j, err := d.job(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := j.SetPaused(ctx, paused); err != nil {
+		return nil, err
+	}
+
+	return j.Progress(), nil
+}
+
+// freeAddr returns a loopback address with a free port for the debug server
+// of a job
+func freeAddr() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+
+	return listener.Addr().String(), nil
+}
+
+// StreamResults sends the results of a job until it finishes
+func (d *daemon) StreamResults(req *JobRequest, stream grpc.ServerStreamingServer[JobResult]) error {
+	j, err := d.job(req.Id)
+	if err != nil {
+		return err
+	}
+
+	for sent := 0; ; {
+		results, finished, changed := j.From(sent)
+		for _, res := range results {
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+		}
+		sent += len(results)
+		if finished {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// StreamProgress sends the progress of a job when it changes, at most once
+// a second, until it finishes
+func (d *daemon) StreamProgress(req *JobRequest, stream grpc.ServerStreamingServer[JobProgress]) error {
+	j, err := d.job(req.Id)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var last *JobProgress
+	for {
+		_, _, changed := j.From(0)
+		progress := j.Progress()
+		if last == nil || progress.Pages != last.Pages || progress.State != last.State || progress.Paused != last.Paused {
+			if err := stream.Send(progress); err != nil {
+				return err
+			}
+			last = progress
+		}
+		if progress.State != JobState_JOB_STATE_RUNNING {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// RunDaemon serves the gRPC API until the process is stopped
+func RunDaemon(args []string) {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	listen := flags.String("listen", ":7800", "Set address to serve the gRPC API on.")
+	workers := flags.Int("workers", 4, "Set number of workers of jobs that do not ask for a number.")
+	maxWorkers := flags.Int("max-workers", 0, "Set to > 0 to limit the workers of a job.")
+	maxPages := flags.Int("max-pages", 0, "Set to > 0 to limit the pages of a job.")
+	maxJobs := flags.Int("max-jobs", 0, "Set to > 0 to limit the number of running jobs.")
+	retention := flags.Duration("retention", time.Hour, "Set how long finished jobs are kept, 0 keeps them until the daemon stops.")
+	dashboardAddr := flags.String("dashboard-addr", "", "Set address to serve a dashboard of the jobs on, e.g. :8080.")
+	flags.Parse(args)
+
+	if *workers < 1 {
+		fmt.Fprintln(os.Stderr, "workers must be >= 1")
+		os.Exit(2)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	d := NewDaemon([]string{executable}, daemonQuotas{int32(*workers), int32(*maxWorkers), int32(*maxPages), *maxJobs})
+	if *retention > 0 {
+		go func() {
+			for range time.Tick(min(*retention, time.Minute)) {
+				d.Evict(time.Now().Add(-*retention))
+			}
+		}()
+	}
+
+	if len(*dashboardAddr) != 0 {
+		go func() {
+			if err := http.ListenAndServe(*dashboardAddr, d.Dashboard()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error on dashboard: %v\n", err)
+			}
+		}()
+	}
+
+	server := grpc.NewServer()
+	RegisterDaemonServer(server, d)
+	fmt.Printf("Daemon: listening on %v\n", listener.Addr())
+	if err := server.Serve(listener); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// ---------- Job client ----------
+
+// RunJob calls the gRPC API of a daemon
+func RunJob(args []string) {
+	flags := flag.NewFlagSet("job", flag.ExitOnError)
+	addr := flags.String("daemon", "localhost:7800", "Set address of the daemon.")
+	url := flags.String("url", "https://golang.org/", "Set starting URL of a submitted job.")
+	depth := flags.Int("depth", 1, "Set to >= 1 to specify depth of a submitted job.")
+	depthMode := flags.String("depth-mode", "hops", "Set depth mode of a submitted job: hops or path.")
+	workers := flags.Int("workers", 0, "Set to > 0 to ask for that many workers for a submitted job.")
+	maxPages := flags.Int("max-pages", 0, "Set to > 0 to crawl at most that many pages in a submitted job.")
+	next := flags.Int("next", 20, "Set number of queued URLs shown by inspect.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: GoCrawler job [flags] submit | list | results <id> | progress <id> | inspect <id> | pause <id> | resume <id> | cancel <id>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	command := flags.Arg(0)
+	id := flags.Arg(1)
+	switch {
+	case command == "submit" || command == "list":
+	case (command == "results" || command == "progress" || command == "inspect" || command == "pause" || command == "resume" || command == "cancel") && len(id) != 0:
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	defer conn.Close()
+
+	client := NewDaemonClient(conn)
+	ctx := context.Background()
+	switch command {
+	case "submit":
+		var reply *SubmitReply
+		reply, err = client.Submit(ctx, &SubmitRequest{
+			Url:       *url,
+			Depth:     int32(*depth),
+			DepthMode: *depthMode,
+			Workers:   int32(*workers),
+			MaxPages:  int32(*maxPages),
+		})
+		if err == nil {
+			fmt.Println(reply.Id)
+		}
+	case "list":
+		var reply *ListReply
+		if reply, err = client.List(ctx, &ListRequest{}); err == nil {
+			for _, p := range reply.Jobs {
+				printProgress(p)
+			}
+		}
+	case "cancel":
+		var p *JobProgress
+		if p, err = client.Cancel(ctx, &JobRequest{Id: id}); err == nil {
+			printProgress(p)
+		}
+	case "pause":
+		var p *JobProgress
+		if p, err = client.Pause(ctx, &JobRequest{Id: id}); err == nil {
+			printProgress(p)
+		}
+	case "resume":
+		var p *JobProgress
+		if p, err = client.Resume(ctx, &JobRequest{Id: id}); err == nil {
+			printProgress(p)
+		}
+	case "inspect":
+		var queue *JobQueue
+		if queue, err = client.Inspect(ctx, &InspectRequest{Id: id, Next: int32(*next)}); err == nil {
+			printQueue(queue)
+		}
+	case "results":
+		var stream grpc.ServerStreamingClient[JobResult]
+		stream, err = client.StreamResults(ctx, &JobRequest{Id: id})
+		for err == nil {
+			var res *JobResult
+			if res, err = stream.Recv(); err == nil {
+				textOutput{}.Write(result{url: res.Url, errClass: errorClass(res.Error)})
+			}
+		}
+	case "progress":
+		var stream grpc.ServerStreamingClient[JobProgress]
+		stream, err = client.StreamProgress(ctx, &JobRequest{Id: id})
+		for err == nil {
+			var p *JobProgress
+			if p, err = stream.Recv(); err == nil {
+				printProgress(p)
+			}
+		}
+	}
+	if err != nil && err != io.EOF {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func printQueue(q *JobQueue) {
+	fmt.Printf("Queue: %v, %v visited, %v results, %v sites left, %v queued\n", q.Id, q.Visited, q.Results, q.SitesLeft, q.Queued)
+	for _, url := range q.Next {
+		fmt.Printf("Next: %v\n", url)
+	}
+
+	hosts := make([]string, 0, len(q.Inflight))
+	for host := range q.Inflight {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		fmt.Printf("In flight: %v (%v)\n", host, q.Inflight[host])
+	}
+
+	hosts = hosts[:0]
+	for host := range q.PausedHosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		fmt.Printf("Host paused: %v until %v\n", host, q.PausedHosts[host])
+	}
+}
+
+func printProgress(p *JobProgress) {
+	state := p.State.String()
+	if p.Paused && p.State == JobState_JOB_STATE_RUNNING {
+		state += " (paused)"
+	}
+	fmt.Printf("Progress: %v %v %v, %v pages, %v errors\n", p.Id, state, p.Url, p.Pages, p.Errors)
+	if len(p.Error) != 0 {
+		fmt.Printf("Error: %v\n", p.Error)
+	}
+}
@@ -0,0 +1,827 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: daemon.proto
+
+package main
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type JobState int32
+
+const (
+	JobState_JOB_STATE_UNSPECIFIED JobState = 0
+	JobState_JOB_STATE_RUNNING     JobState = 1
+	JobState_JOB_STATE_DONE        JobState = 2
+	JobState_JOB_STATE_FAILED      JobState = 3
+	JobState_JOB_STATE_CANCELLED   JobState = 4
+)
+
+// Enum value maps for JobState.
+var (
+	JobState_name = map[int32]string{
+		0: "JOB_STATE_UNSPECIFIED",
+		1: "JOB_STATE_RUNNING",
+		2: "JOB_STATE_DONE",
+		3: "JOB_STATE_FAILED",
+		4: "JOB_STATE_CANCELLED",
+	}
+	JobState_value = map[string]int32{
+		"JOB_STATE_UNSPECIFIED": 0,
+		"JOB_STATE_RUNNING":     1,
+		"JOB_STATE_DONE":        2,
+		"JOB_STATE_FAILED":      3,
+		"JOB_STATE_CANCELLED":   4,
+	}
+)
+
+func (x JobState) Enum() *JobState {
+	p := new(JobState)
+	*p = x
+	return p
+}
+
+func (x JobState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JobState) Descriptor() protoreflect.EnumDescriptor {
+	return file_daemon_proto_enumTypes[0].Descriptor()
+}
+
+func (JobState) Type() protoreflect.EnumType {
+	return &file_daemon_proto_enumTypes[0]
+}
+
+func (x JobState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JobState.Descriptor instead.
+func (JobState) EnumDescriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{0}
+}
+
+type SubmitRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Depth defaults to 1
+	Depth int32 `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`
+	// Depth mode is hops or path, defaults to hops
+	DepthMode string `protobuf:"bytes,3,opt,name=depth_mode,json=depthMode,proto3" json:"depth_mode,omitempty"`
+	// Workers and max pages default to and are capped by the quotas of the daemon
+	Workers       int32 `protobuf:"varint,4,opt,name=workers,proto3" json:"workers,omitempty"`
+	MaxPages      int32 `protobuf:"varint,5,opt,name=max_pages,json=maxPages,proto3" json:"max_pages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitRequest) Reset() {
+	*x = SubmitRequest{}
+	mi := &file_daemon_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitRequest) ProtoMessage() {}
+
+func (x *SubmitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitRequest.ProtoReflect.Descriptor instead.
+func (*SubmitRequest) Descriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubmitRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *SubmitRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *SubmitRequest) GetDepthMode() string {
+	if x != nil {
+		return x.DepthMode
+	}
+	return ""
+}
+
+func (x *SubmitRequest) GetWorkers() int32 {
+	if x != nil {
+		return x.Workers
+	}
+	return 0
+}
+
+func (x *SubmitRequest) GetMaxPages() int32 {
+	if x != nil {
+		return x.MaxPages
+	}
+	return 0
+}
+
+type SubmitReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitReply) Reset() {
+	*x = SubmitReply{}
+	mi := &file_daemon_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitReply) ProtoMessage() {}
+
+func (x *SubmitReply) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitReply.ProtoReflect.Descriptor instead.
+func (*SubmitReply) Descriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubmitReply) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type JobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobRequest) Reset() {
+	*x = JobRequest{}
+	mi := &file_daemon_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobRequest) ProtoMessage() {}
+
+func (x *JobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
+func (*JobRequest) Descriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *JobRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type InspectRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Next is the number of queued URLs to return, defaults to 20
+	Next          int32 `protobuf:"varint,2,opt,name=next,proto3" json:"next,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InspectRequest) Reset() {
+	*x = InspectRequest{}
+	mi := &file_daemon_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InspectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InspectRequest) ProtoMessage() {}
+
+func (x *InspectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InspectRequest.ProtoReflect.Descriptor instead.
+func (*InspectRequest) Descriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *InspectRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *InspectRequest) GetNext() int32 {
+	if x != nil {
+		return x.Next
+	}
+	return 0
+}
+
+type ListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_daemon_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{4}
+}
+
+type ListReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jobs          []*JobProgress         `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReply) Reset() {
+	*x = ListReply{}
+	mi := &file_daemon_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReply) ProtoMessage() {}
+
+func (x *ListReply) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReply.ProtoReflect.Descriptor instead.
+func (*ListReply) Descriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListReply) GetJobs() []*JobProgress {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+type JobResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Error is the error class, empty on success
+	Error         string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Redirect      string   `protobuf:"bytes,3,opt,name=redirect,proto3" json:"redirect,omitempty"`
+	Canonical     string   `protobuf:"bytes,4,opt,name=canonical,proto3" json:"canonical,omitempty"`
+	Noindex       bool     `protobuf:"varint,5,opt,name=noindex,proto3" json:"noindex,omitempty"`
+	Links         []string `protobuf:"bytes,6,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobResult) Reset() {
+	*x = JobResult{}
+	mi := &file_daemon_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobResult) ProtoMessage() {}
+
+func (x *JobResult) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobResult.ProtoReflect.Descriptor instead.
+func (*JobResult) Descriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *JobResult) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *JobResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *JobResult) GetRedirect() string {
+	if x != nil {
+		return x.Redirect
+	}
+	return ""
+}
+
+func (x *JobResult) GetCanonical() string {
+	if x != nil {
+		return x.Canonical
+	}
+	return ""
+}
+
+func (x *JobResult) GetNoindex() bool {
+	if x != nil {
+		return x.Noindex
+	}
+	return false
+}
+
+func (x *JobResult) GetLinks() []string {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+type JobProgress struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	State  JobState               `protobuf:"varint,2,opt,name=state,proto3,enum=gocrawler.v1.JobState" json:"state,omitempty"`
+	Pages  int64                  `protobuf:"varint,3,opt,name=pages,proto3" json:"pages,omitempty"`
+	Errors int64                  `protobuf:"varint,4,opt,name=errors,proto3" json:"errors,omitempty"`
+	// Error is the reason a failed job stopped
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Url           string `protobuf:"bytes,6,opt,name=url,proto3" json:"url,omitempty"`
+	Workers       int32  `protobuf:"varint,7,opt,name=workers,proto3" json:"workers,omitempty"`
+	MaxPages      int32  `protobuf:"varint,8,opt,name=max_pages,json=maxPages,proto3" json:"max_pages,omitempty"`
+	Paused        bool   `protobuf:"varint,9,opt,name=paused,proto3" json:"paused,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobProgress) Reset() {
+	*x = JobProgress{}
+	mi := &file_daemon_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobProgress) ProtoMessage() {}
+
+func (x *JobProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobProgress.ProtoReflect.Descriptor instead.
+func (*JobProgress) Descriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *JobProgress) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *JobProgress) GetState() JobState {
+	if x != nil {
+		return x.State
+	}
+	return JobState_JOB_STATE_UNSPECIFIED
+}
+
+func (x *JobProgress) GetPages() int64 {
+	if x != nil {
+		return x.Pages
+	}
+	return 0
+}
+
+func (x *JobProgress) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *JobProgress) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *JobProgress) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *JobProgress) GetWorkers() int32 {
+	if x != nil {
+		return x.Workers
+	}
+	return 0
+}
+
+func (x *JobProgress) GetMaxPages() int32 {
+	if x != nil {
+		return x.MaxPages
+	}
+	return 0
+}
+
+func (x *JobProgress) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+type JobQueue struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Visited   int64                  `protobuf:"varint,2,opt,name=visited,proto3" json:"visited,omitempty"`
+	Results   int64                  `protobuf:"varint,3,opt,name=results,proto3" json:"results,omitempty"`
+	SitesLeft int64                  `protobuf:"varint,4,opt,name=sites_left,json=sitesLeft,proto3" json:"sites_left,omitempty"`
+	Queued    int64                  `protobuf:"varint,5,opt,name=queued,proto3" json:"queued,omitempty"`
+	// Next are the next queued URLs, in shard order
+	Next []string `protobuf:"bytes,6,rep,name=next,proto3" json:"next,omitempty"`
+	// Inflight counts the requests in flight per host
+	Inflight map[string]int32 `protobuf:"bytes,7,rep,name=inflight,proto3" json:"inflight,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Paused hosts are paused for maintenance until RFC 3339 times
+	PausedHosts   map[string]string `protobuf:"bytes,8,rep,name=paused_hosts,json=pausedHosts,proto3" json:"paused_hosts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobQueue) Reset() {
+	*x = JobQueue{}
+	mi := &file_daemon_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobQueue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobQueue) ProtoMessage() {}
+
+func (x *JobQueue) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobQueue.ProtoReflect.Descriptor instead.
+func (*JobQueue) Descriptor() ([]byte, []int) {
+	return file_daemon_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *JobQueue) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *JobQueue) GetVisited() int64 {
+	if x != nil {
+		return x.Visited
+	}
+	return 0
+}
+
+func (x *JobQueue) GetResults() int64 {
+	if x != nil {
+		return x.Results
+	}
+	return 0
+}
+
+func (x *JobQueue) GetSitesLeft() int64 {
+	if x != nil {
+		return x.SitesLeft
+	}
+	return 0
+}
+
+func (x *JobQueue) GetQueued() int64 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *JobQueue) GetNext() []string {
+	if x != nil {
+		return x.Next
+	}
+	return nil
+}
+
+func (x *JobQueue) GetInflight() map[string]int32 {
+	if x != nil {
+		return x.Inflight
+	}
+	return nil
+}
+
+func (x *JobQueue) GetPausedHosts() map[string]string {
+	if x != nil {
+		return x.PausedHosts
+	}
+	return nil
+}
+
+var File_daemon_proto protoreflect.FileDescriptor
+
+const file_daemon_proto_rawDesc = "" +
+	"\n" +
+	"\fdaemon.proto\x12\fgocrawler.v1\"\x8d\x01\n" +
+	"\rSubmitRequest\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x05R\x05depth\x12\x1d\n" +
+	"\n" +
+	"depth_mode\x18\x03 \x01(\tR\tdepthMode\x12\x18\n" +
+	"\aworkers\x18\x04 \x01(\x05R\aworkers\x12\x1b\n" +
+	"\tmax_pages\x18\x05 \x01(\x05R\bmaxPages\"\x1d\n" +
+	"\vSubmitReply\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x1c\n" +
+	"\n" +
+	"JobRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"4\n" +
+	"\x0eInspectRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04next\x18\x02 \x01(\x05R\x04next\"\r\n" +
+	"\vListRequest\":\n" +
+	"\tListReply\x12-\n" +
+	"\x04jobs\x18\x01 \x03(\v2\x19.gocrawler.v1.JobProgressR\x04jobs\"\x9d\x01\n" +
+	"\tJobResult\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1a\n" +
+	"\bredirect\x18\x03 \x01(\tR\bredirect\x12\x1c\n" +
+	"\tcanonical\x18\x04 \x01(\tR\tcanonical\x12\x18\n" +
+	"\anoindex\x18\x05 \x01(\bR\anoindex\x12\x14\n" +
+	"\x05links\x18\x06 \x03(\tR\x05links\"\xf0\x01\n" +
+	"\vJobProgress\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12,\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x16.gocrawler.v1.JobStateR\x05state\x12\x14\n" +
+	"\x05pages\x18\x03 \x01(\x03R\x05pages\x12\x16\n" +
+	"\x06errors\x18\x04 \x01(\x03R\x06errors\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x10\n" +
+	"\x03url\x18\x06 \x01(\tR\x03url\x12\x18\n" +
+	"\aworkers\x18\a \x01(\x05R\aworkers\x12\x1b\n" +
+	"\tmax_pages\x18\b \x01(\x05R\bmaxPages\x12\x16\n" +
+	"\x06paused\x18\t \x01(\bR\x06paused\"\xa4\x03\n" +
+	"\bJobQueue\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\avisited\x18\x02 \x01(\x03R\avisited\x12\x18\n" +
+	"\aresults\x18\x03 \x01(\x03R\aresults\x12\x1d\n" +
+	"\n" +
+	"sites_left\x18\x04 \x01(\x03R\tsitesLeft\x12\x16\n" +
+	"\x06queued\x18\x05 \x01(\x03R\x06queued\x12\x12\n" +
+	"\x04next\x18\x06 \x03(\tR\x04next\x12@\n" +
+	"\binflight\x18\a \x03(\v2$.gocrawler.v1.JobQueue.InflightEntryR\binflight\x12J\n" +
+	"\fpaused_hosts\x18\b \x03(\v2'.gocrawler.v1.JobQueue.PausedHostsEntryR\vpausedHosts\x1a;\n" +
+	"\rInflightEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a>\n" +
+	"\x10PausedHostsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01*\x7f\n" +
+	"\bJobState\x12\x19\n" +
+	"\x15JOB_STATE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11JOB_STATE_RUNNING\x10\x01\x12\x12\n" +
+	"\x0eJOB_STATE_DONE\x10\x02\x12\x14\n" +
+	"\x10JOB_STATE_FAILED\x10\x03\x12\x17\n" +
+	"\x13JOB_STATE_CANCELLED\x10\x042\x92\x04\n" +
+	"\x06Daemon\x12@\n" +
+	"\x06Submit\x12\x1b.gocrawler.v1.SubmitRequest\x1a\x19.gocrawler.v1.SubmitReply\x12:\n" +
+	"\x04List\x12\x19.gocrawler.v1.ListRequest\x1a\x17.gocrawler.v1.ListReply\x12=\n" +
+	"\x06Cancel\x12\x18.gocrawler.v1.JobRequest\x1a\x19.gocrawler.v1.JobProgress\x12D\n" +
+	"\rStreamResults\x12\x18.gocrawler.v1.JobRequest\x1a\x17.gocrawler.v1.JobResult0\x01\x12G\n" +
+	"\x0eStreamProgress\x12\x18.gocrawler.v1.JobRequest\x1a\x19.gocrawler.v1.JobProgress0\x01\x12?\n" +
+	"\aInspect\x12\x1c.gocrawler.v1.InspectRequest\x1a\x16.gocrawler.v1.JobQueue\x12<\n" +
+	"\x05Pause\x12\x18.gocrawler.v1.JobRequest\x1a\x19.gocrawler.v1.JobProgress\x12=\n" +
+	"\x06Resume\x12\x18.gocrawler.v1.JobRequest\x1a\x19.gocrawler.v1.JobProgressB'Z%github.com/tobiasbrodd/GoCrawler;mainb\x06proto3"
+
+var (
+	file_daemon_proto_rawDescOnce sync.Once
+	file_daemon_proto_rawDescData []byte
+)
+
+func file_daemon_proto_rawDescGZIP() []byte {
+	file_daemon_proto_rawDescOnce.Do(func() {
+		file_daemon_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_daemon_proto_rawDesc), len(file_daemon_proto_rawDesc)))
+	})
+	return file_daemon_proto_rawDescData
+}
+
+var file_daemon_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_daemon_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_daemon_proto_goTypes = []any{
+	(JobState)(0),          // 0: gocrawler.v1.JobState
+	(*SubmitRequest)(nil),  // 1: gocrawler.v1.SubmitRequest
+	(*SubmitReply)(nil),    // 2: gocrawler.v1.SubmitReply
+	(*JobRequest)(nil),     // 3: gocrawler.v1.JobRequest
+	(*InspectRequest)(nil), // 4: gocrawler.v1.InspectRequest
+	(*ListRequest)(nil),    // 5: gocrawler.v1.ListRequest
+	(*ListReply)(nil),      // 6: gocrawler.v1.ListReply
+	(*JobResult)(nil),      // 7: gocrawler.v1.JobResult
+	(*JobProgress)(nil),    // 8: gocrawler.v1.JobProgress
+	(*JobQueue)(nil),       // 9: gocrawler.v1.JobQueue
+	nil,                    // 10: gocrawler.v1.JobQueue.InflightEntry
+	nil,                    // 11: gocrawler.v1.JobQueue.PausedHostsEntry
+}
+var file_daemon_proto_depIdxs = []int32{
+	8,  // 0: gocrawler.v1.ListReply.jobs:type_name -> gocrawler.v1.JobProgress
+	0,  // 1: gocrawler.v1.JobProgress.state:type_name -> gocrawler.v1.JobState
+	10, // 2: gocrawler.v1.JobQueue.inflight:type_name -> gocrawler.v1.JobQueue.InflightEntry
+	11, // 3: gocrawler.v1.JobQueue.paused_hosts:type_name -> gocrawler.v1.JobQueue.PausedHostsEntry
+	1,  // 4: gocrawler.v1.Daemon.Submit:input_type -> gocrawler.v1.SubmitRequest
+	5,  // 5: gocrawler.v1.Daemon.List:input_type -> gocrawler.v1.ListRequest
+	3,  // 6: gocrawler.v1.Daemon.Cancel:input_type -> gocrawler.v1.JobRequest
+	3,  // 7: gocrawler.v1.Daemon.StreamResults:input_type -> gocrawler.v1.JobRequest
+	3,  // 8: gocrawler.v1.Daemon.StreamProgress:input_type -> gocrawler.v1.JobRequest
+	4,  // 9: gocrawler.v1.Daemon.Inspect:input_type -> gocrawler.v1.InspectRequest
+	3,  // 10: gocrawler.v1.Daemon.Pause:input_type -> gocrawler.v1.JobRequest
+	3,  // 11: gocrawler.v1.Daemon.Resume:input_type -> gocrawler.v1.JobRequest
+	2,  // 12: gocrawler.v1.Daemon.Submit:output_type -> gocrawler.v1.SubmitReply
+	6,  // 13: gocrawler.v1.Daemon.List:output_type -> gocrawler.v1.ListReply
+	8,  // 14: gocrawler.v1.Daemon.Cancel:output_type -> gocrawler.v1.JobProgress
+	7,  // 15: gocrawler.v1.Daemon.StreamResults:output_type -> gocrawler.v1.JobResult
+	8,  // 16: gocrawler.v1.Daemon.StreamProgress:output_type -> gocrawler.v1.JobProgress
+	9,  // 17: gocrawler.v1.Daemon.Inspect:output_type -> gocrawler.v1.JobQueue
+	8,  // 18: gocrawler.v1.Daemon.Pause:output_type -> gocrawler.v1.JobProgress
+	8,  // 19: gocrawler.v1.Daemon.Resume:output_type -> gocrawler.v1.JobProgress
+	12, // [12:20] is the sub-list for method output_type
+	4,  // [4:12] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_daemon_proto_init() }
+func file_daemon_proto_init() {
+	if File_daemon_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_daemon_proto_rawDesc), len(file_daemon_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_daemon_proto_goTypes,
+		DependencyIndexes: file_daemon_proto_depIdxs,
+		EnumInfos:         file_daemon_proto_enumTypes,
+		MessageInfos:      file_daemon_proto_msgTypes,
+	}.Build()
+	File_daemon_proto = out.File
+	file_daemon_proto_goTypes = nil
+	file_daemon_proto_depIdxs = nil
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// TestHelperCrawl stands in for a crawl process of the daemon
+func TestHelperCrawl(t *testing.T) {
+	if os.Getenv("GOCRAWLER_HELPER_CRAWL") != "1" {
+		return
+	}
+
+	url, debugAddr := "", ""
+	for i, arg := range os.Args {
+		switch arg {
+		case "-url":
+			url = os.Args[i+1]
+		case "-debug-addr":
+			debugAddr = os.Args[i+1]
+		}
+	}
+	if strings.Contains(url, "fail") {
+		fmt.Fprintln(os.Stderr, "lookup fail.test: no such host")
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.Encode(jsonResult{URL: url, Links: []string{url + "a"}})
+	if strings.Contains(url, "slow") {
+		go ServeDebug(debugAddr, NewPauseGate(false))
+		time.Sleep(time.Minute)
+	}
+	encoder.Encode(jsonResult{URL: url + "a", Error: string(errorHTTP4xx)})
+	fmt.Println("Errors: 1")
+	os.Exit(0)
+}
+
+func startDaemon(t *testing.T, quotas daemonQuotas) DaemonClient {
+	t.Setenv("GOCRAWLER_HELPER_CRAWL", "1")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	RegisterDaemonServer(server, NewDaemon([]string{os.Args[0], "-test.run=^TestHelperCrawl$", "--"}, quotas))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return NewDaemonClient(conn)
+}
+
+func streamResults(t *testing.T, client DaemonClient, id string) []string {
+	stream, err := client.StreamResults(context.Background(), &JobRequest{Id: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, res.Url+" "+res.Error)
+	}
+}
+
+func lastProgress(t *testing.T, client DaemonClient, id string) *JobProgress {
+	stream, err := client.StreamProgress(context.Background(), &JobRequest{Id: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last *JobProgress
+	for {
+		p, err := stream.Recv()
+		if err == io.EOF {
+			return last
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = p
+	}
+}
+
+func TestDaemonJob(t *testing.T) {
+	client := startDaemon(t, daemonQuotas{workers: 4})
+	ctx := context.Background()
+
+	reply, err := client.Submit(ctx, &SubmitRequest{Url: "https://example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"https://example.com/ ", "https://example.com/a http-4xx"}
+	if got := streamResults(t, client, reply.Id); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("results = %q, want %q", got, want)
+	}
+
+	p := lastProgress(t, client, reply.Id)
+	if p.State != JobState_JOB_STATE_DONE || p.Pages != 2 || p.Errors != 1 {
+		t.Errorf("progress = %v, want done with 2 pages and 1 error", p)
+	}
+
+	// Streams of finished jobs replay every result
+	if got := streamResults(t, client, reply.Id); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("results after done = %q, want %q", got, want)
+	}
+}
+
+func TestDaemonFailedJob(t *testing.T) {
+	client := startDaemon(t, daemonQuotas{workers: 4})
+
+	reply, err := client.Submit(context.Background(), &SubmitRequest{Url: "https://fail.test/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := lastProgress(t, client, reply.Id)
+	if p.State != JobState_JOB_STATE_FAILED || p.Error != "lookup fail.test: no such host" {
+		t.Errorf("progress = %v, want failed on lookup", p)
+	}
+}
+
+func TestDaemonInvalidRequests(t *testing.T) {
+	client := startDaemon(t, daemonQuotas{workers: 4})
+	ctx := context.Background()
+
+	tests := []*SubmitRequest{
+		{Url: "example.com"},
+		{Url: "https://example.com/", Depth: -1},
+		{Url: "https://example.com/", DepthMode: "levels"},
+		{Url: "https://example.com/", Workers: -1},
+		{Url: "https://example.com/", MaxPages: -1},
+	}
+
+	for _, test := range tests {
+		if _, err := client.Submit(ctx, test); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Submit(%v) = %v, want InvalidArgument", test, err)
+		}
+	}
+
+	stream, err := client.StreamProgress(ctx, &JobRequest{Id: "job-9"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("StreamProgress(job-9) = %v, want NotFound", err)
+	}
+}
+
+func TestDaemonQuotas(t *testing.T) {
+	client := startDaemon(t, daemonQuotas{workers: 2, maxWorkers: 3, maxPages: 10, maxJobs: 1})
+	ctx := context.Background()
+
+	reply, err := client.Submit(ctx, &SubmitRequest{Url: "https://slow.test/", Workers: 8, MaxPages: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Submit(ctx, &SubmitRequest{Url: "https://example.com/"}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Submit over max jobs = %v, want ResourceExhausted", err)
+	}
+
+	list, err := client.List(ctx, &ListRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Jobs) != 1 || list.Jobs[0].Workers != 3 || list.Jobs[0].MaxPages != 10 {
+		t.Errorf("List = %v, want one job with 3 workers and 10 pages", list.Jobs)
+	}
+
+	stream, err := client.StreamResults(ctx, &JobRequest{Id: reply.Id})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Cancel(ctx, &JobRequest{Id: reply.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if p := lastProgress(t, client, reply.Id); p.State != JobState_JOB_STATE_CANCELLED || p.Pages != 1 {
+		t.Errorf("progress = %v, want cancelled with 1 page", p)
+	}
+
+	// Cancelled jobs no longer count against max jobs
+	if _, err := client.Submit(ctx, &SubmitRequest{Url: "https://example.com/"}); err != nil {
+		t.Errorf("Submit after cancel = %v", err)
+	}
+}
+
+func TestDaemonNoWorkers(t *testing.T) {
+	client := startDaemon(t, daemonQuotas{})
+
+	if _, err := client.Submit(context.Background(), &SubmitRequest{Url: "https://example.com/"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Submit without workers = %v, want InvalidArgument", err)
+	}
+}
+
+func TestDaemonEvict(t *testing.T) {
+	t.Setenv("GOCRAWLER_HELPER_CRAWL", "1")
+
+	d := NewDaemon([]string{os.Args[0], "-test.run=^TestHelperCrawl$", "--"}, daemonQuotas{workers: 1})
+	ctx := context.Background()
+	done, err := d.Submit(ctx, &SubmitRequest{Url: "https://example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow, err := d.Submit(ctx, &SubmitRequest{Url: "https://slow.test/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cancel(ctx, &JobRequest{Id: slow.Id})
+
+	j, _ := d.job(done.Id)
+	for j.Running() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	d.Evict(time.Now().Add(-time.Hour))
+	if _, err := d.job(done.Id); err != nil {
+		t.Errorf("job evicted before retention: %v", err)
+	}
+
+	d.Evict(time.Now())
+	if _, err := d.job(done.Id); status.Code(err) != codes.NotFound {
+		t.Errorf("job(%v) = %v, want NotFound", done.Id, err)
+	}
+	if _, err := d.job(slow.Id); err != nil {
+		t.Errorf("running job evicted: %v", err)
+	}
+}
+
+func TestDaemonDashboard(t *testing.T) {
+	t.Setenv("GOCRAWLER_HELPER_CRAWL", "1")
+
+	d := NewDaemon([]string{os.Args[0], "-test.run=^TestHelperCrawl$", "--"}, daemonQuotas{workers: 1})
+	reply, err := d.Submit(context.Background(), &SubmitRequest{Url: "https://example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, _ := d.job(reply.Id)
+	for j.Running() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	server := httptest.NewServer(d.Dashboard())
+	defer server.Close()
+
+	var jobs []dashboardJob
+	getJSON(t, server.URL+"/api/jobs", &jobs)
+	if len(jobs) != 1 || jobs[0].ID != reply.Id || jobs[0].State != "done" || jobs[0].Pages != 2 {
+		t.Errorf("jobs = %+v, want %v done with 2 pages", jobs, reply.Id)
+	}
+
+	var stats dashboardStats
+	getJSON(t, server.URL+"/jobs/"+reply.Id+"/api/stats", &stats)
+	if stats.Seed != "https://example.com/" || stats.Running || stats.Pages != 2 || stats.Errors != 1 || len(stats.Throughput) != dashboardWindow {
+		t.Errorf("stats = %+v, want finished with 2 pages and 1 error", stats)
+	}
+
+	res, err := http.Get(server.URL + "/jobs/job-9/api/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("stats of job-9 = %v, want %v", res.StatusCode, http.StatusNotFound)
+	}
+}
+
+func getJSON(t *testing.T, url string, v interface{}) {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		t.Fatalf("%v: %v", url, err)
+	}
+}
+
+func TestDaemonInspect(t *testing.T) {
+	client := startDaemon(t, daemonQuotas{workers: 1})
+	ctx := context.Background()
+
+	reply, err := client.Submit(ctx, &SubmitRequest{Url: "https://slow.test/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Cancel(ctx, &JobRequest{Id: reply.Id})
+
+	// The debug server of the job starts along with its crawl
+	var queue *JobQueue
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		queue, err = client.Inspect(ctx, &InspectRequest{Id: reply.Id})
+		if status.Code(err) != codes.Unavailable || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queue.Id != reply.Id || len(queue.Next) != 0 {
+		t.Errorf("queue = %v, want the queue of %v", queue, reply.Id)
+	}
+
+	done, err := client.Submit(ctx, &SubmitRequest{Url: "https://example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastProgress(t, client, done.Id)
+	if _, err := client.Inspect(ctx, &InspectRequest{Id: done.Id}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Inspect of a finished job = %v, want FailedPrecondition", err)
+	}
+}
+
+func TestDaemonPause(t *testing.T) {
+	client := startDaemon(t, daemonQuotas{workers: 1})
+	ctx := context.Background()
+
+	reply, err := client.Submit(ctx, &SubmitRequest{Url: "https://slow.test/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Cancel(ctx, &JobRequest{Id: reply.Id})
+
+	var p *JobProgress
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		p, err = client.Pause(ctx, &JobRequest{Id: reply.Id})
+		if status.Code(err) != codes.Unavailable || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || !p.Paused {
+		t.Fatalf("Pause = %v, %v, want paused", p, err)
+	}
+
+	if p, err = client.Resume(ctx, &JobRequest{Id: reply.Id}); err != nil || p.Paused {
+		t.Errorf("Resume = %v, %v, want not paused", p, err)
+	}
+
+	if _, err := client.Pause(ctx, &JobRequest{Id: "job-9"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Pause(job-9) = %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------- Dashboard ----------
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+//go:embed dashboard_jobs.html
+var dashboardJobsPage []byte
+
+// dashboardWindow is the number of seconds of throughput kept
+const dashboardWindow = 60
+
+type dashboardResult struct {
+	URL   string `json:"url"`
+	Error string `json:"error,omitempty"`
+	Time  string `json:"time"`
+}
+
+type dashboardStats struct {
+	Seed       string `json:"seed"`
+	Running    bool   `json:"running"`
+	Started    string `json:"started"`
+	Elapsed    string `json:"elapsed"`
+	Pages      int    `json:"pages"`
+	Errors     int    `json:"errors"`
+	Throughput []int  `json:"throughput"`
+}
+
+type dashboard struct {
+	mutex    sync.Mutex
+	seed     string
+	started  time.Time
+	finished time.Time
+	results  []dashboardResult
+	errors   []dashboardResult
+	// perSec counts the results of the second in seconds at the same index,
+	// both indexed by unix time modulo dashboardWindow
+	perSec  [dashboardWindow]int
+	seconds [dashboardWindow]int64
+}
+
+// NewDashboard creates a live dashboard for a crawl
+func NewDashboard(seed string) *dashboard {
+	return &dashboard{seed: seed, started: time.Now()}
+}
+
+// Add records a result
+func (d *dashboard) Add(res result) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := time.Now()
+	r := dashboardResult{res.url, string(res.errClass), now.Format(time.RFC3339)}
+	d.results = append(d.results, r)
+	if res.errClass != errorNone {
+		d.errors = append(d.errors, r)
+	}
+	second := now.Unix()
+	i := second % dashboardWindow
+	if d.seconds[i] != second {
+		d.seconds[i] = second
+		d.perSec[i] = 0
+	}
+	d.perSec[i]++
+}
+
+// Finish marks the crawl as done
+func (d *dashboard) Finish() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.finished = time.Now()
+}
+
+// Serve serves the dashboard on an address
+func (d *dashboard) Serve(addr string) error {
+	return http.ListenAndServe(addr, d.Handler())
+}
+
+// Handler serves the page and API of the dashboard. The page uses relative
+// API paths, so the handler may be mounted below a prefix.
+func (d *dashboard) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.handlePage)
+	mux.HandleFunc("/api/stats", d.handleStats)
+	mux.HandleFunc("/api/results", d.handleResults)
+	mux.HandleFunc("/api/errors", d.handleErrors)
+
+	return mux
+}
+
+func (d *dashboard) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardPage)
+}
+
+func (d *dashboard) handleStats(w http.ResponseWriter, r *http.Request) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	end := time.Now()
+	if !d.finished.IsZero() {
+		end = d.finished
+	}
+
+	throughput := make([]int, dashboardWindow)
+	for i := range throughput {
+		second := end.Unix() - int64(dashboardWindow-1-i)
+		if j := second % dashboardWindow; d.seconds[j] == second {
+			throughput[i] = d.perSec[j]
+		}
+	}
+
+	writeJSON(w, dashboardStats{
+		Seed:       d.seed,
+		Running:    d.finished.IsZero(),
+		Started:    d.started.Format(time.RFC3339),
+		Elapsed:    end.Sub(d.started).Round(time.Second).String(),
+		Pages:      len(d.results),
+		Errors:     len(d.errors),
+		Throughput: throughput,
+	})
+}
+
+func (d *dashboard) handleResults(w http.ResponseWriter, r *http.Request) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	writeJSON(w, filterResults(d.results, r))
+}
+
+func (d *dashboard) handleErrors(w http.ResponseWriter, r *http.Request) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	writeJSON(w, filterResults(d.errors, r))
+}
+
+func filterResults(results []dashboardResult, r *http.Request) []dashboardResult {
+	query := strings.ToLower(r.URL.Query().Get("q"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+
+	filtered := []dashboardResult{}
+	for i := len(results) - 1; i >= 0 && len(filtered) < limit; i-- {
+		res := results[i]
+		if strings.Contains(strings.ToLower(res.URL), query) || strings.Contains(res.Error, query) {
+			filtered = append(filtered, res)
+		}
+	}
+
+	return filtered
+}
+
+// ---------- Daemon dashboard ----------
+
+type dashboardJob struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+	Pages  int64  `json:"pages"`
+	Errors int64  `json:"errors"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Dashboard serves a list of the jobs of the daemon at / and the dashboard
+// of each job at /jobs/<id>/
+func (d *daemon) Dashboard() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.handleJobsPage)
+	mux.HandleFunc("/api/jobs", d.handleJobs)
+	mux.HandleFunc("/jobs/", d.handleJob)
+
+	return mux
+}
+
+func (d *daemon) handleJobsPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardJobsPage)
+}
+
+func (d *daemon) handleJobs(w http.ResponseWriter, r *http.Request) {
+	reply, _ := d.List(r.Context(), &ListRequest{})
+
+	jobs := []dashboardJob{}
+	for _, p := range reply.Jobs {
+		state := strings.ToLower(strings.TrimPrefix(p.State.String(), "JOB_STATE_"))
+		if p.Paused && p.State == JobState_JOB_STATE_RUNNING {
+			state = "paused"
+		}
+		jobs = append(jobs, dashboardJob{
+			ID:     p.Id,
+			URL:    p.Url,
+			State:  state,
+			Pages:  p.Pages,
+			Errors: p.Errors,
+			Error:  p.Error,
+		})
+	}
+
+	writeJSON(w, jobs)
+}
+
+func (d *daemon) handleJob(w http.ResponseWriter, r *http.Request) {
+	id, _, found := strings.Cut(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/")
+	j, err := d.job(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !found {
+		http.Redirect(w, r, "/jobs/"+id+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	http.StripPrefix("/jobs/"+id, j.board.Handler()).ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
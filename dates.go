@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// ---------- Dates ----------
+
+type dateFacts struct {
+	published time.Time
+	modified  time.Time
+	inJSONLD  bool
+	script    []string
+}
+
+// publishedMeta and modifiedMeta are the lower case meta names and
+// properties holding publish and modification dates
+var publishedMeta = map[string]bool{
+	"article:published_time": true, "og:published_time": true, "datepublished": true, "date": true,
+	"pubdate": true, "publish_date": true, "publishdate": true, "dc.date": true, "dc.date.issued": true,
+	"dcterms.created": true, "dcterms.issued": true, "citation_publication_date": true,
+}
+var modifiedMeta = map[string]bool{
+	"article:modified_time": true, "og:updated_time": true, "datemodified": true, "last-modified": true,
+	"dc.date.modified": true, "dcterms.modified": true, "revised": true,
+}
+
+// dateLayouts are the date formats understood in pages and headers
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"20060102",
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC850,
+	time.ANSIC,
+}
+
+// Token records dates from meta tags and JSON-LD scripts
+func (f *dateFacts) Token(tokenType html.TokenType, token html.Token) {
+	if "script" == token.Data {
+		if tokenType == html.StartTagToken && strings.EqualFold(strings.TrimSpace(GetAttr(token, "type")), "application/ld+json") {
+			f.inJSONLD = true
+			f.script = nil
+		}
+		if tokenType == html.EndTagToken && f.inJSONLD {
+			f.inJSONLD = false
+			var data interface{}
+			if err := json.Unmarshal([]byte(strings.Join(f.script, "")), &data); err == nil {
+				f.jsonLD(data)
+			}
+		}
+		return
+	}
+
+	if "meta" != token.Data || tokenType == html.EndTagToken {
+		return
+	}
+
+	date, ok := ParseDate(GetAttr(token, "content"))
+	if !ok {
+		return
+	}
+	for _, key := range []string{"name", "property", "itemprop", "http-equiv"} {
+		name := strings.ToLower(strings.TrimSpace(GetAttr(token, key)))
+		if publishedMeta[name] && f.published.IsZero() {
+			f.published = date
+		}
+		if modifiedMeta[name] && f.modified.IsZero() {
+			f.modified = date
+		}
+	}
+}
+
+// Text records the contents of JSON-LD scripts
+func (f *dateFacts) Text(raw string) {
+	if f.inJSONLD {
+		f.script = append(f.script, raw)
+	}
+}
+
+// jsonLD records the first datePublished and dateModified in JSON-LD data,
+// including nested objects and @graph lists
+func (f *dateFacts) jsonLD(data interface{}) {
+	switch value := data.(type) {
+	case []interface{}:
+		for _, item := range value {
+			f.jsonLD(item)
+		}
+	case map[string]interface{}:
+		if s, ok := value["datePublished"].(string); ok && f.published.IsZero() {
+			f.published, _ = ParseDate(s)
+		}
+		if s, ok := value["dateModified"].(string); ok && f.modified.IsZero() {
+			f.modified, _ = ParseDate(s)
+		}
+		for _, item := range value {
+			f.jsonLD(item)
+		}
+	}
+}
+
+// ParseDate parses a date in any of the formats used by pages and headers
+func ParseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return time.Time{}, false
+	}
+
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// LastModified returns the Last-Modified header date of a response
+func LastModified(header http.Header) time.Time {
+	date, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return date
+}
+
+// IsNewerThan reports whether a result was modified or published on or
+// after a date. Results without any date are kept.
+func IsNewerThan(res result, since time.Time) bool {
+	date := res.modified
+	if date.IsZero() {
+		date = res.published
+	}
+
+	return date.IsZero() || !date.Before(since)
+}
+
+// FormatDate formats a date as RFC 3339, or as empty when it is unknown
+func FormatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.Format(time.RFC3339)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFetchDates(t *testing.T) {
+	lastModified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	published := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	modified := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		body      string
+		published time.Time
+		modified  time.Time
+	}{
+		{`<p>No dates</p>`, time.Time{}, lastModified},
+		// Pages with only a publish date keep Last-Modified as modified
+		{`<meta property="article:published_time" content="2019-06-01">`, published, lastModified},
+		{`<meta property="article:modified_time" content="2019-07-01">`, time.Time{}, modified},
+	}
+
+	for _, test := range tests {
+		c, err := NewCache(t.TempDir(), 0, cacheReadWrite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := http.Header{"Content-Type": {"text/html"}, "Last-Modified": {lastModified.Format(http.TimeFormat)}}
+		if err := c.Put("http://example.com/", entry{url: "http://example.com/", header: header, body: []byte(test.body)}); err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := fetcher{cache: c}.Fetch(NewFetchRequest("http://example.com/"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.published.Equal(test.published) || !resp.modified.Equal(test.modified) {
+			t.Errorf("dates of %q = %v, %v, want %v, %v", test.body, resp.published, resp.modified, test.published, test.modified)
+		}
+	}
+}
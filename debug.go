@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	_ "net/http/pprof"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ---------- Debug ----------
+
+var visitedCount = expvar.NewInt("visited")
+var resultsCount = expvar.NewInt("results")
+
+var queuedSites int64
+
+// activeShards are the worker queues of a crawl with workers
+var activeShards struct {
+	sync.Mutex
+	shards []*shard
+}
+
+// inflight counts the requests in flight per host
+var inflight = hostCounter{counts: map[string]int{}}
+
+type hostCounter struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+// Add adds delta to the count of a host
+func (c *hostCounter) Add(host string, delta int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.counts[host] += delta
+	if c.counts[host] <= 0 {
+		delete(c.counts, host)
+	}
+}
+
+// Snapshot returns a copy of the counts
+func (c *hostCounter) Snapshot() map[string]int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	counts := map[string]int{}
+	for host, count := range c.counts {
+		counts[host] = count
+	}
+
+	return counts
+}
+
+type queueState struct {
+	Visited   int64                `json:"visited"`
+	Results   int64                `json:"results"`
+	SitesLeft int64                `json:"sites_left"`
+	Queued    int64                `json:"queued"`
+	Next      []string             `json:"next"`
+	Inflight  map[string]int       `json:"inflight"`
+	Paused    map[string]time.Time `json:"paused"`
+}
+
+// ServeDebug serves pprof profiles under /debug/pprof/, expvar counters
+// under /debug/vars, the crawl queue under /debug/queue?n=20 and pauses and
+// resumes the crawl on POST /debug/pause and /debug/resume
+func ServeDebug(addr string, pause *pauseGate) error {
+	expvar.Publish("goroutines", expvar.Func(func() interface{} {
+		return runtime.NumGoroutine()
+	}))
+	expvar.Publish("sites_left", expvar.Func(func() interface{} {
+		return atomic.LoadInt64(&sitesLeft)
+	}))
+	expvar.Publish("queued", expvar.Func(func() interface{} {
+		return atomic.LoadInt64(&queuedSites)
+	}))
+	expvar.Publish("inflight", expvar.Func(func() interface{} {
+		return inflight.Snapshot()
+	}))
+	http.HandleFunc("/debug/queue", serveQueue)
+	http.Handle("/debug/pause", pause)
+	http.Handle("/debug/resume", pause)
+
+	return http.ListenAndServe(addr, http.DefaultServeMux)
+}
+
+// serveQueue reports the next queued sites, in shard order, and the requests
+// in flight per host. Without workers sites are not queued but crawled as
+// soon as they are visited, so next is empty.
+func serveQueue(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.URL.Query().Get("n"))
+	if err != nil || n <= 0 {
+		n = 20
+	}
+
+	state := queueState{
+		Visited:   visitedCount.Value(),
+		Results:   resultsCount.Value(),
+		SitesLeft: atomic.LoadInt64(&sitesLeft),
+		Queued:    atomic.LoadInt64(&queuedSites),
+		Next:      []string{},
+		Inflight:  inflight.Snapshot(),
+		Paused:    maintenance.Snapshot(),
+	}
+
+	activeShards.Lock()
+	for _, sh := range activeShards.shards {
+		for _, s := range sh.Peek(n - len(state.Next)) {
+			state.Next = append(state.Next, s.url)
+		}
+	}
+	activeShards.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ---------- Depth ----------
+
+type depthMode int
+
+const (
+	depthHops depthMode = iota
+	depthPath
+)
+
+type depthBoost struct {
+	pattern string
+	extra   int
+}
+
+type depthLimit struct {
+	max    int
+	mode   depthMode
+	seed   *url.URL
+	boosts []depthBoost
+	rules  crawlRules
+	hosts  *canonicalHosts
+	scope  map[string]bool
+	// external is the number of hops allowed outside the scope hosts, or
+	// < 0 for no limit
+	external int
+}
+
+// ParseDepthMode parses a depth mode flag value
+func ParseDepthMode(mode string) (depthMode, error) {
+	switch mode {
+	case "hops":
+		return depthHops, nil
+	case "path":
+		return depthPath, nil
+	}
+
+	return depthHops, fmt.Errorf("unknown depth mode: %v", mode)
+}
+
+// NewDepthLimit creates a depth limit relative to a seed URL
+func NewDepthLimit(seed string, max int, mode depthMode) (depthLimit, error) {
+	u, err := url.Parse(seed)
+	if err != nil {
+		return depthLimit{}, err
+	}
+
+	return depthLimit{max: max, mode: mode, seed: u, external: -1}, nil
+}
+
+// SetScope makes the hosts of the seeds the hosts crawled to full depth
+func (d *depthLimit) SetScope(seeds []string) {
+	d.scope = map[string]bool{}
+	for _, seed := range seeds {
+		d.scope[hostOf(seed)] = true
+	}
+}
+
+// ExternalHops returns how many hops outside the scope hosts a link from a
+// site is, and whether it is within the external depth. Links back to a
+// scope host are no hops outside.
+func (d depthLimit) ExternalHops(from site, link string) (int, bool) {
+	if d.external < 0 {
+		return 0, true
+	}
+
+	host := hostOf(d.hosts.Rewrite(link))
+	for scoped := range d.scope {
+		if host == hostOf(d.hosts.Rewrite("http://"+scoped+"/")) {
+			return 0, true
+		}
+	}
+
+	hops := from.external + 1
+	return hops, hops <= d.external
+}
+
+// ParseDepthBoosts parses comma separated pattern=+n rules granting links
+// matching a pattern n extra levels of depth
+func ParseDepthBoosts(list string) ([]depthBoost, error) {
+	var boosts []depthBoost
+	for _, rule := range strings.Split(list, ",") {
+		if len(strings.TrimSpace(rule)) == 0 {
+			continue
+		}
+
+		i := strings.LastIndex(rule, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid depth boost: %v", rule)
+		}
+
+		extra, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(rule[i+1:]), "+"))
+		if err != nil || extra < 0 {
+			return nil, fmt.Errorf("invalid depth boost: %v", rule)
+		}
+		boosts = append(boosts, depthBoost{strings.TrimSpace(rule[:i]), extra})
+	}
+
+	return boosts, nil
+}
+
+// Max returns the depth limit of a link, including the largest boost of the
+// rules it matches. A crawl rule depth replaces the -depth limit.
+func (d depthLimit) Max(link string) int {
+	u, err := url.Parse(link)
+	if err != nil {
+		return d.max
+	}
+
+	max := d.max
+	if depth := d.rules.For(link).depth; depth != nil {
+		max = *depth
+	}
+
+	extra := 0
+	for _, boost := range d.boosts {
+		if boost.extra > extra && MatchPattern(boost.pattern, u) {
+			extra = boost.extra
+		}
+	}
+
+	return max + extra
+}
+
+// Expand reports whether links should be followed from a site
+func (d depthLimit) Expand(s site) bool {
+	if d.mode == depthPath {
+		return true
+	}
+
+	return s.depth < d.Max(s.url)
+}
+
+// Allow reports whether a discovered link is within the depth limit
+func (d depthLimit) Allow(link string) bool {
+	if d.mode == depthHops {
+		return true
+	}
+
+	// Compare on canonical hosts, as the seed may be on a host alias
+	seed := d.seed
+	if d.hosts != nil {
+		if u, err := url.Parse(d.hosts.Rewrite(seed.String())); err == nil {
+			seed = u
+		}
+		link = d.hosts.Rewrite(link)
+	}
+
+	depth, ok := PathDepth(seed, link)
+	return ok && depth <= d.Max(link)
+}
+
+// PathDepth returns the directory depth of a link below the seed, where
+// pages in the seed's own directory have depth 1
+func PathDepth(seed *url.URL, link string) (int, bool) {
+	u, err := url.Parse(link)
+	if err != nil || u.Host != seed.Host {
+		return 0, false
+	}
+
+	dir := seed.Path[:strings.LastIndex(seed.Path, "/")+1]
+	if len(dir) == 0 {
+		dir = "/"
+	}
+
+	path := u.Path
+	if len(path) == 0 {
+		path = "/"
+	}
+
+	if !strings.HasPrefix(path, dir) {
+		return 0, false
+	}
+
+	return strings.Count(path, "/") - strings.Count(dir, "/") + 1, true
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseDepthMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		want depthMode
+		ok   bool
+	}{
+		{"hops", depthHops, true},
+		{"path", depthPath, true},
+		{"dirs", depthHops, false},
+	}
+
+	for _, test := range tests {
+		mode, err := ParseDepthMode(test.mode)
+		if mode != test.want || (err == nil) != test.ok {
+			t.Errorf("ParseDepthMode(%q) = %v, %v, want %v, ok %v", test.mode, mode, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestPathDepth(t *testing.T) {
+	tests := []struct {
+		seed  string
+		link  string
+		depth int
+		ok    bool
+	}{
+		{"http://example.com/", "http://example.com/", 1, true},
+		{"http://example.com", "http://example.com", 1, true},
+		{"http://example.com/", "http://example.com/page.html", 1, true},
+		{"http://example.com/", "http://example.com/a/", 2, true},
+		{"http://example.com/", "http://example.com/a/b/page.html", 3, true},
+		{"http://example.com/docs/", "http://example.com/docs/guide/", 2, true},
+		{"http://example.com/docs/index.html", "http://example.com/docs/intro.html", 1, true},
+		{"http://example.com/docs/", "http://example.com/blog/", 0, false},
+		{"http://example.com/docs/", "http://example.com/", 0, false},
+		{"http://example.com/", "http://other.com/", 0, false},
+		{"http://example.com/", "://bad", 0, false},
+	}
+
+	for _, test := range tests {
+		seed, err := url.Parse(test.seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		depth, ok := PathDepth(seed, test.link)
+		if depth != test.depth || ok != test.ok {
+			t.Errorf("PathDepth(%q, %q) = %v, %v, want %v, %v", test.seed, test.link, depth, ok, test.depth, test.ok)
+		}
+	}
+}
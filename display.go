@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	neturl "net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// ---------- Display ----------
+
+type hostDisplay int
+
+const (
+	hostsKeep hostDisplay = iota
+	hostsUnicode
+	hostsPunycode
+)
+
+type pathDisplay int
+
+const (
+	pathsKeep pathDisplay = iota
+	pathsEscape
+	pathsPreserve
+)
+
+type urlDisplay struct {
+	hosts hostDisplay
+	paths pathDisplay
+}
+
+// ParseURLDisplay parses the host and path display flag values
+func ParseURLDisplay(hosts string, paths string) (urlDisplay, error) {
+	var d urlDisplay
+	switch hosts {
+	case "keep":
+		d.hosts = hostsKeep
+	case "unicode":
+		d.hosts = hostsUnicode
+	case "punycode":
+		d.hosts = hostsPunycode
+	default:
+		return d, fmt.Errorf("unknown host display: %v", hosts)
+	}
+
+	switch paths {
+	case "keep":
+		d.paths = pathsKeep
+	case "escape":
+		d.paths = pathsEscape
+	case "preserve":
+		d.paths = pathsPreserve
+	default:
+		return d, fmt.Errorf("unknown path display: %v", paths)
+	}
+
+	return d, nil
+}
+
+// URL returns a link as it should be displayed, changing only its host and
+// path. Links without a host are returned unchanged.
+func (d urlDisplay) URL(link string) string {
+	if d.hosts == hostsKeep && d.paths == pathsKeep {
+		return link
+	}
+
+	i := strings.Index(link, "://")
+	if i < 0 {
+		return link
+	}
+	start := i + 3
+	end := len(link)
+	if j := strings.IndexAny(link[start:], "/?#"); j >= 0 {
+		end = start + j
+	}
+
+	authority, rest := link[start:end], link[end:]
+	userinfo := ""
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		userinfo, authority = authority[:at+1], authority[at+1:]
+	}
+
+	path, suffix := rest, ""
+	if j := strings.IndexAny(rest, "?#"); j >= 0 {
+		path, suffix = rest[:j], rest[j:]
+	}
+
+	return link[:start] + userinfo + d.host(authority) + d.path(path) + suffix
+}
+
+func (d urlDisplay) host(hostport string) string {
+	u, err := neturl.Parse("//" + hostport)
+	if err != nil || d.hosts == hostsKeep {
+		return hostport
+	}
+
+	var host string
+	if d.hosts == hostsUnicode {
+		host, err = idna.Display.ToUnicode(u.Hostname())
+	} else {
+		host, err = idna.Lookup.ToASCII(u.Hostname())
+	}
+	if err != nil || strings.HasPrefix(hostport, "[") {
+		return hostport
+	}
+
+	if port := u.Port(); len(port) != 0 {
+		return host + ":" + port
+	}
+
+	return host
+}
+
+func (d urlDisplay) path(path string) string {
+	switch d.paths {
+	case pathsEscape:
+		return escapeNonASCII(path)
+	case pathsPreserve:
+		return unescapeNonASCII(path)
+	}
+
+	return path
+}
+
+// Result returns a result with the links written by outputs displayed
+func (d urlDisplay) Result(res result) result {
+	if d.hosts == hostsKeep && d.paths == pathsKeep {
+		return res
+	}
+
+	res.url = d.URL(res.url)
+	res.canonical = d.URL(res.canonical)
+	res.amp = d.URL(res.amp)
+	res.mobile = d.URL(res.mobile)
+	res.manifest = d.URL(res.manifest)
+	res.desktop = d.URL(res.desktop)
+	res.redirect = d.URL(res.redirect)
+	res.links = d.urls(res.links)
+	res.navLinks = d.urls(res.navLinks)
+	res.icons = d.urls(res.icons)
+
+	statuses := make([]linkStatus, len(res.linkStatuses))
+	for i, link := range res.linkStatuses {
+		statuses[i] = linkStatus{d.URL(link.url), link.status}
+	}
+	res.linkStatuses = statuses
+
+	anchors := make([]anchor, len(res.anchors))
+	for i, a := range res.anchors {
+		anchors[i] = anchor{d.URL(a.url), a.text, a.nav}
+	}
+	res.anchors = anchors
+
+	return res
+}
+
+func (d urlDisplay) urls(links []string) []string {
+	displayed := make([]string, len(links))
+	for i, link := range links {
+		displayed[i] = d.URL(link)
+	}
+
+	return displayed
+}
+
+// escapeNonASCII percent-encodes the non-ASCII bytes of a path
+func escapeNonASCII(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] < 0x80 {
+			b.WriteByte(path[i])
+		} else {
+			fmt.Fprintf(&b, "%%%02X", path[i])
+		}
+	}
+
+	return b.String()
+}
+
+// unescapeNonASCII decodes percent-encoded UTF-8 sequences in an escaped
+// path, leaving reserved ASCII characters escaped
+func unescapeNonASCII(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); {
+		j := i
+		var decoded []byte
+		for j+2 < len(path) && path[j] == '%' {
+			c, err := neturl.PathUnescape(path[j : j+3])
+			if err != nil || c[0] < 0x80 {
+				break
+			}
+			decoded = append(decoded, c[0])
+			j += 3
+		}
+
+		switch {
+		case len(decoded) != 0 && utf8.Valid(decoded):
+			b.Write(decoded)
+			i = j
+		case j > i:
+			b.WriteString(path[i:j])
+			i = j
+		default:
+			b.WriteByte(path[i])
+			i++
+		}
+	}
+
+	return b.String()
+}
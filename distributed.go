@@ -0,0 +1,618 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative coordinator.proto
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ---------- Distributed ----------
+
+// The coordinator owns the frontier and the visited set and hands out sites
+// to workers, which fetch and parse them and report back. Leases of workers
+// that stop sending heartbeats, or hold a site past the lease timeout, are
+// handed out again. The service is defined in coordinator.proto.
+
+type lease struct {
+	task    *CrawlTask
+	worker  string
+	expires time.Time
+}
+
+type coordinator struct {
+	UnimplementedCoordinatorServer
+	mutex    sync.Mutex
+	queue    []*CrawlTask
+	visited  map[string]bool
+	leases   map[int64]lease
+	workers  map[string]time.Time
+	nextTask int64
+	nextID   int
+	limit    depthLimit
+	join     *JoinReply
+	timeout  time.Duration
+	leaseFor time.Duration
+	out      Output
+	counts   errorCounts
+	done     chan bool
+	finished bool
+	verbose  bool
+}
+
+// NewCoordinator creates a coordinator with the seeds queued
+func NewCoordinator(seeds []string, limit depthLimit, join *JoinReply, timeout time.Duration, leaseFor time.Duration, out Output, verbose bool) *coordinator {
+	c := &coordinator{
+		visited:  map[string]bool{},
+		leases:   map[int64]lease{},
+		workers:  map[string]time.Time{},
+		limit:    limit,
+		join:     join,
+		timeout:  timeout,
+		leaseFor: leaseFor,
+		out:      out,
+		counts:   errorCounts{},
+		done:     make(chan bool),
+		verbose:  verbose,
+	}
+	for _, seed := range seeds {
+		c.enqueue(seed, 1)
+	}
+
+	return c
+}
+
+func (c *coordinator) enqueue(url string, depth int) {
+	url = StripTracking(url)
+	key := URLKey(url)
+	if c.visited[key] {
+		return
+	}
+
+	c.visited[key] = true
+	c.nextTask++
+	c.queue = append(c.queue, &CrawlTask{Id: c.nextTask, Url: url, Depth: int32(depth)})
+}
+
+// Join registers a worker
+func (c *coordinator) Join(ctx context.Context, req *JoinRequest) (*JoinReply, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.nextID++
+	reply := proto.Clone(c.join).(*JoinReply)
+	reply.Worker = req.Name + "-" + strconv.Itoa(c.nextID)
+	c.workers[reply.Worker] = time.Now()
+	if c.verbose {
+		fmt.Fprintf(logOutput, "Worker joined: %v\n", reply.Worker)
+	}
+
+	return reply, nil
+}
+
+// Lease hands out the next site to crawl, if any
+func (c *coordinator) Lease(ctx context.Context, req *LeaseRequest) (*LeaseReply, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.finished {
+		return &LeaseReply{Done: true}, nil
+	}
+	c.workers[req.Worker] = time.Now()
+
+	if len(c.queue) == 0 {
+		return &LeaseReply{}, nil
+	}
+
+	task := c.queue[0]
+	c.queue = c.queue[1:]
+	c.leases[task.Id] = lease{task, req.Worker, time.Now().Add(c.leaseFor)}
+
+	return &LeaseReply{Task: task}, nil
+}
+
+// Heartbeat keeps the leases of a worker. A worker that was lost and comes
+// back is taken on again, though its old leases were already reassigned.
+func (c *coordinator) Heartbeat(ctx context.Context, req *HeartbeatRequest) (*HeartbeatReply, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.finished {
+		c.workers[req.Worker] = time.Now()
+	}
+
+	return &HeartbeatReply{}, nil
+}
+
+// Complete records the result of a leased site and queues its links.
+// Results of leases that were handed out again are dropped.
+func (c *coordinator) Complete(ctx context.Context, req *CompleteRequest) (*CompleteReply, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	l, ok := c.leases[req.Task]
+	if !ok || l.worker != req.Worker {
+		return &CompleteReply{}, nil
+	}
+	delete(c.leases, req.Task)
+	c.workers[req.Worker] = time.Now()
+
+	res := WorkerResultOf(req.Result)
+	c.counts.Add(res.errClass)
+	if err := c.out.Write(res); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on output: %v\n", err)
+	}
+
+	s := site{url: l.task.Url, depth: int(l.task.Depth)}
+	if res.errClass == errorNone && c.limit.Expand(s) {
+		for _, link := range res.links {
+			if c.limit.Allow(link) {
+				c.enqueue(link, s.depth+1)
+			}
+		}
+	}
+	c.finish()
+
+	return &CompleteReply{}, nil
+}
+
+// Reap hands out the leases of workers without a heartbeat within the
+// timeout again, and leases held past their deadline, such as sites whose
+// completion was lost, until the crawl is finished
+func (c *coordinator) Reap() {
+	ticker := time.NewTicker(c.timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		c.mutex.Lock()
+		for worker, seen := range c.workers {
+			if time.Since(seen) <= c.timeout {
+				continue
+			}
+
+			delete(c.workers, worker)
+			var requeued []*CrawlTask
+			for id, l := range c.leases {
+				if l.worker == worker {
+					delete(c.leases, id)
+					requeued = append(requeued, l.task)
+				}
+			}
+			c.queue = append(requeued, c.queue...)
+			if c.verbose {
+				fmt.Fprintf(logOutput, "Worker lost: %v, reassigning %v sites\n", worker, len(requeued))
+			}
+		}
+
+		now := time.Now()
+		for id, l := range c.leases {
+			if now.Before(l.expires) {
+				continue
+			}
+
+			delete(c.leases, id)
+			c.queue = append([]*CrawlTask{l.task}, c.queue...)
+			if c.verbose {
+				fmt.Fprintf(logOutput, "Lease expired: %v held by %v, reassigning\n", l.task.Url, l.worker)
+			}
+		}
+		c.mutex.Unlock()
+	}
+}
+
+func (c *coordinator) finish() {
+	if !c.finished && len(c.queue) == 0 && len(c.leases) == 0 {
+		c.finished = true
+		close(c.done)
+	}
+}
+
+// WorkerResultOf converts a worker result back to a result
+func WorkerResultOf(r *WorkerResult) result {
+	return result{
+		url:         r.GetUrl(),
+		errClass:    errorClass(r.GetError()),
+		status:      int(r.GetStatus()),
+		redirect:    r.GetRedirect(),
+		canonical:   r.GetCanonical(),
+		noindex:     r.GetNoindex(),
+		lang:        r.GetLang(),
+		title:       r.GetTitle(),
+		contentType: r.GetContentType(),
+		links:       r.GetLinks(),
+	}
+}
+
+// NewWorkerResult converts a result to be sent to the coordinator
+func NewWorkerResult(res result) *WorkerResult {
+	return &WorkerResult{
+		Url:         res.url,
+		Error:       string(res.errClass),
+		Status:      int32(res.status),
+		Redirect:    res.redirect,
+		Canonical:   res.canonical,
+		Noindex:     res.noindex,
+		Lang:        res.lang,
+		Title:       res.title,
+		ContentType: res.contentType,
+		Links:       res.links,
+	}
+}
+
+// HeaderRules converts the header rules of a config to be sent to workers
+func HeaderRules(rules []headerRule) []*HeaderRule {
+	var messages []*HeaderRule
+	for _, rule := range rules {
+		messages = append(messages, &HeaderRule{Match: rule.Match, UserAgent: rule.UserAgent, Headers: rule.Headers})
+	}
+
+	return messages
+}
+
+// CrawlRules converts the crawl rules of a config to be sent to workers
+func CrawlRules(rules crawlRules) []*CrawlRule {
+	var messages []*CrawlRule
+	for _, rule := range rules {
+		m := &CrawlRule{
+			Match:          rule.Match,
+			Delay:          rule.Delay,
+			Headers:        rule.Headers,
+			Accept:         rule.Accept,
+			AcceptLanguage: rule.Language,
+			Method:         rule.Method,
+			Body:           rule.Body,
+			Parser:         rule.Parser,
+			StoreBody:      rule.StoreBody,
+		}
+		if rule.Depth != nil {
+			depth := int32(*rule.Depth)
+			m.Depth = &depth
+		}
+		messages = append(messages, m)
+	}
+
+	return messages
+}
+
+// Config converts the headers and rules sent by the coordinator back to a
+// config, with its rules compiled
+func (j *JoinReply) Config() (config, error) {
+	var c config
+	for _, rule := range j.GetHeaders() {
+		c.Headers = append(c.Headers, headerRule{rule.GetMatch(), rule.GetUserAgent(), rule.GetHeaders()})
+	}
+	for _, m := range j.GetRules() {
+		rule := crawlRule{
+			Match:     m.GetMatch(),
+			Delay:     m.GetDelay(),
+			Headers:   m.GetHeaders(),
+			Accept:    m.GetAccept(),
+			Language:  m.GetAcceptLanguage(),
+			Method:    m.GetMethod(),
+			Body:      m.GetBody(),
+			Parser:    m.GetParser(),
+			StoreBody: m.StoreBody,
+		}
+		if m.Depth != nil {
+			depth := int(m.GetDepth())
+			rule.Depth = &depth
+		}
+		c.Rules = append(c.Rules, rule)
+	}
+
+	return c, c.Rules.compile()
+}
+
+// RunCoordinator runs the coordinator subcommand
+func RunCoordinator(args []string) {
+	flags := flag.NewFlagSet("coordinator", flag.ExitOnError)
+	listen := flags.String("listen", ":7700", "Set address to listen for workers on.")
+	url := flags.String("url", "https://golang.org/", "Set starting URL.")
+	seedFile := flags.String("seeds", "", "Set file of starting URLs, one per line.")
+	depth := flags.Int("depth", 1, "Set to >= 1 to specify depth.")
+	output := flags.String("output", "text", "Set output: text or jsonl.")
+	outputFile := flags.String("output-file", "", "Set file to write jsonl output to instead of stdout.")
+	heartbeat := flags.Duration("heartbeat", 5*time.Second, "Set interval of worker heartbeats.")
+	timeout := flags.Duration("worker-timeout", 30*time.Second, "Set time without heartbeat after which the sites of a worker are reassigned.")
+	leaseFor := flags.Duration("lease-timeout", 10*time.Minute, "Set time after which a site not completed by its worker is reassigned.")
+	parseCSS := flags.Bool("parse-css", false, "Set to true to make workers extract links from stylesheets.")
+	parseImages := flags.Bool("parse-images", false, "Set to true to make workers extract image links.")
+	parseJS := flags.Bool("parse-js", false, "Set to true to make workers extract links from scripts.")
+	maxBody := flags.Int64("max-body", 10<<20, "Set maximum body size in bytes workers download, 0 for no limit.")
+	identify := flags.String("identify", "", "Set User-Agent workers identify the crawler with.")
+	from := flags.String("from", "", "Set From header workers send with a contact address for site operators.")
+	obeyRobots := flags.Bool("robots", false, "Set to true to make workers skip URLs disallowed by robots.txt for the -identify user agent.")
+	hostDelay := flags.Duration("host-delay", 0, "Set minimum time between requests of each worker to the same host.")
+	maxBandwidth := flags.String("max-bandwidth", "", "Set to limit the download rate of each worker, e.g. 5MB/s or 500KiB/s.")
+	configPath := flags.String("config", "", "Set JSON config file with the headers and rules workers apply.")
+	verbose := flags.Bool("verbose", true, "Set to false to disable printing.")
+	flags.Parse(args)
+
+	if *heartbeat <= 0 || *timeout <= 0 || *leaseFor <= 0 {
+		fmt.Fprintln(os.Stderr, "heartbeat, worker-timeout and lease-timeout must be > 0")
+		os.Exit(2)
+	}
+
+	seeds := []string{*url}
+	if len(*seedFile) != 0 {
+		var err error
+		seeds, _, err = LoadSeeds(*seedFile)
+		if err == nil && len(seeds) == 0 {
+			err = fmt.Errorf("no seeds in %v", *seedFile)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on seeds: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	limit, err := NewDepthLimit(seeds[0], *depth, depthHops)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	conf, err := LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error on config: %v\n", err)
+		os.Exit(2)
+	}
+
+	bandwidth := 0.0
+	if len(*maxBandwidth) != 0 {
+		bandwidth, err = ParseBandwidth(*maxBandwidth)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	var out Output
+	switch *output {
+	case "text":
+		out = textOutput{}
+	case "jsonl":
+		var w io.WriteCloser
+		if len(*outputFile) != 0 {
+			w, err = os.Create(*outputFile)
+		} else {
+			w = StdoutResults()
+		}
+		if err == nil {
+			out, err = NewJSONLOutput(w, "none", 0)
+		}
+	default:
+		err = fmt.Errorf("unknown output: %v", *output)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	join := &JoinReply{
+		Heartbeat: durationpb.New(*heartbeat),
+		Css:       *parseCSS,
+		Images:    *parseImages,
+		Js:        *parseJS,
+		MaxBody:   *maxBody,
+		Agent:     *identify,
+		From:      *from,
+		Robots:    *obeyRobots,
+		HostDelay: durationpb.New(*hostDelay),
+		Bandwidth: bandwidth,
+		Headers:   HeaderRules(conf.Headers),
+		Rules:     CrawlRules(conf.Rules),
+	}
+	c := NewCoordinator(seeds, limit, join, *timeout, *leaseFor, out, *verbose)
+	server := grpc.NewServer()
+	RegisterCoordinatorServer(server, c)
+	go server.Serve(lis)
+	go c.Reap()
+	if *verbose {
+		fmt.Fprintf(logOutput, "Coordinator listening on %v\n", lis.Addr())
+	}
+
+	<-c.done
+	// Workers learn the crawl is done from their next lease
+	time.Sleep(*heartbeat)
+	server.Stop()
+
+	if err := out.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on output: %v\n", err)
+	}
+	c.counts.Print()
+}
+
+// callTimeout bounds each call of a worker to the coordinator
+const callTimeout = time.Minute
+
+// callContext returns a context for a call of a worker to the coordinator
+func callContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), callTimeout)
+}
+
+// RunWorker runs the worker subcommand
+func RunWorker(args []string) {
+	flags := flag.NewFlagSet("worker", flag.ExitOnError)
+	addr := flags.String("join", "localhost:7700", "Set address of the coordinator to join.")
+	name := flags.String("name", "", "Set worker name, defaults to the host name.")
+	parallel := flags.Int("parallel", 4, "Set number of sites crawled at the same time.")
+	verbose := flags.Bool("verbose", true, "Set to false to disable printing.")
+	flags.Parse(args)
+
+	if len(*name) == 0 {
+		*name, _ = os.Hostname()
+	}
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	client := NewCoordinatorClient(conn)
+
+	ctx, cancel := callContext()
+	join, err := client.Join(ctx, &JoinRequest{Name: *name})
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error on join: %v\n", err)
+		os.Exit(1)
+	}
+	if *verbose {
+		fmt.Fprintf(logOutput, "Joined %v as %v\n", *addr, join.Worker)
+	}
+
+	stop := make(chan bool)
+	go func() {
+		ticker := time.NewTicker(join.GetHeartbeat().AsDuration())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			ctx, cancel := callContext()
+			_, err := client.Heartbeat(ctx, &HeartbeatRequest{Worker: join.Worker})
+			cancel()
+			if err != nil && *verbose {
+				fmt.Fprintf(logOutput, "Error on heartbeat: %v\n", err)
+			}
+		}
+	}()
+
+	fetcher, robots, err := NewWorkerFetcher(join)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error on join: %v\n", err)
+		os.Exit(1)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < *parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RunWorkerLoop(client, join.Worker, fetcher, robots, *verbose)
+		}()
+	}
+	wg.Wait()
+	close(stop)
+}
+
+// NewWorkerFetcher creates the fetcher of a worker and the robots.txt
+// policy it obeys, if any, from the settings of the coordinator
+func NewWorkerFetcher(join *JoinReply) (fetcher, *robotsPolicy, error) {
+	conf, err := join.Config()
+	if err != nil {
+		return fetcher{}, nil, err
+	}
+
+	client := NewClient()
+	Identify(client, join.Agent, join.From)
+
+	pacer := NewHostPacer(join.GetHostDelay().AsDuration())
+	if pacer == nil && conf.Rules.Paced() {
+		pacer = &hostPacer{next: map[string]time.Time{}}
+	}
+
+	f := fetcher{
+		client:   client,
+		headers:  conf.Headers,
+		rules:    conf.Rules,
+		throttle: NewThrottle(join.Bandwidth),
+		pacer:    pacer,
+		maxBody:  join.MaxBody,
+		links:    linkOptions{css: join.Css, images: join.Images, js: join.Js},
+	}
+
+	var robots *robotsPolicy
+	if join.Robots {
+		agent := join.Agent
+		if len(agent) == 0 {
+			agent = "Go-http-client"
+		}
+		robots = NewRobotsPolicy(client, agent)
+	}
+
+	return f, robots, nil
+}
+
+// completeRetries is how often a worker tries to report a result
+const completeRetries = 3
+
+// RunWorkerLoop leases, crawls and completes sites until the coordinator
+// is done or cannot be reached
+func RunWorkerLoop(client CoordinatorClient, worker string, fetcher Fetcher, robots *robotsPolicy, verbose bool) {
+	failures := 0
+	for failures < 3 {
+		ctx, cancel := callContext()
+		lease, err := client.Lease(ctx, &LeaseRequest{Worker: worker})
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on lease: %v\n", err)
+			failures++
+			time.Sleep(time.Second)
+			continue
+		}
+		failures = 0
+
+		if lease.Done {
+			return
+		}
+		if lease.Task == nil {
+			time.Sleep(200 * time.Millisecond)
+			continue
+		}
+
+		url := lease.Task.Url
+		if verbose {
+			fmt.Fprintf(logOutput, "Crawling URL: %v\n", url)
+		}
+		resp := response{url: url, urls: []string{}, err: robotsError{}}
+		if robots.Allowed(url) {
+			resp, _ = fetcher.Fetch(NewFetchRequest(url))
+		}
+		res := parser{}.Parse(resp)
+
+		// Sites whose result is lost are reassigned once their lease expires
+		req := &CompleteRequest{Worker: worker, Task: lease.Task.Id, Result: NewWorkerResult(res)}
+		for attempt := 1; ; attempt++ {
+			ctx, cancel := callContext()
+			_, err := client.Complete(ctx, req)
+			cancel()
+			if err == nil {
+				break
+			}
+			fmt.Fprintf(os.Stderr, "Error on complete: %v\n", err)
+			if attempt == completeRetries {
+				break
+			}
+			time.Sleep(time.Second)
+		}
+	}
+}
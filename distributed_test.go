@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestJoinReplyConfig(t *testing.T) {
+	depth, storeBody := 2, false
+	conf := config{
+		Headers: []headerRule{{Match: "*.example.com", UserAgent: "bot", Headers: map[string]string{"X-Team": "search"}}},
+		Rules: crawlRules{
+			{Match: "example.com/docs/*", Depth: &depth, Delay: "1s", Method: "GET", StoreBody: &storeBody},
+			{Match: "example.com/api/*", Parser: "js", Headers: map[string]string{"Accept": "application/json"}},
+		},
+	}
+	if err := conf.Rules.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rules are sent over the wire, so the reply must survive encoding
+	data, err := proto.Marshal(&JoinReply{Headers: HeaderRules(conf.Headers), Rules: CrawlRules(conf.Rules)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var join JoinReply
+	if err := proto.Unmarshal(data, &join); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := join.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Headers, conf.Headers) {
+		t.Errorf("headers = %+v, want %+v", got.Headers, conf.Headers)
+	}
+	if !reflect.DeepEqual(got.Rules, conf.Rules) {
+		t.Errorf("rules = %+v, want %+v", got.Rules, conf.Rules)
+	}
+	if got.Rules[0].delay != time.Second {
+		t.Errorf("delay = %v, want compiled to 1s", got.Rules[0].delay)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ---------- Dry run ----------
+
+// DryRun prints which seeds would be fetched at depth 1 and which sitemap
+// URLs could be fetched at depth 2, after the filters, robots rules and
+// depth limit of a crawl, without fetching any pages. Only robots.txt and
+// sitemaps are fetched. Depth 2 holds the sitemap URLs of the seeds' hosts
+// since the links of a page are only known once it is fetched.
+func DryRun(client *http.Client, seeds []string, opts crawlOptions, agent string, rules crawlRules) {
+	policy := opts.robots
+	obeyed := policy != nil
+	if policy == nil {
+		policy = NewRobotsPolicy(client, agent)
+	}
+
+	seen := map[string]bool{}
+	check := func(url string, depth int) {
+		url = opts.collapse.Strip(StripTracking(url))
+		key := URLKey(url)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		var notes []string
+		skip := ""
+		allowed := policy.Allowed(url)
+		_, inExternal := opts.limit.ExternalHops(site{}, url)
+		switch {
+		case depth > 1 && !opts.limit.Allow(url):
+			skip = "outside max depth"
+		case depth > 1 && !inExternal:
+			skip = "outside external depth"
+		case !allowed && obeyed:
+			skip = "disallowed by robots.txt"
+		case !allowed:
+			notes = append(notes, "disallowed by robots.txt, not obeyed without -robots")
+		}
+		notes = append(notes, describeRules(rules.For(url))...)
+
+		if len(skip) != 0 {
+			fmt.Fprintf(logOutput, "Skip (depth %v): %v (%v)\n", depth, url, skip)
+		} else if len(notes) != 0 {
+			fmt.Fprintf(logOutput, "Would fetch (depth %v): %v (%v)\n", depth, url, strings.Join(notes, ", "))
+		} else {
+			fmt.Fprintf(logOutput, "Would fetch (depth %v): %v\n", depth, url)
+		}
+	}
+
+	var expanded []string
+	for _, seed := range seeds {
+		check(seed, 1)
+		if opts.limit.Expand(site{url: seed, depth: 1}) {
+			expanded = append(expanded, seed)
+		}
+	}
+
+	sitemapHosts := map[string]bool{}
+	for _, seed := range expanded {
+		origin := RobotsURL(seed)
+		if sitemapHosts[origin] {
+			continue
+		}
+		sitemapHosts[origin] = true
+
+		robotsTxt, err := FetchRobots(client, seed)
+		if err != nil {
+			fmt.Fprintf(logOutput, "Error on %v: %v\n", origin, err)
+			continue
+		}
+		locations := robotsTxt.sitemaps
+		if len(locations) == 0 {
+			locations = []string{DefaultSitemap(seed)}
+		}
+		urls, err := GetSitemapURLs(locations)
+		if err != nil {
+			fmt.Fprintf(logOutput, "Error on sitemap: %v\n", err)
+		}
+		for _, url := range urls {
+			check(url, 2)
+		}
+	}
+}
+
+// describeRules describes the crawl rule behavior applying to a URL
+func describeRules(behavior ruleBehavior) []string {
+	var notes []string
+	if behavior.depth != nil {
+		notes = append(notes, fmt.Sprintf("rule depth %v", *behavior.depth))
+	}
+	if behavior.delay != nil {
+		notes = append(notes, fmt.Sprintf("rule delay %v", *behavior.delay))
+	}
+	if len(behavior.headers) != 0 {
+		notes = append(notes, fmt.Sprintf("rule headers %v", len(behavior.headers)))
+	}
+	if len(behavior.parser) != 0 {
+		notes = append(notes, "rule parser "+behavior.parser)
+	}
+	if behavior.storeBody != nil {
+		notes = append(notes, fmt.Sprintf("rule store body %v", *behavior.storeBody))
+	}
+
+	return notes
+}
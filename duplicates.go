@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ---------- Duplicates ----------
+
+// metadataCluster is a title or meta description shared by several pages
+type metadataCluster struct {
+	text  string
+	pages map[string]string
+}
+
+// metadataClusters groups crawled pages by identical titles and meta
+// descriptions, compared ignoring case and whitespace
+type metadataClusters struct {
+	titles       map[string]*metadataCluster
+	descriptions map[string]*metadataCluster
+}
+
+// Add records the title and description of a crawled page
+func (m *metadataClusters) Add(res result) {
+	if res.errClass != errorNone || len(res.redirect) != 0 {
+		return
+	}
+	if m.titles == nil {
+		m.titles = map[string]*metadataCluster{}
+		m.descriptions = map[string]*metadataCluster{}
+	}
+
+	addToCluster(m.titles, res.title, res.url)
+	addToCluster(m.descriptions, res.description, res.url)
+}
+
+func addToCluster(clusters map[string]*metadataCluster, text string, page string) {
+	key := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if len(key) == 0 {
+		return
+	}
+
+	if clusters[key] == nil {
+		clusters[key] = &metadataCluster{text: text, pages: map[string]string{}}
+	}
+	clusters[key].pages[URLKey(page)] = page
+}
+
+// Duplicates returns the clusters of more than one page, largest first
+func Duplicates(clusters map[string]*metadataCluster) []*metadataCluster {
+	var duplicates []*metadataCluster
+	for _, cluster := range clusters {
+		if len(cluster.pages) > 1 {
+			duplicates = append(duplicates, cluster)
+		}
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		if len(duplicates[i].pages) != len(duplicates[j].pages) {
+			return len(duplicates[i].pages) > len(duplicates[j].pages)
+		}
+		return duplicates[i].text < duplicates[j].text
+	})
+
+	return duplicates
+}
+
+// Print prints the duplicated titles and descriptions with up to n example
+// pages each
+func (m *metadataClusters) Print(n int) {
+	printClusters("Duplicate title", Duplicates(m.titles), n)
+	printClusters("Duplicate description", Duplicates(m.descriptions), n)
+}
+
+func printClusters(label string, clusters []*metadataCluster, n int) {
+	for _, cluster := range clusters {
+		var pages []string
+		for _, page := range cluster.pages {
+			pages = append(pages, page)
+		}
+		sort.Strings(pages)
+
+		fmt.Fprintf(logOutput, "%v: %q on %v pages\n", label, cluster.text, len(pages))
+		for i, page := range pages {
+			if i == n {
+				fmt.Fprintf(logOutput, "  and %v more\n", len(pages)-n)
+				break
+			}
+			fmt.Fprintf(logOutput, "  %v\n", page)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// ---------- Errors ----------
+
+type errorClass string
+
+const (
+	errorNone        errorClass = ""
+	errorDNS         errorClass = "dns"
+	errorConnect     errorClass = "connect"
+	errorTLS         errorClass = "tls"
+	errorTimeout     errorClass = "timeout"
+	errorHTTP4xx     errorClass = "http-4xx"
+	errorHTTP5xx     errorClass = "http-5xx"
+	errorTooLarge    errorClass = "too-large"
+	errorNotArchived errorClass = "not-archived"
+	errorSoft404     errorClass = "soft-404"
+	errorRobots      errorClass = "robots-blocked"
+	errorTrap        errorClass = "trap-skipped"
+	errorOther       errorClass = "other"
+)
+
+type statusError struct {
+	status int
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("unexpected status: %v", e.status)
+}
+
+type tooLargeError struct {
+	limit int64
+}
+
+func (e tooLargeError) Error() string {
+	return fmt.Sprintf("body exceeds %v bytes", e.limit)
+}
+
+type notArchivedError struct {
+	url string
+}
+
+func (e notArchivedError) Error() string {
+	return fmt.Sprintf("not in archive: %v", e.url)
+}
+
+type robotsError struct{}
+
+func (e robotsError) Error() string {
+	return "disallowed by robots.txt"
+}
+
+type trapError struct {
+	reason string
+}
+
+func (e trapError) Error() string {
+	return fmt.Sprintf("crawler trap: %v", e.reason)
+}
+
+// ClassifyError maps a fetch error to an error class
+func ClassifyError(err error) errorClass {
+	if err == nil {
+		return errorNone
+	}
+
+	var status statusError
+	if errors.As(err, &status) {
+		if status.status >= 500 {
+			return errorHTTP5xx
+		}
+		return errorHTTP4xx
+	}
+
+	var tooLarge tooLargeError
+	if errors.As(err, &tooLarge) {
+		return errorTooLarge
+	}
+
+	var archiveErr notArchivedError
+	if errors.As(err, &archiveErr) {
+		return errorNotArchived
+	}
+
+	var robots robotsError
+	if errors.As(err, &robots) {
+		return errorRobots
+	}
+
+	var trap trapError
+	if errors.As(err, &trap) {
+		return errorTrap
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return errorDNS
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return errorTimeout
+	}
+
+	if IsTLSError(err) {
+		return errorTLS
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return errorConnect
+	}
+
+	return errorOther
+}
+
+// IsTLSError reports whether an error comes from a TLS handshake, such as
+// an invalid certificate or an alert sent by the server
+func IsTLSError(err error) bool {
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var verifyErr *tls.CertificateVerificationError
+	var certErr x509.CertificateInvalidError
+	var hostErr x509.HostnameError
+	var authErr x509.UnknownAuthorityError
+	var rootsErr x509.SystemRootsError
+	var algorithmErr x509.InsecureAlgorithmError
+	var constraintErr x509.ConstraintViolationError
+	var extensionErr x509.UnhandledCriticalExtension
+	if errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &certErr) || errors.As(err, &hostErr) || errors.As(err, &authErr) ||
+		errors.As(err, &rootsErr) || errors.As(err, &algorithmErr) ||
+		errors.As(err, &constraintErr) || errors.As(err, &extensionErr) {
+		return true
+	}
+
+	// Alerts received over TCP are only reported as remote errors
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "remote error"
+}
+
+// ErrorStatus returns the HTTP status of a fetch error, or 0 if the error is
+// not an HTTP status
+func ErrorStatus(err error) int {
+	var status statusError
+	if errors.As(err, &status) {
+		return status.status
+	}
+
+	return 0
+}
+
+type errorCounts map[errorClass]int
+
+// Add counts an error class
+func (c errorCounts) Add(class errorClass) {
+	if class != errorNone {
+		c[class]++
+	}
+}
+
+// Print prints the error counts sorted by class
+func (c errorCounts) Print() {
+	var classes []string
+	for class := range c {
+		classes = append(classes, string(class))
+	}
+	sort.Strings(classes)
+
+	for _, class := range classes {
+		fmt.Fprintf(logOutput, "Errors (%v): %v\n", class, c[errorClass(class)])
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err   error
+		class errorClass
+	}{
+		{nil, errorNone},
+		{statusError{404}, errorHTTP4xx},
+		{statusError{503}, errorHTTP5xx},
+		{fmt.Errorf("get: %w", statusError{500}), errorHTTP5xx},
+		{tooLargeError{10}, errorTooLarge},
+		{notArchivedError{"http://example.com/"}, errorNotArchived},
+		{robotsError{}, errorRobots},
+		{trapError{"repeated"}, errorTrap},
+		{&net.DNSError{Err: "no such host", Name: "example.invalid"}, errorDNS},
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, errorConnect},
+		{tls.AlertError(40), errorTLS},
+		{&tls.CertificateVerificationError{Err: errors.New("expired")}, errorTLS},
+		{fmt.Errorf("get: %w", x509.HostnameError{Host: "example.com"}), errorTLS},
+		{x509.UnknownAuthorityError{}, errorTLS},
+		{x509.SystemRootsError{}, errorTLS},
+		{x509.InsecureAlgorithmError(x509.SHA1WithRSA), errorTLS},
+		{x509.ConstraintViolationError{}, errorTLS},
+		{&net.OpError{Op: "remote error", Err: errors.New("tls: handshake failure")}, errorTLS},
+		{errors.New("other"), errorOther},
+	}
+
+	for _, test := range tests {
+		if class := ClassifyError(test.err); class != test.class {
+			t.Errorf("ClassifyError(%v) = %q, want %q", test.err, class, test.class)
+		}
+	}
+}
+
+func TestClassifyTLSError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The test server's certificate is not trusted by the default client
+	_, err := http.Get(server.URL)
+	if class := ClassifyError(err); class != errorTLS {
+		t.Errorf("ClassifyError(%v) = %q, want %q", err, class, errorTLS)
+	}
+}
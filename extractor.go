@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"plugin"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// ---------- Extractors ----------
+
+// Extractor extracts custom values from a fetched page.
+//
+// Go plugins (.so, built with -buildmode=plugin on Linux or macOS) export
+//
+//	func Extract(url string, contentType string, body []byte) (map[string]string, error)
+//
+// WASM modules (.wasm) export their memory and
+//
+//	alloc(size i32) i32
+//	extract(url_ptr, url_len, type_ptr, type_len, body_ptr, body_len i32) i64
+//
+// where alloc reserves size bytes the crawler writes an argument to, and
+// extract returns the pointer in the high and the length in the low 32 bits
+// of a JSON object of string values, or 0 for none. A JSON object with an
+// "error" key reports an error.
+type Extractor interface {
+	Extract(url string, contentType string, body []byte) (map[string]string, error)
+}
+
+type extractFunc func(url string, contentType string, body []byte) (map[string]string, error)
+
+// Extract calls the function
+func (f extractFunc) Extract(url string, contentType string, body []byte) (map[string]string, error) {
+	return f(url, contentType, body)
+}
+
+// LoadExtractors loads comma separated Go plugin and WASM extractors
+func LoadExtractors(list string) ([]Extractor, error) {
+	var extractors []Extractor
+	for _, path := range strings.Split(list, ",") {
+		path = strings.TrimSpace(path)
+		if len(path) == 0 {
+			continue
+		}
+
+		var extractor Extractor
+		var err error
+		if strings.HasSuffix(path, ".wasm") {
+			extractor, err = LoadWASMExtractor(path)
+		} else {
+			extractor, err = LoadPluginExtractor(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("extractor %v: %v", path, err)
+		}
+		extractors = append(extractors, extractor)
+	}
+
+	return extractors, nil
+}
+
+// LoadPluginExtractor loads a Go plugin exporting Extract
+func LoadPluginExtractor(path string) (Extractor, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol, err := p.Lookup("Extract")
+	if err != nil {
+		return nil, err
+	}
+
+	extract, ok := symbol.(func(string, string, []byte) (map[string]string, error))
+	if !ok {
+		return nil, fmt.Errorf("Extract has type %T", symbol)
+	}
+
+	return extractFunc(extract), nil
+}
+
+type wasmExtractor struct {
+	mutex   sync.Mutex
+	module  api.Module
+	alloc   api.Function
+	extract api.Function
+}
+
+// LoadWASMExtractor instantiates a WASM module exporting alloc and extract.
+// Modules may use WASI.
+func LoadWASMExtractor(path string) (Extractor, error) {
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	runtime := wazero.NewRuntime(ctx)
+	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
+
+	module, err := runtime.Instantiate(ctx, source)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+
+	e := &wasmExtractor{module: module, alloc: module.ExportedFunction("alloc"), extract: module.ExportedFunction("extract")}
+	if e.alloc == nil || e.extract == nil || module.Memory() == nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("module must export memory, alloc and extract")
+	}
+
+	return e, nil
+}
+
+// Extract calls the module, one page at a time
+func (e *wasmExtractor) Extract(url string, contentType string, body []byte) (map[string]string, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ctx := context.Background()
+	var params []uint64
+	for _, arg := range [][]byte{[]byte(url), []byte(contentType), body} {
+		ptr, err := e.write(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, ptr, uint64(len(arg)))
+	}
+
+	ret, err := e.extract.Call(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 || ret[0] == 0 {
+		return nil, nil
+	}
+
+	data, ok := e.module.Memory().Read(uint32(ret[0]>>32), uint32(ret[0]))
+	if !ok {
+		return nil, fmt.Errorf("result out of memory range")
+	}
+
+	values := map[string]string{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if msg, ok := values["error"]; ok {
+		return nil, fmt.Errorf("%v", msg)
+	}
+
+	return values, nil
+}
+
+func (e *wasmExtractor) write(ctx context.Context, data []byte) (uint64, error) {
+	ret, err := e.alloc.Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, err
+	}
+	if len(ret) == 0 {
+		return 0, fmt.Errorf("alloc returned nothing")
+	}
+
+	if !e.module.Memory().Write(uint32(ret[0]), data) {
+		return 0, fmt.Errorf("argument out of memory range")
+	}
+
+	return ret[0], nil
+}
+
+// RunExtractors merges the values of every extractor for a page, later
+// extractors overriding earlier ones
+func RunExtractors(extractors []Extractor, url string, contentType string, body []byte) map[string]string {
+	var values map[string]string
+	for _, extractor := range extractors {
+		extracted, err := extractor.Extract(url, contentType, body)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error on extractor for %v: %v\n", url, err)
+			continue
+		}
+		for key, value := range extracted {
+			if values == nil {
+				values = map[string]string{}
+			}
+			values[key] = value
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package main
+
+import "syscall"
+
+// FileLimit returns the soft limit on open file descriptors, or 0 if it is
+// unknown
+func FileLimit() int {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0
+	}
+
+	return int(limit.Cur)
+}
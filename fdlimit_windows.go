@@ -0,0 +1,9 @@
+//go:build windows
+
+package main
+
+// FileLimit returns 0 since Windows has no file descriptor limit to watch.
+// Dials still wait and retry when they run out of handles.
+func FileLimit() int {
+	return 0
+}
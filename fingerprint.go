@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// ---------- Fingerprint ----------
+
+var tlsFingerprints = map[string]utls.ClientHelloID{
+	"chrome":  utls.HelloChrome_Auto,
+	"firefox": utls.HelloFirefox_Auto,
+	"safari":  utls.HelloSafari_Auto,
+	"edge":    utls.HelloEdge_Auto,
+	"ios":     utls.HelloIOS_Auto,
+}
+
+// ParseTLSFingerprint parses a TLS fingerprint flag value, where go keeps
+// Go's own ClientHello
+func ParseTLSFingerprint(name string) (*utls.ClientHelloID, error) {
+	if name == "go" {
+		return nil, nil
+	}
+
+	id, ok := tlsFingerprints[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown TLS fingerprint: %v", name)
+	}
+
+	return &id, nil
+}
+
+// ApplyTLSFingerprint makes a client send the ClientHello of a browser. The
+// hello only offers HTTP/1.1, since the transport cannot speak HTTP/2 over
+// its connections, and proxies are bypassed since the transport would
+// otherwise do its own handshake through them.
+func ApplyTLSFingerprint(client *http.Client, id *utls.ClientHelloID) {
+	if id == nil {
+		return
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	transport.DialTLSContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		spec, err := utls.UTLSIdToSpec(*id)
+		if err != nil {
+			return nil, err
+		}
+		for _, ext := range spec.Extensions {
+			if alpn, ok := ext.(*utls.ALPNExtension); ok {
+				alpn.AlpnProtocols = []string{"http/1.1"}
+			}
+		}
+
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConn := utls.UClient(conn, &utls.Config{ServerName: host}, utls.HelloCustom)
+		if err := tlsConn.ApplyPreset(&spec); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return tlsConn, nil
+	}
+	transport.Proxy = nil
+	client.Transport = transport
+}
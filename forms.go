@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ---------- Forms ----------
+
+type formInput struct {
+	name string
+	kind string
+}
+
+// form is an input surface of a page
+type form struct {
+	action string
+	method string
+	inputs []formInput
+	csrf   bool
+}
+
+// csrfFields are lower case names of hidden fields holding anti-CSRF tokens
+// in common frameworks
+var csrfFields = []string{
+	"csrf", "xsrf", "authenticity_token", "__requestverificationtoken",
+	"_token", "form_key", "nonce", "__viewstategenerator",
+}
+
+// IsCSRFField reports whether a field name looks like an anti-CSRF token
+func IsCSRFField(name string) bool {
+	name = strings.ToLower(name)
+	for _, field := range csrfFields {
+		if strings.Contains(name, field) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formFacts collects the forms of a page and their fields
+type formFacts struct {
+	forms  []form
+	inForm bool
+}
+
+// Token records forms and the fields inside them
+func (f *formFacts) Token(baseURL string, tokenType html.TokenType, token html.Token) {
+	if "form" == token.Data {
+		f.inForm = tokenType == html.StartTagToken
+		if tokenType == html.EndTagToken {
+			return
+		}
+
+		action := baseURL
+		if link := TrimLink(GetAttr(token, "action")); len(link) != 0 {
+			action = FixLink(baseURL, link)
+		}
+		method := strings.ToUpper(strings.TrimSpace(GetAttr(token, "method")))
+		if len(method) == 0 {
+			method = "GET"
+		}
+		f.forms = append(f.forms, form{action: action, method: method})
+		return
+	}
+
+	if !f.inForm || len(f.forms) == 0 || tokenType == html.EndTagToken {
+		return
+	}
+
+	var kind string
+	switch token.Data {
+	case "input":
+		kind = strings.ToLower(strings.TrimSpace(GetAttr(token, "type")))
+		if len(kind) == 0 {
+			kind = "text"
+		}
+	case "select", "textarea":
+		kind = token.Data
+	case "button":
+		kind = "button"
+		if len(GetAttr(token, "name")) == 0 {
+			return
+		}
+	default:
+		return
+	}
+
+	current := &f.forms[len(f.forms)-1]
+	name := GetAttr(token, "name")
+	current.inputs = append(current.inputs, formInput{name, kind})
+	if kind == "hidden" && IsCSRFField(name) {
+		current.csrf = true
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ---------- Frames ----------
+
+type frameMode int
+
+const (
+	framesNone frameMode = iota
+	framesSeparate
+	framesMerged
+)
+
+// maxFrameNesting limits how deep merged frames are followed
+const maxFrameNesting = 3
+
+// ParseFrameMode parses a frame mode flag value
+func ParseFrameMode(mode string) (frameMode, error) {
+	switch mode {
+	case "none":
+		return framesNone, nil
+	case "separate":
+		return framesSeparate, nil
+	case "merged":
+		return framesMerged, nil
+	}
+
+	return framesNone, fmt.Errorf("unknown frame mode: %v", mode)
+}
+
+// mergeFrames fetches the frames of a page and adds their links and text to
+// the page, as if the frame content was part of it
+func (f fetcher) mergeFrames(resp *response, frames []string, seen map[string]bool, nesting int) {
+	if nesting > maxFrameNesting {
+		return
+	}
+
+	for _, frame := range frames {
+		if seen[URLKey(frame)] {
+			continue
+		}
+		seen[URLKey(frame)] = true
+
+		e, err := f.get(NewFetchRequest(frame))
+		if err != nil {
+			continue
+		}
+
+		doc := ParseDocument(e.url, bytes.NewReader(e.body), f.links)
+		resp.urls = MergeLinks(resp.urls, doc.links)
+		resp.anchors = append(resp.anchors, doc.anchors...)
+		resp.images = append(resp.images, doc.images...)
+		resp.resources = append(resp.resources, doc.resources...)
+		if len(doc.text) != 0 {
+			resp.text += "\n" + doc.text
+		}
+
+		f.mergeFrames(resp, doc.frames, seen, nesting+1)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ---------- Freshness ----------
+
+// FreshnessLifetime returns how long a response stays fresh after it was
+// fetched by its Cache-Control or Expires header, and whether either is set.
+// Responses that must not be stored or must be revalidated are never fresh,
+// wherever the directive is, and s-maxage takes precedence over max-age.
+func FreshnessLifetime(header http.Header) (time.Duration, bool) {
+	age := time.Duration(0)
+	if seconds, err := strconv.ParseInt(strings.TrimSpace(header.Get("Age")), 10, 64); err == nil && seconds > 0 {
+		age = time.Duration(seconds) * time.Second
+	}
+
+	maxAge, sharedMaxAge := "", ""
+	for _, directive := range CacheDirectives(header) {
+		switch {
+		case directive == "no-store" || directive == "no-cache":
+			return 0, true
+		case strings.HasPrefix(directive, "s-maxage=") && len(sharedMaxAge) == 0:
+			sharedMaxAge = directive[len("s-maxage="):]
+		case strings.HasPrefix(directive, "max-age=") && len(maxAge) == 0:
+			maxAge = directive[len("max-age="):]
+		}
+	}
+
+	if len(sharedMaxAge) != 0 {
+		maxAge = sharedMaxAge
+	}
+	if len(maxAge) != 0 {
+		seconds, err := strconv.ParseInt(strings.Trim(maxAge, `"`), 10, 64)
+		if err != nil {
+			return 0, true
+		}
+		return positive(time.Duration(seconds)*time.Second - age), true
+	}
+
+	if expiresHeader := header.Get("Expires"); len(expiresHeader) != 0 {
+		expires, err := http.ParseTime(expiresHeader)
+		if err != nil {
+			return 0, true
+		}
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			return positive(time.Until(expires)), true
+		}
+		return positive(expires.Sub(date) - age), true
+	}
+
+	return 0, false
+}
+
+// CacheDirectives returns the lower case Cache-Control directives of a
+// response
+func CacheDirectives(header http.Header) []string {
+	var directives []string
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			if directive = strings.ToLower(strings.TrimSpace(directive)); len(directive) != 0 {
+				directives = append(directives, directive)
+			}
+		}
+	}
+
+	return directives
+}
+
+// freshSkipper skips URLs whose cached responses are still fresh, so
+// re-crawls over the same cache only fetch what may have changed
+type freshSkipper struct {
+	cache   *cache
+	skipped atomic.Int64
+}
+
+// NewFreshSkipper creates a skipper over a cache, or nil if none is given
+func NewFreshSkipper(c *cache) *freshSkipper {
+	if c == nil {
+		return nil
+	}
+
+	return &freshSkipper{cache: c}
+}
+
+// Skip reports whether a URL is still fresh and counts it if so
+func (f *freshSkipper) Skip(url string) bool {
+	if f == nil || !f.cache.Fresh(url) {
+		return false
+	}
+
+	f.skipped.Add(1)
+	return true
+}
+
+// Print prints the number of URLs skipped as fresh
+func (f *freshSkipper) Print() {
+	if f == nil {
+		return
+	}
+
+	fmt.Fprintf(logOutput, "Skipped fresh: %v\n", f.skipped.Load())
+}
+
+func positive(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
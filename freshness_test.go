@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFreshnessLifetime(t *testing.T) {
+	date := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header   http.Header
+		lifetime time.Duration
+		ok       bool
+	}{
+		{http.Header{}, 0, false},
+		{http.Header{"Cache-Control": {"max-age=60"}}, time.Minute, true},
+		{http.Header{"Cache-Control": {`max-age="60"`}}, time.Minute, true},
+		{http.Header{"Cache-Control": {"max-age=60"}, "Age": {"20"}}, 40 * time.Second, true},
+		{http.Header{"Cache-Control": {"max-age=60"}, "Age": {"90"}}, 0, true},
+		{http.Header{"Cache-Control": {"max-age=60, no-store"}}, 0, true},
+		{http.Header{"Cache-Control": {"max-age=60", "no-cache"}}, 0, true},
+		{http.Header{"Cache-Control": {"No-Cache"}}, 0, true},
+		{http.Header{"Cache-Control": {"max-age=60, s-maxage=300"}}, 5 * time.Minute, true},
+		{http.Header{"Cache-Control": {"s-maxage=300, max-age=60"}}, 5 * time.Minute, true},
+		{http.Header{"Cache-Control": {"max-age=soon"}}, 0, true},
+		{http.Header{"Cache-Control": {"public"}}, 0, false},
+		{http.Header{
+			"Date":    {date.Format(http.TimeFormat)},
+			"Expires": {date.Add(time.Hour).Format(http.TimeFormat)},
+		}, time.Hour, true},
+		{http.Header{
+			"Cache-Control": {"max-age=60"},
+			"Date":          {date.Format(http.TimeFormat)},
+			"Expires":       {date.Add(time.Hour).Format(http.TimeFormat)},
+		}, time.Minute, true},
+		{http.Header{"Expires": {"0"}}, 0, true},
+	}
+
+	for _, test := range tests {
+		lifetime, ok := FreshnessLifetime(test.header)
+		if lifetime != test.lifetime || ok != test.ok {
+			t.Errorf("FreshnessLifetime(%v) = %v, %v, want %v, %v", test.header, lifetime, ok, test.lifetime, test.ok)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ---------- Frontier ----------
+
+type frontierMessage struct {
+	URL      string `json:"url"`
+	Depth    int    `json:"depth"`
+	External int    `json:"external,omitempty"`
+}
+
+// kafkaFrontier keeps the sites to crawl in a Kafka topic. Messages are keyed
+// by host so every URL of a host goes to the same partition, and so to the
+// same consumer, which keeps the visited check of each consumer complete.
+// The keys of crawled URLs are appended to a visited file, so a restarted
+// crawler skips them.
+type kafkaFrontier struct {
+	ctx     context.Context
+	writer  *kafka.Writer
+	reader  *kafka.Reader
+	mutex   sync.Mutex
+	pending map[int][]*frontierOffset
+	visited map[string]bool
+	log     *os.File
+}
+
+// frontierOffset is a message handed to the crawler, committed once it and
+// every message before it on its partition are done
+type frontierOffset struct {
+	msg  kafka.Message
+	done bool
+}
+
+// NewKafkaFrontier connects to a frontier given as kafka://broker,broker/topic,
+// loading and recording crawled URLs in visitedPath, which defaults to
+// <topic>-visited.txt
+func NewKafkaFrontier(uri string, group string, visitedPath string) (*kafkaFrontier, error) {
+	u, err := neturl.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	topic := strings.Trim(u.Path, "/")
+	if u.Scheme != "kafka" || len(u.Host) == 0 || len(topic) == 0 {
+		return nil, fmt.Errorf("invalid frontier, expected kafka://broker/topic: %v", uri)
+	}
+	brokers := strings.Split(u.Host, ",")
+
+	if len(visitedPath) == 0 {
+		visitedPath = topic + "-visited.txt"
+	}
+	visited, log, err := OpenVisitedLog(visitedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: brokers,
+		GroupID: group,
+		Topic:   topic,
+	})
+
+	return &kafkaFrontier{
+		ctx:     context.Background(),
+		writer:  writer,
+		reader:  reader,
+		pending: map[int][]*frontierOffset{},
+		visited: visited,
+		log:     log,
+	}, nil
+}
+
+// OpenVisitedLog reads the URL keys of a visited file, one per line, and
+// opens it for appending
+func OpenVisitedLog(path string) (map[string]bool, *os.File, error) {
+	log, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	visited := map[string]bool{}
+	scanner := bufio.NewScanner(log)
+	for scanner.Scan() {
+		if key := strings.TrimSpace(scanner.Text()); len(key) != 0 {
+			visited[key] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Close()
+		return nil, nil, err
+	}
+
+	return visited, log, nil
+}
+
+// Visited reports whether a URL was crawled from the frontier before
+func (f *kafkaFrontier) Visited(url string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.visited[URLKey(url)]
+}
+
+// Push produces sites that were not crawled before to the frontier topic
+func (f *kafkaFrontier) Push(sites []site) error {
+	var msgs []kafka.Message
+	for _, s := range sites {
+		if f.Visited(s.url) {
+			continue
+		}
+		value, err := json.Marshal(frontierMessage{s.url, s.depth, s.external})
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, kafka.Message{Key: []byte(hostOf(s.url)), Value: value})
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return f.writer.WriteMessages(f.ctx, msgs...)
+}
+
+// Consume sends the sites of the frontier topic to the sites handler until
+// the frontier is closed. Offsets are committed once sites are crawled, so
+// sites being crawled when the process stops are crawled again on restart.
+func (f *kafkaFrontier) Consume(verbose bool) {
+	for {
+		msg, err := f.reader.FetchMessage(f.ctx)
+		if err != nil {
+			if verbose {
+				fmt.Fprintf(logOutput, "Error on frontier: %v\n", err)
+			}
+			DecreaseSitesLeft()
+			return
+		}
+
+		offset := f.fetched(msg)
+		var m frontierMessage
+		if err := json.Unmarshal(msg.Value, &m); err != nil || len(m.URL) == 0 {
+			if verbose {
+				fmt.Fprintf(logOutput, "Invalid frontier message: %s\n", msg.Value)
+			}
+			f.finish(offset, "")
+			continue
+		}
+		if f.Visited(m.URL) {
+			if verbose {
+				fmt.Fprintf(logOutput, "Already visited %v\n", m.URL)
+			}
+			f.finish(offset, "")
+			continue
+		}
+
+		key := URLKey(m.URL)
+		IncreaseSitesLeft()
+		sites <- site{url: m.URL, depth: m.Depth, external: m.External, finish: func(crawled bool) {
+			if crawled {
+				f.finish(offset, key)
+			} else {
+				f.finish(offset, "")
+			}
+		}}
+	}
+}
+
+// fetched tracks a message until its site is done
+func (f *kafkaFrontier) fetched(msg kafka.Message) *frontierOffset {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	offset := &frontierOffset{msg: msg}
+	f.pending[msg.Partition] = append(f.pending[msg.Partition], offset)
+
+	return offset
+}
+
+// finish records the key of a crawled URL, if any, and commits the offsets
+// of the partition of a message up to the first message not yet done
+func (f *kafkaFrontier) finish(offset *frontierOffset, key string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(key) != 0 && !f.visited[key] {
+		f.visited[key] = true
+		if _, err := fmt.Fprintln(f.log, key); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on frontier: %v\n", err)
+		}
+	}
+
+	offset.done = true
+	partition := offset.msg.Partition
+	queue := f.pending[partition]
+	var last *kafka.Message
+	for len(queue) != 0 && queue[0].done {
+		last = &queue[0].msg
+		queue = queue[1:]
+	}
+	f.pending[partition] = queue
+
+	if last != nil {
+		if err := f.reader.CommitMessages(f.ctx, *last); err != nil {
+			fmt.Fprintf(os.Stderr, "Error on frontier: %v\n", err)
+		}
+	}
+}
+
+// Close closes the frontier connections and the visited file
+func (f *kafkaFrontier) Close() error {
+	f.reader.Close()
+	f.log.Close()
+	return f.writer.Close()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestVisitedLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "visited.txt")
+
+	visited, log, err := OpenVisitedLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(visited) != 0 {
+		t.Errorf("visited = %v, want empty", visited)
+	}
+	fmt.Fprintln(log, URLKey("https://example.com/a"))
+	fmt.Fprintln(log, URLKey("https://example.com/b"))
+	log.Close()
+
+	visited, log, err = OpenVisitedLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	for _, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		if !visited[URLKey(url)] {
+			t.Errorf("visited[%v] = false, want true after reopening", url)
+		}
+	}
+	if visited[URLKey("https://example.com/c")] {
+		t.Errorf("visited[https://example.com/c] = true, want false")
+	}
+}
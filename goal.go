@@ -0,0 +1,340 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ---------- Goal ----------
+
+// goal is a condition ending the crawl once met, written in a small
+// expression language:
+//
+//	found("text")      a page text or title contains text, ignoring case and
+//	                   how whitespace is broken
+//	url("pattern")     a page URL matches a pattern where * matches anything
+//	pages, errors      the number of pages and failed pages so far
+//	elapsed            the seconds since the crawl started, e.g. elapsed > 10m
+//
+// Numbers compare with < <= > >= == !=, and conditions combine with and, or,
+// not and parentheses, e.g. found("login") or pages > 1000.
+type goal struct {
+	source string
+	root   goalNode
+	checks []*goalCheck
+	pages  int
+	errors int
+	start  time.Time
+}
+
+type goalNode interface {
+	value(g *goal) float64
+}
+
+type goalNumber float64
+
+func (n goalNumber) value(g *goal) float64 { return float64(n) }
+
+type goalVariable string
+
+func (v goalVariable) value(g *goal) float64 {
+	switch v {
+	case "pages":
+		return float64(g.pages)
+	case "errors":
+		return float64(g.errors)
+	}
+
+	return time.Since(g.start).Seconds()
+}
+
+// goalCheck is a function of the pages seen, true once a page matched it
+type goalCheck struct {
+	match func(res result) bool
+	met   bool
+}
+
+func (c *goalCheck) value(g *goal) float64 { return truth(c.met) }
+
+type goalOp struct {
+	op    string
+	left  goalNode
+	right goalNode
+}
+
+func (o goalOp) value(g *goal) float64 {
+	switch o.op {
+	case "or":
+		return truth(o.left.value(g) != 0 || o.right.value(g) != 0)
+	case "and":
+		return truth(o.left.value(g) != 0 && o.right.value(g) != 0)
+	case "not":
+		return truth(o.left.value(g) == 0)
+	}
+
+	a, b := o.left.value(g), o.right.value(g)
+	switch o.op {
+	case "<":
+		return truth(a < b)
+	case "<=":
+		return truth(a <= b)
+	case ">":
+		return truth(a > b)
+	case ">=":
+		return truth(a >= b)
+	case "==":
+		return truth(a == b)
+	}
+
+	return truth(a != b)
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// ParseGoal parses a goal expression, or returns nil for an empty one
+func ParseGoal(source string) (*goal, error) {
+	if len(strings.TrimSpace(source)) == 0 {
+		return nil, nil
+	}
+
+	tokens, err := goalTokens(source)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &goal{source: source, start: time.Now()}
+	p := goalParser{tokens: tokens, goal: g}
+	root, logical, err := p.or()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %v", p.tokens[p.pos])
+	}
+	if err == nil && !logical {
+		err = fmt.Errorf("goal is a number, not a condition")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid goal %q: %v", source, err)
+	}
+	g.root = root
+
+	return g, nil
+}
+
+// Observe counts a page and checks it against the functions of the goal
+func (g *goal) Observe(res result) {
+	if g == nil {
+		return
+	}
+
+	g.pages++
+	if res.errClass != errorNone {
+		g.errors++
+	}
+	for _, check := range g.checks {
+		check.met = check.met || check.match(res)
+	}
+}
+
+// Met reports whether the goal is met
+func (g *goal) Met() bool {
+	return g != nil && g.root.value(g) != 0
+}
+
+// goalTokens splits a goal into identifiers, numbers with an optional
+// duration unit, quoted strings, operators and parentheses
+func goalTokens(source string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.ContainsRune("<>=!", c):
+			j := i + 1
+			if j < len(source) && source[j] == '=' {
+				j++
+			}
+			tokens = append(tokens, source[i:j])
+			i = j
+		case c == '"':
+			j := i + 1
+			for j < len(source) && source[j] != '"' {
+				if source[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(source) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, source[i:j+1])
+			i = j + 1
+		case unicode.IsLetter(c) || unicode.IsDigit(c) || c == '.' || c == '_':
+			j := i
+			for j < len(source) && (unicode.IsLetter(rune(source[j])) || unicode.IsDigit(rune(source[j])) || source[j] == '.' || source[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, source[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+
+	return tokens, nil
+}
+
+type goalParser struct {
+	tokens []string
+	pos    int
+	goal   *goal
+}
+
+func (p *goalParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+func (p *goalParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+// or parses conditions joined by or, returning whether the result is a
+// condition rather than a number
+func (p *goalParser) or() (goalNode, bool, error) {
+	return p.binary("or", p.and)
+}
+
+func (p *goalParser) and() (goalNode, bool, error) {
+	return p.binary("and", p.not)
+}
+
+func (p *goalParser) binary(op string, operand func() (goalNode, bool, error)) (goalNode, bool, error) {
+	left, logical, err := operand()
+	for err == nil && p.peek() == op {
+		p.next()
+		var right goalNode
+		var rightLogical bool
+		right, rightLogical, err = operand()
+		if err == nil && (!logical || !rightLogical) {
+			err = fmt.Errorf("%v needs conditions on both sides", op)
+		}
+		left, logical = goalOp{op: op, left: left, right: right}, true
+	}
+
+	return left, logical, err
+}
+
+func (p *goalParser) not() (goalNode, bool, error) {
+	if p.peek() != "not" {
+		return p.comparison()
+	}
+
+	p.next()
+	operand, logical, err := p.not()
+	if err == nil && !logical {
+		err = fmt.Errorf("not needs a condition")
+	}
+
+	return goalOp{op: "not", left: operand}, true, err
+}
+
+func (p *goalParser) comparison() (goalNode, bool, error) {
+	left, logical, err := p.primary()
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch op := p.peek(); op {
+	case "<", "<=", ">", ">=", "==", "!=":
+		p.next()
+		right, rightLogical, err := p.primary()
+		if err == nil && (logical || rightLogical) {
+			err = fmt.Errorf("%v needs numbers on both sides", op)
+		}
+		return goalOp{op: op, left: left, right: right}, true, err
+	}
+
+	return left, logical, nil
+}
+
+func (p *goalParser) primary() (goalNode, bool, error) {
+	token := p.next()
+	switch {
+	case len(token) == 0:
+		return nil, false, fmt.Errorf("unexpected end")
+	case token == "(":
+		node, logical, err := p.or()
+		if err == nil && p.next() != ")" {
+			err = fmt.Errorf("missing )")
+		}
+		return node, logical, err
+	case token == "pages" || token == "errors" || token == "elapsed":
+		return goalVariable(token), false, nil
+	case token == "found" || token == "url":
+		return p.function(token)
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		if n, err := strconv.ParseFloat(token, 64); err == nil {
+			return goalNumber(n), false, nil
+		}
+		if d, err := time.ParseDuration(token); err == nil {
+			return goalNumber(d.Seconds()), false, nil
+		}
+		return nil, false, fmt.Errorf("invalid number %v", token)
+	}
+
+	return nil, false, fmt.Errorf("unknown name %v", token)
+}
+
+func (p *goalParser) function(name string) (goalNode, bool, error) {
+	if p.next() != "(" {
+		return nil, false, fmt.Errorf("%v needs (", name)
+	}
+	arg, err := strconv.Unquote(p.next())
+	if err != nil {
+		return nil, false, fmt.Errorf("%v needs a quoted string", name)
+	}
+	if p.next() != ")" {
+		return nil, false, fmt.Errorf("missing ) after %v", name)
+	}
+
+	check := &goalCheck{}
+	switch name {
+	case "found":
+		// Whitespace is collapsed so text matches across lines
+		text := strings.ToLower(strings.Join(strings.Fields(arg), " "))
+		check.match = func(res result) bool {
+			page := strings.ToLower(strings.Join(strings.Fields(res.title+" "+res.text), " "))
+			return strings.Contains(page, text)
+		}
+	case "url":
+		quoted := strings.Split(arg, "*")
+		for i := range quoted {
+			quoted[i] = regexp.QuoteMeta(quoted[i])
+		}
+		pattern := regexp.MustCompile("^" + strings.Join(quoted, ".*") + "$")
+		check.match = func(res result) bool {
+			return res.errClass == errorNone && pattern.MatchString(res.url)
+		}
+	}
+	p.goal.checks = append(p.goal.checks, check)
+
+	return check, true, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestParseGoal(t *testing.T) {
+	tests := []struct {
+		source string
+		ok     bool
+	}{
+		{`found("login")`, true},
+		{`url("*/checkout*")`, true},
+		{`pages > 1000`, true},
+		{`elapsed >= 10m`, true},
+		{`found("login") or pages > 1000`, true},
+		{`not (errors > 5 and pages < 10)`, true},
+		{`pages`, false},
+		{`pages > found("x")`, false},
+		{`found("x") and 5`, false},
+		{`found(login)`, false},
+		{`found("login"`, false},
+		{`found("login) or pages > 1`, false},
+		{`pages > 10 )`, false},
+		{`size > 10`, false},
+		{`pages > 10x`, false},
+		{`pages # 10`, false},
+	}
+
+	for _, test := range tests {
+		g, err := ParseGoal(test.source)
+		if (err == nil) != test.ok || (g != nil) != test.ok {
+			t.Errorf("ParseGoal(%q) = %v, %v, want ok %v", test.source, g, err, test.ok)
+		}
+	}
+
+	if g, err := ParseGoal("  "); g != nil || err != nil {
+		t.Errorf("ParseGoal of empty goal = %v, %v, want nil, nil", g, err)
+	}
+}
+
+func TestGoalMet(t *testing.T) {
+	pages := []result{
+		{url: "http://example.com/", title: "Home", text: "Welcome"},
+		{url: "http://example.com/missing", errClass: errorHTTP4xx},
+		{url: "http://example.com/login", title: "Sign in", text: "Enter your\n  password here"},
+	}
+
+	tests := []struct {
+		source string
+		met    []bool
+	}{
+		{`pages >= 2`, []bool{false, true, true}},
+		{`errors > 0`, []bool{false, true, true}},
+		{`found("your password")`, []bool{false, false, true}},
+		{`found("SIGN IN")`, []bool{false, false, true}},
+		{`url("*/missing")`, []bool{false, false, false}},
+		{`url("*/login") and not errors > 1`, []bool{false, false, true}},
+		{`found("welcome") or pages > 100`, []bool{true, true, true}},
+	}
+
+	for _, test := range tests {
+		g, err := ParseGoal(test.source)
+		if err != nil {
+			t.Fatalf("ParseGoal(%q): %v", test.source, err)
+		}
+		for i, res := range pages {
+			g.Observe(res)
+			if met := g.Met(); met != test.met[i] {
+				t.Errorf("goal %q after page %v met = %v, want %v", test.source, i+1, met, test.met[i])
+			}
+		}
+	}
+}
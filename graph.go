@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ---------- Graph ----------
+
+type pageRank struct {
+	url  string
+	rank float64
+	in   int
+	out  int
+}
+
+// linkGraph is the internal link graph of a crawl. normalize, if set,
+// rewrites links to the URLs their pages were crawled as.
+type linkGraph struct {
+	urls      map[string]string
+	links     map[string][]string
+	normalize func(string) string
+}
+
+// Add records a crawled page and its outgoing links
+func (g *linkGraph) Add(res result) {
+	if g.urls == nil {
+		g.urls = map[string]string{}
+		g.links = map[string][]string{}
+	}
+
+	if res.errClass != errorNone {
+		return
+	}
+
+	key := URLKey(res.url)
+	g.urls[key] = res.url
+	for _, link := range res.links {
+		if g.normalize != nil {
+			link = g.normalize(link)
+		}
+		g.links[key] = append(g.links[key], URLKey(link))
+	}
+}
+
+// Edges returns the deduplicated internal links of each page, leaving out
+// self links and links to pages that were not crawled
+func (g *linkGraph) Edges() map[string][]string {
+	edges := map[string][]string{}
+	for from, links := range g.links {
+		seen := map[string]bool{}
+		for _, to := range links {
+			if _, ok := g.urls[to]; ok && to != from && !seen[to] {
+				seen[to] = true
+				edges[from] = append(edges[from], to)
+			}
+		}
+	}
+
+	return edges
+}
+
+// PageRank computes PageRank, in-degree and out-degree over the internal
+// link graph, sorted by rank
+func (g *linkGraph) PageRank(damping float64, iterations int) []pageRank {
+	edges := g.Edges()
+	n := float64(len(g.urls))
+	if n == 0 {
+		return nil
+	}
+
+	in := map[string]int{}
+	for _, tos := range edges {
+		for _, to := range tos {
+			in[to]++
+		}
+	}
+
+	rank := map[string]float64{}
+	for key := range g.urls {
+		rank[key] = 1 / n
+	}
+
+	for i := 0; i < iterations; i++ {
+		dangling := 0.0
+		for key := range g.urls {
+			if len(edges[key]) == 0 {
+				dangling += rank[key]
+			}
+		}
+
+		next := map[string]float64{}
+		for key := range g.urls {
+			next[key] = (1-damping)/n + damping*dangling/n
+		}
+		for from, tos := range edges {
+			share := damping * rank[from] / float64(len(tos))
+			for _, to := range tos {
+				next[to] += share
+			}
+		}
+
+		delta := 0.0
+		for key := range g.urls {
+			delta += math.Abs(next[key] - rank[key])
+		}
+		rank = next
+		if delta < 1e-9 {
+			break
+		}
+	}
+
+	var ranks []pageRank
+	for key, url := range g.urls {
+		ranks = append(ranks, pageRank{url, rank[key], in[key], len(edges[key])})
+	}
+
+	sort.Slice(ranks, func(i, j int) bool {
+		if ranks[i].rank != ranks[j].rank {
+			return ranks[i].rank > ranks[j].rank
+		}
+		return ranks[i].url < ranks[j].url
+	})
+
+	return ranks
+}
+
+// PrintPageRank prints the PageRank and degrees of every crawled page
+func (g *linkGraph) PrintPageRank() {
+	for _, r := range g.PageRank(0.85, 100) {
+		fmt.Fprintf(logOutput, "Rank: %v %.6f in=%v out=%v\n", r.url, r.rank, r.in, r.out)
+	}
+}
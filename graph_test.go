@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestLinkGraphNormalizesLinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		normalize func(string) string
+		in        int
+	}{
+		{"raw links", nil, 0},
+		{"normalized links", StripTracking, 1},
+	}
+
+	for _, test := range tests {
+		g := linkGraph{normalize: test.normalize}
+		g.Add(result{url: "http://example.com/", links: []string{"http://example.com/b?utm_source=x"}})
+		g.Add(result{url: "http://example.com/b"})
+
+		for _, r := range g.PageRank(0.85, 100) {
+			if r.url == "http://example.com/b" && r.in != test.in {
+				t.Errorf("%v: in-degree of /b = %v, want %v", test.name, r.in, test.in)
+			}
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ---------- HAR ----------
+
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Pages   []harPage  `json:"pages"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harPage struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	PageTimings     harPageTimings `json:"pageTimings"`
+}
+
+type harPageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harEntry struct {
+	Pageref         string      `json:"pageref"`
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+// harTrace times the phases of one request
+type harTrace struct {
+	start, dnsStart, dnsDone, connectStart, connectDone time.Time
+	tlsStart, tlsDone, wrote, firstByte, done           time.Time
+	entry                                               harEntry
+}
+
+// harRecorder collects the requests sent while fetching a page
+type harRecorder struct {
+	mutex  sync.Mutex
+	traces []*harTrace
+}
+
+type harRecorderKey struct{}
+
+// WithHARRecorder returns a context recording the requests sent with it
+func WithHARRecorder(ctx context.Context, rec *harRecorder) context.Context {
+	return context.WithValue(ctx, harRecorderKey{}, rec)
+}
+
+type harTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip sends a request, timing it if its context has a HAR recorder
+func (t harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec, ok := req.Context().Value(harRecorderKey{}).(*harRecorder)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	tr := &harTrace{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { tr.dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { tr.dnsDone = time.Now() },
+		ConnectStart:         func(string, string) { tr.connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { tr.connectDone = time.Now() },
+		TLSHandshakeStart:    func() { tr.tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { tr.tlsDone = time.Now() },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { tr.wrote = time.Now() },
+		GotFirstResponseByte: func() { tr.firstByte = time.Now() },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	tr.entry.Request = harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	for key, values := range req.URL.Query() {
+		for _, value := range values {
+			tr.entry.Request.QueryString = append(tr.entry.Request.QueryString, harNameValue{key, value})
+		}
+	}
+
+	rec.mutex.Lock()
+	rec.traces = append(rec.traces, tr)
+	rec.mutex.Unlock()
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		tr.done = time.Now()
+		return resp, err
+	}
+
+	tr.entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(resp.Header),
+		Content:     harContent{MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	resp.Body = &harBody{ReadCloser: resp.Body, trace: tr}
+
+	return resp, nil
+}
+
+type harBody struct {
+	io.ReadCloser
+	trace *harTrace
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.trace.entry.Response.BodySize += int64(n)
+	b.trace.entry.Response.Content.Size += int64(n)
+	if err != nil {
+		b.trace.done = time.Now()
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	if b.trace.done.IsZero() {
+		b.trace.done = time.Now()
+	}
+	return b.ReadCloser.Close()
+}
+
+// RecordHAR makes a client time the requests of contexts with a HAR recorder
+func RecordHAR(client *http.Client) {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	client.Transport = harTransport{base}
+}
+
+// WriteHAR writes the requests recorded for a page to a HAR file in a
+// directory, named by the hash of the page URL
+func WriteHAR(dir string, url string, rec *harRecorder) error {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	if len(rec.traces) == 0 {
+		return nil
+	}
+
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator = harCreator{"GoCrawler", "1.0"}
+	start := rec.traces[0].start
+	end := start
+	for _, tr := range rec.traces {
+		entry := tr.entry
+		entry.Pageref = "page_1"
+		entry.StartedDateTime = tr.start.UTC().Format(time.RFC3339Nano)
+		entry.Timings = tr.timings()
+		entry.Time = milliseconds(tr.start, tr.done)
+		har.Log.Entries = append(har.Log.Entries, entry)
+		if tr.done.After(end) {
+			end = tr.done
+		}
+	}
+	har.Log.Pages = []harPage{{
+		StartedDateTime: start.UTC().Format(time.RFC3339Nano),
+		ID:              "page_1",
+		Title:           url,
+		PageTimings:     harPageTimings{-1, milliseconds(start, end)},
+	}}
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return ioutil.WriteFile(filepath.Join(dir, hex.EncodeToString(sum[:8])+".har"), data, 0644)
+}
+
+// timings returns the HAR timings of a request, -1 for phases that did not
+// happen such as DNS and connecting on a reused connection
+func (tr *harTrace) timings() harTimings {
+	t := harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	sendStart := tr.start
+	if !tr.dnsDone.IsZero() {
+		t.DNS = milliseconds(tr.dnsStart, tr.dnsDone)
+	}
+	if !tr.connectDone.IsZero() {
+		t.Connect = milliseconds(tr.connectStart, tr.connectDone)
+		sendStart = tr.connectDone
+	}
+	if !tr.tlsDone.IsZero() {
+		t.SSL = milliseconds(tr.tlsStart, tr.tlsDone)
+		t.Connect += t.SSL
+		sendStart = tr.tlsDone
+	}
+	if !tr.wrote.IsZero() {
+		t.Send = milliseconds(sendStart, tr.wrote)
+	}
+	if !tr.firstByte.IsZero() && !tr.wrote.IsZero() {
+		t.Wait = milliseconds(tr.wrote, tr.firstByte)
+	}
+	if !tr.done.IsZero() && !tr.firstByte.IsZero() {
+		t.Receive = milliseconds(tr.firstByte, tr.done)
+	}
+
+	return t
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for key, values := range header {
+		for _, value := range values {
+			headers = append(headers, harNameValue{key, value})
+		}
+	}
+
+	return headers
+}
+
+func milliseconds(from time.Time, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() {
+		return 0
+	}
+
+	return float64(to.Sub(from)) / float64(time.Millisecond)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ---------- Link rot history ----------
+
+// urlHistory is the error class of a URL in each run it was crawled in,
+// oldest first
+type urlHistory struct {
+	runs    []int64
+	classes []string
+}
+
+// Broken reports whether the URL was broken in the i:th run it was crawled in
+func (h urlHistory) Broken(i int) bool {
+	return h.classes[i] != string(errorNone)
+}
+
+// Consecutive returns the number of latest runs the URL has been broken in
+func (h urlHistory) Consecutive() int {
+	n := 0
+	for i := len(h.classes) - 1; i >= 0 && h.Broken(i); i-- {
+		n++
+	}
+
+	return n
+}
+
+// Flaps returns the number of times the URL changed between working and broken
+func (h urlHistory) Flaps() int {
+	n := 0
+	for i := 1; i < len(h.classes); i++ {
+		if h.Broken(i) != h.Broken(i-1) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// LoadHistory reads the status of every URL in the latest runs of a SQLite
+// database
+func LoadHistory(db *sql.DB, runs int) (map[string]*urlHistory, int64, error) {
+	rows, err := db.Query(`
+		SELECT p.url, p.run_id, p.error FROM pages p
+		WHERE p.run_id IN (SELECT id FROM runs WHERE finished_at IS NOT NULL ORDER BY id DESC LIMIT ?)
+		ORDER BY p.run_id`, runs)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	history := map[string]*urlHistory{}
+	var latest int64
+	for rows.Next() {
+		var url, class string
+		var run int64
+		if err := rows.Scan(&url, &run, &class); err != nil {
+			return nil, 0, err
+		}
+
+		h, ok := history[url]
+		if !ok {
+			h = &urlHistory{}
+			history[url] = h
+		}
+		h.runs = append(h.runs, run)
+		h.classes = append(h.classes, class)
+		if run > latest {
+			latest = run
+		}
+	}
+
+	return history, latest, rows.Err()
+}
+
+// RunHistory prints newly broken, persistently broken and flapping URLs
+// from the runs of a SQLite output database
+func RunHistory(args []string) {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	path := flags.String("db", "crawl.db", "Set SQLite database written by -output sqlite.")
+	runs := flags.Int("runs", 10, "Set number of latest runs to consider.")
+	consecutive := flags.Int("consecutive", 3, "Set number of consecutive runs after which a URL counts as persistently broken.")
+	flaps := flags.Int("flaps", 2, "Set number of changes between working and broken after which a URL counts as flapping.")
+	flags.Parse(args)
+
+	db, err := sql.Open("sqlite", *path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	history, latest, err := LoadHistory(db, *runs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var urls []string
+	for url := range history {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	for _, url := range urls {
+		h := history[url]
+		last := len(h.classes) - 1
+		if h.runs[last] != latest || !h.Broken(last) {
+			continue
+		}
+
+		if last > 0 && !h.Broken(last-1) {
+			fmt.Fprintf(logOutput, "Newly broken: %v (%v)\n", url, h.classes[last])
+		}
+		if n := h.Consecutive(); n >= *consecutive {
+			fmt.Fprintf(logOutput, "Broken: %v (%v, %v runs)\n", url, h.classes[last], n)
+		}
+	}
+
+	for _, url := range urls {
+		if n := history[url].Flaps(); n >= *flaps {
+			fmt.Fprintf(logOutput, "Flapping: %v (%v changes)\n", url, n)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ---------- Canonical hosts ----------
+
+// canonicalHosts learns which origins redirect to another scheme or to the
+// www or apex form of their host, such as http://example.com redirecting to
+// https://www.example.com, and rewrites queued URLs to the canonical origin
+type canonicalHosts struct {
+	mutex   sync.Mutex
+	origins map[string]string
+	reached map[string]bool
+	verbose bool
+}
+
+// NewCanonicalHosts creates canonical hosts without aliases learned
+func NewCanonicalHosts(verbose bool) *canonicalHosts {
+	return &canonicalHosts{origins: map[string]string{}, reached: map[string]bool{}, verbose: verbose}
+}
+
+// originOf returns the lowercased scheme and host of a URL
+func originOf(u *url.URL) string {
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host)
+}
+
+// IsHostAlias reports whether a redirect only changes the scheme or the www
+// prefix of the host of a URL, keeping its path and query
+func IsHostAlias(from string, to string) bool {
+	f, err := url.Parse(from)
+	if err != nil {
+		return false
+	}
+	t, err := url.Parse(to)
+	if err != nil || originOf(f) == originOf(t) {
+		return false
+	}
+
+	fromHost := strings.TrimPrefix(strings.ToLower(f.Hostname()), "www.")
+	toHost := strings.TrimPrefix(strings.ToLower(t.Hostname()), "www.")
+	if fromHost != toHost {
+		return false
+	}
+
+	f.Scheme, f.Host = t.Scheme, t.Host
+	return URLKey(f.String()) == URLKey(t.String())
+}
+
+// Learn records the origin of a redirect as an alias of the origin it
+// redirects to, if the redirect only changes scheme or www prefix. The
+// target is remembered as reached, since it was fetched by following the
+// redirect.
+func (c *canonicalHosts) Learn(from string, to string) {
+	if c == nil || !IsHostAlias(from, to) {
+		return
+	}
+
+	f, _ := url.Parse(from)
+	t, _ := url.Parse(to)
+	alias, canonical := originOf(f), originOf(t)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.reached[URLKey(to)] = true
+	if next, ok := c.origins[canonical]; ok {
+		canonical = next
+	}
+	if canonical == alias || c.origins[alias] == canonical {
+		return
+	}
+
+	c.origins[alias] = canonical
+	for origin, target := range c.origins {
+		if target == alias {
+			c.origins[origin] = canonical
+		}
+	}
+	if c.verbose {
+		fmt.Fprintf(logOutput, "Canonical host learned: %v -> %v\n", alias, canonical)
+	}
+}
+
+// Rewrite returns a URL with its origin replaced by the canonical origin,
+// if the origin is a known alias
+func (c *canonicalHosts) Rewrite(link string) string {
+	if c == nil {
+		return link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	c.mutex.Lock()
+	canonical, ok := c.origins[originOf(u)]
+	c.mutex.Unlock()
+	if !ok {
+		return link
+	}
+
+	t, err := url.Parse(canonical)
+	if err != nil {
+		return link
+	}
+	u.Scheme, u.Host = t.Scheme, t.Host
+
+	return u.String()
+}
+
+// Reached reports whether a URL was already fetched as the target of a
+// host alias redirect
+func (c *canonicalHosts) Reached(link string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.reached[URLKey(link)]
+}
+
+// Print prints the host aliases learned during the crawl
+func (c *canonicalHosts) Print() {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	aliases := make([]string, 0, len(c.origins))
+	for alias := range c.origins {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		fmt.Fprintf(logOutput, "Canonical host: %v -> %v\n", alias, c.origins[alias])
+	}
+}
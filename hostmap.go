@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ---------- Host map ----------
+
+// hostMap maps host names to the addresses they are dialed at, keeping the
+// URL, and so the Host header and TLS server name, unchanged
+type hostMap map[string]string
+
+// String returns the mappings as a comma separated list
+func (m hostMap) String() string {
+	var pairs []string
+	for host, addr := range m {
+		pairs = append(pairs, host+"="+addr)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// Set adds comma separated host=address mappings
+func (m hostMap) Set(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return fmt.Errorf("invalid host mapping: %v", pair)
+		}
+		m[strings.ToLower(parts[0])] = parts[1]
+	}
+
+	return nil
+}
+
+// Dial returns the address to dial for an address, mapping its host. A
+// mapped address without a port keeps the original port.
+func (m hostMap) Dial(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	mapped, ok := m[strings.ToLower(host)]
+	if !ok {
+		return addr
+	}
+	if _, _, err := net.SplitHostPort(mapped); err == nil {
+		return mapped
+	}
+
+	return net.JoinHostPort(strings.Trim(mapped, "[]"), port)
+}
+
+// Apply makes a client dial mapped hosts at their addresses. Proxies are
+// bypassed since a proxy would resolve the host itself.
+func (m hostMap) Apply(client *http.Client) {
+	if len(m) == 0 {
+		return
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{}
+	transport.DialContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, m.Dial(addr))
+	}
+	transport.Proxy = nil
+	client.Transport = transport
+}
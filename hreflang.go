@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ---------- Hreflang ----------
+
+type alternate struct {
+	lang string
+	url  string
+}
+
+type hreflangMismatch struct {
+	page      string
+	alternate alternate
+}
+
+type hreflangChecker map[string][]alternate
+
+// Add records the hreflang alternates of a crawled page
+func (c hreflangChecker) Add(res result) {
+	if res.errClass == errorNone {
+		c[URLKey(res.url)] = res.alternates
+	}
+}
+
+// Mismatches returns alternates that were crawled but do not link back to
+// the page that references them
+func (c hreflangChecker) Mismatches() []hreflangMismatch {
+	var mismatches []hreflangMismatch
+	for page, alternates := range c {
+		for _, alt := range alternates {
+			key := URLKey(alt.url)
+			if key == page {
+				continue
+			}
+
+			back, ok := c[key]
+			if !ok {
+				continue
+			}
+
+			reciprocal := false
+			for _, b := range back {
+				if URLKey(b.url) == page {
+					reciprocal = true
+					break
+				}
+			}
+
+			if !reciprocal {
+				mismatches = append(mismatches, hreflangMismatch{page, alt})
+			}
+		}
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].page != mismatches[j].page {
+			return mismatches[i].page < mismatches[j].page
+		}
+		return mismatches[i].alternate.url < mismatches[j].alternate.url
+	})
+
+	return mismatches
+}
+
+// Print prints hreflang alternates without a return link
+func (c hreflangChecker) Print() {
+	for _, m := range c.Mismatches() {
+		fmt.Fprintf(logOutput, "Hreflang: %v -> %v (%v) has no return link\n", m.page, m.alternate.url, m.alternate.lang)
+	}
+}
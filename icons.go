@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// ---------- Icons ----------
+
+type hostIcons struct {
+	icons     map[string]bool
+	manifests map[string]bool
+}
+
+type iconChecker map[string]*hostIcons
+
+// Add records the icons and manifest declared by a page
+func (c iconChecker) Add(res result) {
+	if res.errClass != errorNone {
+		return
+	}
+
+	host := hostOf(res.url)
+	h, ok := c[host]
+	if !ok {
+		h = &hostIcons{map[string]bool{}, map[string]bool{}}
+		c[host] = h
+		h.icons[FixLink(res.url, "/favicon.ico")] = false
+	}
+
+	for _, icon := range res.icons {
+		h.icons[icon] = true
+	}
+	if len(res.manifest) != 0 {
+		h.manifests[res.manifest] = true
+	}
+}
+
+// Print verifies and prints the icons and manifests of every host. The
+// default /favicon.ico is only reported when a host declares no icons.
+func (c iconChecker) Print(client *http.Client) {
+	var hosts []string
+	for host := range c {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		h := c[host]
+		declared := false
+		for _, ok := range h.icons {
+			declared = declared || ok
+		}
+
+		for _, icon := range sortedKeys(h.icons) {
+			if declared && !h.icons[icon] {
+				continue
+			}
+			fmt.Fprintf(logOutput, "Icon: %v %v (%v)\n", host, icon, CheckResolves(client, icon))
+		}
+		for _, manifest := range sortedKeys(h.manifests) {
+			fmt.Fprintf(logOutput, "Manifest: %v %v (%v)\n", host, manifest, CheckResolves(client, manifest))
+		}
+	}
+}
+
+// CheckResolves fetches a URL and describes whether it resolved
+func CheckResolves(client *http.Client, url string) string {
+	resp, err := client.Head(url)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = client.Get(url)
+	}
+	if err != nil {
+		return string(ClassifyError(err))
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return string(ClassifyError(statusError{resp.StatusCode}))
+	}
+
+	return "ok"
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ---------- Identity ----------
+
+type identityTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	from      string
+}
+
+// RoundTrip sets the crawler identity on requests that have none
+func (t identityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if (len(t.userAgent) != 0 && len(req.Header.Get("User-Agent")) == 0) || (len(t.from) != 0 && len(req.Header.Get("From")) == 0) {
+		req = req.Clone(req.Context())
+		if len(t.userAgent) != 0 && len(req.Header.Get("User-Agent")) == 0 {
+			req.Header.Set("User-Agent", t.userAgent)
+		}
+		if len(t.from) != 0 && len(req.Header.Get("From")) == 0 {
+			req.Header.Set("From", t.from)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// Identify makes every request of a client carry a User-Agent and From
+// header, unless a header rule of the config sets them
+func Identify(client *http.Client, userAgent string, from string) {
+	if len(userAgent) == 0 && len(from) == 0 {
+		return
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = identityTransport{base, userAgent, from}
+}
+
+// IdentityWarning describes what is missing for a crawl of a site to
+// identify itself, or returns an empty string. Local sites are not warned
+// about since they are the user's own.
+func IdentityWarning(seed string, userAgent string, rules []headerRule) string {
+	host := hostOf(seed)
+	if IsLocalHost(host) {
+		return ""
+	}
+
+	if len(userAgent) == 0 {
+		for _, rule := range rules {
+			if len(rule.UserAgent) != 0 {
+				return ""
+			}
+		}
+		return fmt.Sprintf("Warning: crawling %v without identifying the crawler, set -identify \"Name/1.0 (+https://example.com/bot)\"", host)
+	}
+
+	if !strings.Contains(userAgent, "http") && !strings.Contains(userAgent, "@") {
+		return fmt.Sprintf("Warning: -identify %q has no contact URL or address, e.g. (+https://example.com/bot)", userAgent)
+	}
+
+	return ""
+}
+
+// IsLocalHost reports whether a host is the local machine or on a private network
+func IsLocalHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".local") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast())
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ---------- Images ----------
+
+type imageTag struct {
+	src       string
+	alt       string
+	hasAlt    bool
+	hasWidth  bool
+	hasHeight bool
+}
+
+type imageCheck struct {
+	status string
+	size   int64
+}
+
+type imageAuditor struct {
+	client  *http.Client
+	maxSize int64
+	checked map[string]imageCheck
+}
+
+// NewImageAuditor creates an auditor flagging images larger than maxSize
+// bytes
+func NewImageAuditor(client *http.Client, maxSize int64) *imageAuditor {
+	return &imageAuditor{client, maxSize, map[string]imageCheck{}}
+}
+
+// Audit checks the images of a page and returns its findings
+func (a *imageAuditor) Audit(res result) []string {
+	var findings []string
+	for _, img := range res.images {
+		if !img.hasAlt {
+			findings = append(findings, fmt.Sprintf("%v: missing alt text", img.src))
+		}
+		if !img.hasWidth || !img.hasHeight {
+			findings = append(findings, fmt.Sprintf("%v: missing width or height", img.src))
+		}
+
+		check := a.check(img.src)
+		if check.status != "ok" {
+			findings = append(findings, fmt.Sprintf("%v: broken (%v)", img.src, check.status))
+		} else if a.maxSize > 0 && check.size > a.maxSize {
+			findings = append(findings, fmt.Sprintf("%v: %v bytes exceeds %v", img.src, check.size, a.maxSize))
+		}
+	}
+
+	return findings
+}
+
+// Print audits the images of a page and prints the findings
+func (a *imageAuditor) Print(res result) {
+	for _, finding := range a.Audit(res) {
+		fmt.Fprintf(logOutput, "Image: %v %v\n", res.url, finding)
+	}
+}
+
+func (a *imageAuditor) check(url string) imageCheck {
+	if check, ok := a.checked[url]; ok {
+		return check
+	}
+
+	check := a.fetch(url)
+	a.checked[url] = check
+
+	return check
+}
+
+func (a *imageAuditor) fetch(url string) imageCheck {
+	resp, err := a.client.Head(url)
+	if err == nil && resp.StatusCode < 400 && resp.ContentLength >= 0 {
+		resp.Body.Close()
+		return imageCheck{"ok", resp.ContentLength}
+	}
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	resp, err = a.client.Get(url)
+	if err != nil {
+		return imageCheck{string(ClassifyError(err)), 0}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return imageCheck{string(ClassifyError(statusError{resp.StatusCode})), 0}
+	}
+
+	size, err := io.Copy(ioutil.Discard, resp.Body)
+	if err != nil {
+		return imageCheck{string(ClassifyError(err)), 0}
+	}
+
+	return imageCheck{"ok", size}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------- Infrastructure ----------
+
+// lookupTimeout bounds each DNS lookup made to describe a host
+const lookupTimeout = 5 * time.Second
+
+type hostInfo struct {
+	ip         string
+	reverseDNS string
+	asn        string
+	asName     string
+	provider   string
+}
+
+// infraLookup resolves the IP, reverse DNS and, optionally, the autonomous
+// system of each host once, for infrastructure inventories
+type infraLookup struct {
+	mutex    sync.Mutex
+	hosts    map[string]*hostInfo
+	ready    map[string]chan bool
+	mappings hostMap
+	asn      bool
+}
+
+// NewInfraLookup creates a lookup resolving hosts as they are dialed with a
+// host map. With asn, the autonomous system of each IP is looked up through
+// the DNS of Team Cymru's IP to ASN service.
+func NewInfraLookup(mappings hostMap, asn bool) *infraLookup {
+	return &infraLookup{hosts: map[string]*hostInfo{}, ready: map[string]chan bool{}, mappings: mappings, asn: asn}
+}
+
+// providerASNs maps the autonomous systems of common CDNs and hosting
+// providers to their names
+var providerASNs = map[string]string{
+	"AS13335": "Cloudflare", "AS209242": "Cloudflare",
+	"AS16509": "Amazon", "AS14618": "Amazon",
+	"AS54113": "Fastly",
+	"AS20940": "Akamai", "AS16625": "Akamai", "AS12222": "Akamai",
+	"AS15169": "Google", "AS396982": "Google",
+	"AS8075":  "Microsoft",
+	"AS14061": "DigitalOcean",
+	"AS24940": "Hetzner",
+	"AS16276": "OVH",
+	"AS63949": "Linode",
+	"AS20473": "Vultr",
+}
+
+// providerDomains maps reverse DNS suffixes to the providers using them
+var providerDomains = map[string]string{
+	"cloudfront.net": "Amazon CloudFront", "amazonaws.com": "Amazon",
+	"akamaitechnologies.com": "Akamai", "akamaiedge.net": "Akamai",
+	"1e100.net": "Google", "googleusercontent.com": "Google",
+	"your-server.de": "Hetzner", "ovh.net": "OVH", "linodeusercontent.com": "Linode",
+	"vultrusercontent.com": "Vultr", "azure.com": "Microsoft",
+}
+
+// HeaderProvider detects the CDN or hosting provider serving a response by
+// the headers it adds
+func HeaderProvider(header http.Header) string {
+	server := strings.ToLower(header.Get("Server"))
+	via := strings.ToLower(header.Get("Via"))
+	switch {
+	case len(header.Get("Cf-Ray")) != 0 || server == "cloudflare":
+		return "Cloudflare"
+	case len(header.Get("X-Amz-Cf-Id")) != 0 || strings.Contains(via, "cloudfront"):
+		return "Amazon CloudFront"
+	case len(header.Get("X-Fastly-Request-Id")) != 0 || strings.HasPrefix(header.Get("X-Served-By"), "cache-"):
+		return "Fastly"
+	case strings.HasPrefix(server, "akamai") || len(header.Get("X-Akamai-Transformed")) != 0:
+		return "Akamai"
+	case len(header.Get("X-Azure-Ref")) != 0:
+		return "Azure Front Door"
+	case len(header.Get("X-Vercel-Id")) != 0:
+		return "Vercel"
+	case len(header.Get("X-Nf-Request-Id")) != 0:
+		return "Netlify"
+	case len(header.Get("X-Github-Request-Id")) != 0:
+		return "GitHub Pages"
+	case len(header.Get("X-Sucuri-Id")) != 0:
+		return "Sucuri"
+	case len(header.Get("X-Iinfo")) != 0:
+		return "Imperva"
+	case server == "bunnycdn" || strings.HasPrefix(server, "bunnycdn-"):
+		return "Bunny CDN"
+	case server == "google frontend" || server == "gws":
+		return "Google"
+	}
+
+	return ""
+}
+
+// Enrich sets the IP, reverse DNS, autonomous system and provider of the
+// host of a result. Headers identify the provider before the reverse DNS
+// and autonomous system do.
+func (l *infraLookup) Enrich(res *result) {
+	if l == nil {
+		return
+	}
+
+	url := res.url
+	if len(res.redirect) != 0 {
+		url = res.redirect
+	}
+	info := l.Host(hostOf(url))
+
+	res.ip = info.ip
+	res.reverseDNS = info.reverseDNS
+	res.asn = info.asn
+	res.provider = HeaderProvider(res.header)
+	if len(res.provider) == 0 {
+		res.provider = info.provider
+	}
+}
+
+// Host returns the description of a host, looking it up on first use
+func (l *infraLookup) Host(host string) hostInfo {
+	l.mutex.Lock()
+	ready, ok := l.ready[host]
+	if !ok {
+		ready = make(chan bool)
+		l.ready[host] = ready
+	}
+	l.mutex.Unlock()
+
+	if ok {
+		<-ready
+	} else {
+		info := l.lookup(host)
+		l.mutex.Lock()
+		l.hosts[host] = &info
+		l.mutex.Unlock()
+		close(ready)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return *l.hosts[host]
+}
+
+func (l *infraLookup) lookup(host string) hostInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	var info hostInfo
+	if len(host) == 0 {
+		return info
+	}
+
+	// Mapped hosts are described by the address they are dialed at
+	if dialed, _, err := net.SplitHostPort(l.mappings.Dial(net.JoinHostPort(host, "0"))); err == nil {
+		host = dialed
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return info
+		}
+		ip = addrs[0].IP
+	}
+	info.ip = ip.String()
+
+	if names, err := net.DefaultResolver.LookupAddr(ctx, info.ip); err == nil && len(names) != 0 {
+		info.reverseDNS = strings.TrimSuffix(names[0], ".")
+	}
+	for domain, provider := range providerDomains {
+		if strings.HasSuffix(info.reverseDNS, "."+domain) {
+			info.provider = provider
+		}
+	}
+
+	if l.asn && !ip.IsLoopback() && !ip.IsPrivate() {
+		info.asn, info.asName = LookupASN(ctx, ip)
+		if len(info.provider) == 0 {
+			info.provider = providerASNs[info.asn]
+		}
+		if len(info.provider) == 0 {
+			info.provider = info.asName
+		}
+	}
+
+	return info
+}
+
+// LookupASN returns the autonomous system announcing an IP, such as
+// AS13335, and its name, using the DNS of Team Cymru's IP to ASN service
+func LookupASN(ctx context.Context, ip net.IP) (string, string) {
+	var name string
+	if v4 := ip.To4(); v4 != nil {
+		name = fmt.Sprintf("%v.%v.%v.%v.origin.asn.cymru.com", v4[3], v4[2], v4[1], v4[0])
+	} else {
+		var nibbles []string
+		for i := len(ip) - 1; i >= 0; i-- {
+			nibbles = append(nibbles, fmt.Sprintf("%x.%x", ip[i]&0xf, ip[i]>>4))
+		}
+		name = strings.Join(nibbles, ".") + ".origin6.asn.cymru.com"
+	}
+
+	// Answers look like "13335 | 104.16.0.0/13 | US | arin | 2014-03-28"
+	records, err := net.DefaultResolver.LookupTXT(ctx, name)
+	if err != nil || len(records) == 0 {
+		return "", ""
+	}
+	fields := strings.Fields(strings.SplitN(records[0], "|", 2)[0])
+	if len(fields) == 0 {
+		return "", ""
+	}
+	asn := "AS" + fields[0]
+
+	// Answers look like "13335 | US | arin | 2010-07-14 | CLOUDFLARENET - Cloudflare, Inc., US"
+	records, err = net.DefaultResolver.LookupTXT(ctx, asn+".asn.cymru.com")
+	if err != nil || len(records) == 0 {
+		return asn, ""
+	}
+	parts := strings.Split(records[0], "|")
+
+	return asn, strings.TrimSpace(parts[len(parts)-1])
+}
+
+// Print prints the description of every host looked up
+func (l *infraLookup) Print() {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	hosts := make([]string, 0, len(l.hosts))
+	for host := range l.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		info := l.hosts[host]
+		var notes []string
+		for _, note := range []string{info.reverseDNS, info.asn, info.provider} {
+			if len(note) != 0 {
+				notes = append(notes, note)
+			}
+		}
+		if len(notes) != 0 {
+			fmt.Fprintf(logOutput, "Host: %v %v (%v)\n", host, info.ip, strings.Join(notes, ", "))
+		} else {
+			fmt.Fprintf(logOutput, "Host: %v %v\n", host, info.ip)
+		}
+	}
+}
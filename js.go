@@ -0,0 +1,46 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ---------- JavaScript ----------
+
+// jsURL matches absolute URLs and jsPath root relative paths in string
+// literals, such as the routes of client-side routing tables
+var jsURL = regexp.MustCompile("[\"'`](https?://[^\"'`\\s<>\\\\]+)[\"'`]")
+var jsPath = regexp.MustCompile("[\"'`](/[A-Za-z0-9_~%.\\-/]*)[\"'`]")
+
+// GetJSLinks retrieves URL and path literals from JavaScript. Paths with
+// route parameters or wildcards are left out since they are templates
+// rather than pages.
+func GetJSLinks(baseURL string, js string) []string {
+	var links []string
+	for _, match := range jsURL.FindAllStringSubmatch(js, -1) {
+		if link := TrimLink(match[1]); len(link) != 0 && !strings.ContainsAny(link, "{}*$") {
+			links = append(links, FixLink(baseURL, link))
+		}
+	}
+	for _, match := range jsPath.FindAllStringSubmatch(js, -1) {
+		link := TrimLink(match[1])
+		if len(link) != 0 && !strings.HasPrefix(link, "//") && !strings.Contains(link, "/:") {
+			links = append(links, FixLink(baseURL, link))
+		}
+	}
+
+	return links
+}
+
+// IsJavaScript reports whether a content type is a script
+func IsJavaScript(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	return mediaType == "application/javascript" || mediaType == "text/javascript" ||
+		mediaType == "application/x-javascript" || mediaType == "application/ecmascript"
+}
+
+// IsScriptType reports whether a script tag type attribute holds JavaScript
+func IsScriptType(scriptType string) bool {
+	scriptType = strings.ToLower(strings.TrimSpace(scriptType))
+	return len(scriptType) == 0 || scriptType == "module" || IsJavaScript(scriptType)
+}
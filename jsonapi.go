@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ---------- JSON APIs ----------
+
+// jsonLinkKeys are the object keys whose string values are links even when
+// relative, covering plain next links, HAL _links and JSON:API links
+var jsonLinkKeys = map[string]bool{
+	"href": true, "url": true, "uri": true, "link": true, "self": true, "related": true,
+	"next": true, "prev": true, "previous": true, "first": true, "last": true,
+	"next_page": true, "nextpage": true, "next_url": true, "nexturl": true,
+}
+
+// IsJSON reports whether a content type is JSON
+func IsJSON(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// GetJSONLinks retrieves the URL-shaped strings of a JSON document and the
+// next link of a Link header
+func GetJSONLinks(baseURL string, header http.Header, body []byte) []string {
+	var links []string
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err == nil {
+		links = walkJSON(baseURL, "", doc, links)
+	}
+
+	for _, value := range header.Values("Link") {
+		links = append(links, GetHeaderLinks(baseURL, value, "next")...)
+	}
+
+	return links
+}
+
+func walkJSON(baseURL string, key string, value interface{}, links []string) []string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			links = walkJSON(baseURL, strings.ToLower(k), v[k], links)
+		}
+	case []interface{}:
+		for _, item := range v {
+			links = walkJSON(baseURL, key, item, links)
+		}
+	case string:
+		link := TrimLink(v)
+		lower := strings.ToLower(link)
+		if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+			links = append(links, link)
+		} else if jsonLinkKeys[key] && len(link) != 0 && !strings.ContainsAny(link, " \t\n") && strings.ContainsAny(link[:1], "/.?") {
+			links = append(links, FixLink(baseURL, link))
+		}
+	}
+
+	return links
+}
+
+// GetHeaderLinks returns the targets of a Link header with a relation
+func GetHeaderLinks(baseURL string, header string, rel string) []string {
+	var links []string
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		target := strings.TrimSpace(fields[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+
+		for _, param := range fields[1:] {
+			name := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(name) == 2 && strings.EqualFold(name[0], "rel") {
+				for _, value := range strings.Fields(strings.Trim(name[1], `"`)) {
+					if strings.EqualFold(value, rel) {
+						links = append(links, FixLink(baseURL, strings.Trim(target, "<>")))
+					}
+				}
+			}
+		}
+	}
+
+	return links
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ---------- JSONL ----------
+
+type jsonLinkStatus struct {
+	URL    string `json:"url"`
+	Status string `json:"status"`
+}
+
+type jsonFormInput struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+type jsonForm struct {
+	Action    string          `json:"action"`
+	Method    string          `json:"method"`
+	Inputs    []jsonFormInput `json:"inputs,omitempty"`
+	CSRFToken bool            `json:"csrf_token"`
+}
+
+type jsonResult struct {
+	Version       int               `json:"schema_version"`
+	URL           string            `json:"url"`
+	Status        int               `json:"status,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	Redirect      string            `json:"redirect,omitempty"`
+	Canonical     string            `json:"canonical,omitempty"`
+	AMP           string            `json:"amp,omitempty"`
+	Mobile        string            `json:"mobile,omitempty"`
+	Icons         []string          `json:"icons,omitempty"`
+	Manifest      string            `json:"manifest,omitempty"`
+	Variant       string            `json:"variant,omitempty"`
+	Desktop       string            `json:"desktop,omitempty"`
+	Noindex       bool              `json:"noindex,omitempty"`
+	Lang          string            `json:"lang,omitempty"`
+	Emails        []string          `json:"emails,omitempty"`
+	Phones        []string          `json:"phones,omitempty"`
+	IP            string            `json:"ip,omitempty"`
+	ReverseDNS    string            `json:"reverse_dns,omitempty"`
+	ASN           string            `json:"asn,omitempty"`
+	Provider      string            `json:"provider,omitempty"`
+	Meta          map[string]string `json:"meta,omitempty"`
+	Extracted     map[string]string `json:"extracted,omitempty"`
+	Links         []string          `json:"links,omitempty"`
+	NavLinks      []string          `json:"nav_links,omitempty"`
+	LinkStatuses  []jsonLinkStatus  `json:"link_statuses,omitempty"`
+	Forms         []jsonForm        `json:"forms,omitempty"`
+	ContentType   string            `json:"content_type,omitempty"`
+	FreshFor      *int64            `json:"fresh_for,omitempty"`
+	Published     string            `json:"published,omitempty"`
+	Modified      string            `json:"modified,omitempty"`
+	BodySHA256    string            `json:"body_sha256,omitempty"`
+	Body          string            `json:"body,omitempty"`
+	BodyEncoding  string            `json:"body_encoding,omitempty"`
+	BodyTruncated bool              `json:"body_truncated,omitempty"`
+}
+
+// snippetSize is the number of body bytes included as a snippet
+const snippetSize = 512
+
+type jsonlOutput struct {
+	w         io.WriteCloser
+	encoder   *json.Encoder
+	body      string
+	bodyLimit int
+}
+
+// NewJSONLOutput creates an output writing one JSON result per line,
+// including none, a snippet or all of the body up to bodyLimit bytes
+func NewJSONLOutput(w io.WriteCloser, body string, bodyLimit int) (*jsonlOutput, error) {
+	if body != "none" && body != "snippet" && body != "full" {
+		return nil, fmt.Errorf("unknown body inclusion: %v", body)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	return &jsonlOutput{w, encoder, body, bodyLimit}, nil
+}
+
+// Write encodes a result as a JSON line
+func (o *jsonlOutput) Write(res result) error {
+	var statuses []jsonLinkStatus
+	for _, link := range res.linkStatuses {
+		statuses = append(statuses, jsonLinkStatus{link.url, link.status})
+	}
+
+	var forms []jsonForm
+	for _, f := range res.forms {
+		var inputs []jsonFormInput
+		for _, input := range f.inputs {
+			inputs = append(inputs, jsonFormInput{input.name, input.kind})
+		}
+		forms = append(forms, jsonForm{f.action, f.method, inputs, f.csrf})
+	}
+
+	limit := o.bodyLimit
+	if o.body == "snippet" {
+		limit = snippetSize
+	}
+	body := res.body
+	if o.body == "none" {
+		body = nil
+	}
+	encodedBody, encoding, truncated := EncodeBody(body, res.contentType, limit)
+
+	var freshFor *int64
+	if lifetime, ok := FreshnessLifetime(res.header); ok {
+		seconds := int64(lifetime / time.Second)
+		freshFor = &seconds
+	}
+
+	return o.encoder.Encode(jsonResult{
+		Version:       ResultSchemaVersion,
+		URL:           res.url,
+		Status:        res.status,
+		Title:         res.title,
+		Description:   res.description,
+		Error:         string(res.errClass),
+		Redirect:      res.redirect,
+		Canonical:     res.canonical,
+		AMP:           res.amp,
+		Mobile:        res.mobile,
+		Icons:         res.icons,
+		Manifest:      res.manifest,
+		Variant:       res.variant,
+		Desktop:       res.desktop,
+		Noindex:       res.noindex,
+		Lang:          res.lang,
+		Emails:        res.emails,
+		Phones:        res.phones,
+		IP:            res.ip,
+		ReverseDNS:    res.reverseDNS,
+		ASN:           res.asn,
+		Provider:      res.provider,
+		Meta:          res.meta,
+		Extracted:     res.extracted,
+		Links:         res.links,
+		NavLinks:      res.navLinks,
+		LinkStatuses:  statuses,
+		Forms:         forms,
+		ContentType:   res.contentType,
+		FreshFor:      freshFor,
+		Published:     FormatDate(res.published),
+		Modified:      FormatDate(res.modified),
+		BodySHA256:    res.bodyHash,
+		Body:          encodedBody,
+		BodyEncoding:  encoding,
+		BodyTruncated: truncated,
+	})
+}
+
+// Close closes the underlying writer
+func (o *jsonlOutput) Close() error {
+	return o.w.Close()
+}
+
+// EncodeBody truncates a body to limit bytes and returns it as text, or as
+// base64 if it is binary, along with its encoding and whether it was
+// truncated
+func EncodeBody(body []byte, contentType string, limit int) (string, string, bool) {
+	if len(body) == 0 {
+		return "", "", false
+	}
+
+	truncated := limit > 0 && len(body) > limit
+	if truncated {
+		body = body[:limit]
+	}
+
+	if IsText(contentType) {
+		// Only a character cut by the truncation is dropped, so invalid
+		// text is kept whole as base64
+		text := body
+		for cut := 0; truncated && cut < utf8.UTFMax-1 && len(text) > 0 && !utf8.Valid(text); cut++ {
+			text = text[:len(text)-1]
+		}
+		if len(text) > 0 && utf8.Valid(text) {
+			return string(text), "", truncated
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(body), "base64", truncated
+}
+
+// IsText reports whether a content type holds text
+func IsText(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	if strings.HasPrefix(mediaType, "text/") || len(mediaType) == 0 {
+		return true
+	}
+
+	for _, suffix := range []string{"json", "xml", "javascript", "ecmascript"} {
+		if strings.HasSuffix(mediaType, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+// Close does nothing
+func (nopCloser) Close() error {
+	return nil
+}
+
+// StdoutResults returns stdout for writing a result stream to, and moves
+// logs and reports to stderr so the stream stays parseable
+func StdoutResults() io.WriteCloser {
+	logOutput = os.Stderr
+	return nopCloser{os.Stdout}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONLOutput(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewJSONLOutput(nopCloser{&buf}, "none", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := []result{
+		{url: "http://example.com/", links: []string{"http://example.com/a"}, canonical: "http://example.com/", status: 200, title: "Example", description: "An example"},
+		{url: "http://example.com/missing", errClass: errorHTTP4xx, status: 404},
+	}
+	for _, res := range results {
+		if err := out.Write(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(results) {
+		t.Fatalf("got %v lines, want %v: %q", len(lines), len(results), buf.String())
+	}
+	for i, line := range lines {
+		var record jsonResult
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %v: %v", i, err)
+		}
+		res := results[i]
+		if record.URL != res.url || record.Error != string(res.errClass) || !reflect.DeepEqual(record.Links, res.links) ||
+			record.Version != ResultSchemaVersion || record.Status != res.status || record.Title != res.title || record.Description != res.description {
+			t.Errorf("line %v = %+v, want result %v", i, record, res.url)
+		}
+	}
+	if strings.Contains(lines[1], `"links"`) || strings.Contains(lines[0], `"error"`) {
+		t.Errorf("empty fields were written: %q", buf.String())
+	}
+}
+
+func TestIsBucketURL(t *testing.T) {
+	tests := []struct {
+		output string
+		bucket bool
+	}{
+		{"s3://bucket/prefix", true},
+		{"gs://bucket", true},
+		{"jsonl", false},
+		{"file:///tmp/results", false},
+		{"S3://bucket", false},
+	}
+
+	for _, test := range tests {
+		if bucket := IsBucketURL(test.output); bucket != test.bucket {
+			t.Errorf("IsBucketURL(%q) = %v, want %v", test.output, bucket, test.bucket)
+		}
+	}
+}
+
+func TestEncodeBody(t *testing.T) {
+	tests := []struct {
+		body        string
+		contentType string
+		limit       int
+		encoded     string
+		encoding    string
+		truncated   bool
+	}{
+		{"", "text/html", 0, "", "", false},
+		{"<p>hi</p>", "text/html; charset=utf-8", 0, "<p>hi</p>", "", false},
+		{`{"a":1}`, "application/json", 0, `{"a":1}`, "", false},
+		{"<feed/>", "application/atom+xml", 0, "<feed/>", "", false},
+		{"plain", "", 0, "plain", "", false},
+		{"hello world", "text/plain", 5, "hello", "", true},
+		{"hello", "text/plain", 5, "hello", "", false},
+		{"h\xc3\xa4j", "text/plain", 2, "h", "", true},
+		{"\x89PNG", "image/png", 0, "iVBORw==", "base64", false},
+		{"\x89PNG", "image/png", 2, "iVA=", "base64", true},
+		{"\xff\xfe", "text/plain", 0, "//4=", "base64", false},
+		{"\xff\xfe\xfd", "text/plain", 2, "//4=", "base64", true},
+	}
+
+	for _, test := range tests {
+		encoded, encoding, truncated := EncodeBody([]byte(test.body), test.contentType, test.limit)
+		if encoded != test.encoded || encoding != test.encoding || truncated != test.truncated {
+			t.Errorf("EncodeBody(%q, %q, %v) = %q, %q, %v, want %q, %q, %v", test.body, test.contentType, test.limit,
+				encoded, encoding, truncated, test.encoded, test.encoding, test.truncated)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ---------- Language ----------
+
+// languageSamples are the texts the trigram profiles are built from
+var languageSamples = map[string]string{
+	"en": "All human beings are born free and equal in dignity and rights. They are endowed with reason and conscience and should act towards one another in a spirit of brotherhood. " +
+		"Welcome to our website. Here you can find information about our products and services, read the latest news and contact us if you have any questions. We are happy to help you.",
+	"sv": "Alla människor är födda fria och lika i värde och rättigheter. De har utrustats med förnuft och samvete och bör handla gentemot varandra i en anda av broderskap. " +
+		"Välkommen till vår webbplats. Här kan du hitta information om våra produkter och tjänster, läsa de senaste nyheterna och kontakta oss om du har några frågor. Vi hjälper dig gärna.",
+	"de": "Alle Menschen sind frei und gleich an Würde und Rechten geboren. Sie sind mit Vernunft und Gewissen begabt und sollen einander im Geist der Brüderlichkeit begegnen. " +
+		"Willkommen auf unserer Webseite. Hier finden Sie Informationen über unsere Produkte und Dienstleistungen, lesen die neuesten Nachrichten und können uns kontaktieren, wenn Sie Fragen haben. Wir helfen Ihnen gerne.",
+	"fr": "Tous les êtres humains naissent libres et égaux en dignité et en droits. Ils sont doués de raison et de conscience et doivent agir les uns envers les autres dans un esprit de fraternité. " +
+		"Bienvenue sur notre site. Vous y trouverez des informations sur nos produits et services, les dernières nouvelles, et vous pouvez nous contacter si vous avez des questions. Nous sommes heureux de vous aider.",
+	"es": "Todos los seres humanos nacen libres e iguales en dignidad y derechos y, dotados como están de razón y conciencia, deben comportarse fraternalmente los unos con los otros. " +
+		"Bienvenido a nuestro sitio web. Aquí puede encontrar información sobre nuestros productos y servicios, leer las últimas noticias y ponerse en contacto con nosotros si tiene alguna pregunta. Estaremos encantados de ayudarle.",
+	"nl": "Alle mensen worden vrij en gelijk in waardigheid en rechten geboren. Zij zijn begiftigd met verstand en geweten, en behoren zich jegens elkander in een geest van broederschap te gedragen. " +
+		"Welkom op onze website. Hier vindt u informatie over onze producten en diensten, leest u het laatste nieuws en kunt u contact met ons opnemen als u vragen heeft. Wij helpen u graag.",
+	"da": "Alle mennesker er født frie og lige i værdighed og rettigheder. De er udstyret med fornuft og samvittighed, og de bør handle mod hverandre i en broderskabets ånd. " +
+		"Velkommen til vores hjemmeside. Her kan du finde information om vores produkter og tjenester, læse de seneste nyheder og kontakte os, hvis du har spørgsmål. Vi hjælper dig gerne.",
+	"no": "Alle mennesker er født frie og med samme menneskeverd og menneskerettigheter. De er utstyrt med fornuft og samvittighet og bør handle mot hverandre i brorskapets ånd. " +
+		"Velkommen til vår nettside. Her kan du finne informasjon om våre produkter og tjenester, lese de siste nyhetene og kontakte oss hvis du har spørsmål. Vi hjelper deg gjerne.",
+	"fi": "Kaikki ihmiset syntyvät vapaina ja tasavertaisina arvoltaan ja oikeuksiltaan. Heille on annettu järki ja omatunto, ja heidän on toimittava toisiaan kohtaan veljeyden hengessä. " +
+		"Tervetuloa verkkosivuillemme. Täältä löydät tietoa tuotteistamme ja palveluistamme, voit lukea uusimmat uutiset ja ottaa meihin yhteyttä, jos sinulla on kysyttävää. Autamme mielellämme.",
+	"it": "Tutti gli esseri umani nascono liberi ed eguali in dignità e diritti. Essi sono dotati di ragione e di coscienza e devono agire gli uni verso gli altri in spirito di fratellanza. " +
+		"Benvenuti sul nostro sito. Qui potete trovare informazioni sui nostri prodotti e servizi, leggere le ultime notizie e contattarci se avete domande. Saremo lieti di aiutarvi.",
+	"pt": "Todos os seres humanos nascem livres e iguais em dignidade e em direitos. Dotados de razão e de consciência, devem agir uns para com os outros em espírito de fraternidade. " +
+		"Bem-vindo ao nosso site. Aqui você pode encontrar informações sobre os nossos produtos e serviços, ler as últimas notícias e entrar em contato conosco se tiver alguma dúvida. Teremos todo o prazer em ajudar.",
+}
+
+// minDetectText is the least number of letters needed to detect a language
+const minDetectText = 40
+
+var languageProfiles = buildProfiles()
+
+func buildProfiles() map[string]map[string]float64 {
+	profiles := map[string]map[string]float64{}
+	for lang, sample := range languageSamples {
+		profiles[lang] = Trigrams(sample)
+	}
+
+	return profiles
+}
+
+// Trigrams returns the normalized trigram frequencies of a text
+func Trigrams(text string) map[string]float64 {
+	counts := map[string]float64{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+
+	norm := 0.0
+	for _, count := range counts {
+		norm += count * count
+	}
+	norm = math.Sqrt(norm)
+	for trigram := range counts {
+		counts[trigram] /= norm
+	}
+
+	return counts
+}
+
+// DetectLanguage returns the language of a page from its lang attribute or,
+// without one, from the trigrams of its text
+func DetectLanguage(attr string, text string) string {
+	if lang := PrimaryLanguage(attr); len(lang) != 0 {
+		return lang
+	}
+
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters < minDetectText {
+		return ""
+	}
+
+	trigrams := Trigrams(text)
+	var langs []string
+	for lang := range languageProfiles {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	best, bestScore := "", 0.0
+	for _, lang := range langs {
+		score := 0.0
+		for trigram, weight := range trigrams {
+			score += weight * languageProfiles[lang][trigram]
+		}
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+
+	return best
+}
+
+// PrimaryLanguage returns the lower case primary subtag of a language tag
+func PrimaryLanguage(tag string) string {
+	tag = strings.TrimSpace(strings.ToLower(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+
+	return tag
+}
+
+// ParseLanguages parses a comma separated language list into a set
+func ParseLanguages(list string) map[string]bool {
+	if len(strings.TrimSpace(list)) == 0 {
+		return nil
+	}
+
+	langs := map[string]bool{}
+	for _, lang := range strings.Split(list, ",") {
+		if lang = PrimaryLanguage(lang); len(lang) != 0 {
+			langs[lang] = true
+		}
+	}
+
+	return langs
+}
+
+// AllowLanguage reports whether a page language passes a language filter.
+// Pages whose language could not be detected are allowed.
+func AllowLanguage(langs map[string]bool, lang string) bool {
+	return len(langs) == 0 || len(lang) == 0 || langs[lang]
+}
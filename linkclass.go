@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// ---------- Link classes ----------
+
+// boilerplateElements and boilerplateRoles hold the links of navigation,
+// headers, footers and sidebars rather than page content
+var boilerplateElements = map[string]bool{"nav": true, "header": true, "footer": true, "aside": true, "menu": true}
+var boilerplateRoles = map[string]bool{"navigation": true, "banner": true, "contentinfo": true, "complementary": true, "menu": true, "menubar": true}
+
+// minRepeatPages is the number of pages of a host seen before links are
+// classified by how many of them they appear on
+const minRepeatPages = 5
+
+// linkContext tracks whether the tokenizer is inside a boilerplate element
+type linkContext struct {
+	names       []string
+	boilerplate []bool
+}
+
+// Token records the open elements of a tag
+func (c *linkContext) Token(tokenType html.TokenType, token html.Token) {
+	switch {
+	case tokenType == html.StartTagToken && !voidElements[token.Data]:
+		role := strings.ToLower(strings.TrimSpace(GetAttr(token, "role")))
+		c.names = append(c.names, token.Data)
+		c.boilerplate = append(c.boilerplate, boilerplateElements[token.Data] || boilerplateRoles[role])
+	case tokenType == html.EndTagToken:
+		for i := len(c.names) - 1; i >= 0; i-- {
+			if c.names[i] == token.Data {
+				c.names = c.names[:i]
+				c.boilerplate = c.boilerplate[:i]
+				break
+			}
+		}
+	}
+}
+
+// InBoilerplate reports whether the current tag is inside a boilerplate
+// element
+func (c *linkContext) InBoilerplate() bool {
+	for _, b := range c.boilerplate {
+		if b {
+			return true
+		}
+	}
+
+	return false
+}
+
+// linkClassifier tells navigation links from content links by where they
+// are on a page and by how many pages of a host repeat them
+type linkClassifier struct {
+	mutex sync.Mutex
+	pages map[string]int
+	links map[string]map[string]int
+}
+
+// NewLinkClassifier creates a classifier without pages seen
+func NewLinkClassifier() *linkClassifier {
+	return &linkClassifier{pages: map[string]int{}, links: map[string]map[string]int{}}
+}
+
+// Classify records the anchors of a page and returns the navigation links
+// among them: links only found in navigation elements, headers, footers and
+// sidebars, and links found on at least half of the pages of the host
+func (c *linkClassifier) Classify(url string, anchors []anchor) []string {
+	if c == nil {
+		return nil
+	}
+
+	host := hostOf(url)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.links[host] == nil {
+		c.links[host] = map[string]int{}
+	}
+	c.pages[host]++
+	pages := c.pages[host]
+
+	seen := map[string]bool{}
+	for _, anc := range anchors {
+		key := URLKey(anc.url)
+		if !seen[key] {
+			seen[key] = true
+			c.links[host][key]++
+		}
+	}
+
+	inContent := map[string]bool{}
+	for _, anc := range anchors {
+		if !anc.nav {
+			inContent[URLKey(anc.url)] = true
+		}
+	}
+
+	var nav []string
+	added := map[string]bool{}
+	for _, anc := range anchors {
+		key := URLKey(anc.url)
+		repeated := pages >= minRepeatPages && c.links[host][key]*2 >= pages
+		if (!inContent[key] || repeated) && !added[key] {
+			added[key] = true
+			nav = append(nav, anc.url)
+		}
+	}
+
+	return nav
+}
+
+// ContentFirst orders links so content links come before navigation links,
+// keeping the page order within each
+func ContentFirst(links []string, nav map[string]bool) []string {
+	ordered := make([]string, 0, len(links))
+	for _, link := range links {
+		if !nav[URLKey(link)] {
+			ordered = append(ordered, link)
+		}
+	}
+	for _, link := range links {
+		if nav[URLKey(link)] {
+			ordered = append(ordered, link)
+		}
+	}
+
+	return ordered
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ---------- Link status ----------
+
+type linkStatus struct {
+	url    string
+	status string
+}
+
+// AttachLinkStatuses sets the eventual status of every outgoing link on
+// each result, matching links to results after normalizing them the way
+// crawled URLs were. Links that were never crawled get the status
+// not-crawled.
+func AttachLinkStatuses(results []result, normalize func(string) string) {
+	statuses := map[string]string{}
+	for _, res := range results {
+		status := "ok"
+		if res.errClass != errorNone {
+			status = string(res.errClass)
+		}
+		statuses[URLKey(res.url)] = status
+	}
+
+	for i := range results {
+		seen := map[string]bool{}
+		var links []linkStatus
+		for _, link := range results[i].links {
+			key := URLKey(normalize(link))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			status, ok := statuses[key]
+			if !ok {
+				status = "not-crawled"
+			}
+			links = append(links, linkStatus{link, status})
+		}
+		results[i].linkStatuses = links
+	}
+}
+
+// PrintBrokenLinks prints every page linking to a failed URL
+func PrintBrokenLinks(results []result) {
+	for _, res := range results {
+		for _, link := range res.linkStatuses {
+			if link.status != "ok" && link.status != "not-crawled" {
+				fmt.Fprintf(logOutput, "Broken link: %v -> %v (%v)\n", res.url, link.url, link.status)
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestAttachLinkStatuses(t *testing.T) {
+	results := []result{
+		{url: "http://example.com/", links: []string{
+			"http://example.com/ok?utm_source=x",
+			"http://example.com/ok",
+			"http://example.com/missing?fbclid=1",
+			"http://example.com/never",
+		}},
+		{url: "http://example.com/ok"},
+		{url: "http://example.com/missing", errClass: errorHTTP4xx},
+	}
+
+	AttachLinkStatuses(results, StripTracking)
+
+	want := []linkStatus{
+		{"http://example.com/ok?utm_source=x", "ok"},
+		{"http://example.com/missing?fbclid=1", string(errorHTTP4xx)},
+		{"http://example.com/never", "not-crawled"},
+	}
+	got := results[0].linkStatuses
+	if len(got) != len(want) {
+		t.Fatalf("got %v link statuses, want %v: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("link %v = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	neturl "net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ---------- Login ----------
+
+type loginForm struct {
+	action string
+	method string
+	fields neturl.Values
+}
+
+// NewClient creates an HTTP client with a cookie jar for session cookies
+func NewClient() *http.Client {
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{Jar: jar}
+}
+
+// Login fetches a login page, fills in its form with the given values and
+// submits it so the client's cookie jar holds the session
+func Login(client *http.Client, loginURL string, values neturl.Values) error {
+	resp, err := client.Get(loginURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return statusError{resp.StatusCode}
+	}
+
+	pageURL := resp.Request.URL.String()
+	form, ok := GetLoginForm(pageURL, resp.Body)
+	if !ok {
+		form = loginForm{pageURL, http.MethodPost, neturl.Values{}}
+	}
+
+	for key, vals := range values {
+		form.fields[key] = vals
+	}
+
+	var submit *http.Response
+	if form.method == http.MethodGet {
+		target, err := neturl.Parse(form.action)
+		if err != nil {
+			return err
+		}
+		target.RawQuery = form.fields.Encode()
+		submit, err = client.Get(target.String())
+	} else {
+		submit, err = client.PostForm(form.action, form.fields)
+	}
+	if err != nil {
+		return err
+	}
+	defer submit.Body.Close()
+
+	if submit.StatusCode >= 400 {
+		return fmt.Errorf("login failed: %v", statusError{submit.StatusCode})
+	}
+
+	return nil
+}
+
+// GetLoginForm retrieves the first form with a password field, or else the
+// first form, along with its prefilled fields such as CSRF tokens
+func GetLoginForm(baseURL string, body io.Reader) (loginForm, bool) {
+	var forms []loginForm
+	var passwords []bool
+	page := html.NewTokenizer(body)
+	for {
+		tokenType := page.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			for i, form := range forms {
+				if passwords[i] {
+					return form, true
+				}
+			}
+			if len(forms) != 0 {
+				return forms[0], true
+			}
+			return loginForm{}, false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := page.Token()
+			switch token.Data {
+			case "form":
+				action := TrimLink(GetAttr(token, "action"))
+				if len(action) == 0 {
+					action = baseURL
+				}
+				method := strings.ToUpper(GetAttr(token, "method"))
+				if method != http.MethodGet {
+					method = http.MethodPost
+				}
+				forms = append(forms, loginForm{FixLink(baseURL, action), method, neturl.Values{}})
+				passwords = append(passwords, false)
+			case "input":
+				if len(forms) == 0 {
+					continue
+				}
+				name := GetAttr(token, "name")
+				kind := strings.ToLower(GetAttr(token, "type"))
+				if kind == "password" {
+					passwords[len(passwords)-1] = true
+				}
+				if len(name) != 0 && kind != "submit" && kind != "checkbox" && kind != "radio" {
+					forms[len(forms)-1].fields.Set(name, GetAttr(token, "value"))
+				}
+			}
+		}
+	}
+}
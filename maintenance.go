@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------- Maintenance ----------
+
+// maintenanceRetries is how often a page answered with 503 and Retry-After
+// is retried after its host was paused
+const maintenanceRetries = 3
+
+type pauseStats struct {
+	count int
+	total time.Duration
+}
+
+// hostPauses pauses every request to a host that answered 503 Service
+// Unavailable with Retry-After, as sites do during maintenance windows
+type hostPauses struct {
+	mutex   sync.Mutex
+	max     time.Duration
+	until   map[string]time.Time
+	stats   map[string]*pauseStats
+	verbose bool
+}
+
+// maintenance holds the host pauses of the crawl
+var maintenance = &hostPauses{until: map[string]time.Time{}, stats: map[string]*pauseStats{}}
+
+// RetryAfter parses a Retry-After header given in seconds or as a date
+func RetryAfter(header http.Header) (time.Duration, bool) {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, seconds > 0
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Until(date), time.Until(date) > 0
+}
+
+// Pause pauses a host for the Retry-After of a response, at most for the
+// longest allowed pause, and reports whether the host was paused
+func (p *hostPauses) Pause(host string, header http.Header) bool {
+	delay, ok := RetryAfter(header)
+	if !ok || p.max <= 0 {
+		return false
+	}
+	if delay > p.max {
+		delay = p.max
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	until := now.Add(delay)
+	from := p.until[host]
+	if from.Before(now) {
+		from = now
+		if p.stats[host] == nil {
+			p.stats[host] = &pauseStats{}
+		}
+		p.stats[host].count++
+		if p.verbose {
+			fmt.Fprintf(logOutput, "Pausing host %v for %v (503 Retry-After)\n", host, delay)
+		}
+	}
+	if until.After(from) {
+		p.stats[host].total += until.Sub(from)
+		p.until[host] = until
+	}
+
+	return true
+}
+
+// Wait blocks while a host is paused
+func (p *hostPauses) Wait(host string) {
+	p.mutex.Lock()
+	until := p.until[host]
+	p.mutex.Unlock()
+
+	time.Sleep(time.Until(until))
+}
+
+// Snapshot returns the hosts paused now and until when
+func (p *hostPauses) Snapshot() map[string]time.Time {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	paused := map[string]time.Time{}
+	now := time.Now()
+	for host, until := range p.until {
+		if until.After(now) {
+			paused[host] = until
+		}
+	}
+
+	return paused
+}
+
+// Print prints how often and how long each host was paused
+func (p *hostPauses) Print() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	hosts := make([]string, 0, len(p.stats))
+	for host := range p.stats {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		stats := p.stats[host]
+		fmt.Fprintf(logOutput, "Host paused: %v (%v times, %v in total)\n", host, stats.count, stats.total.Round(time.Second))
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// ---------- Manifest ----------
+
+// secretFlags are left out of manifests since they may hold credentials
+var secretFlags = map[string]bool{"login-data": true, "neo4j-password": true}
+
+// secretHeaders are left out of the config recorded in manifests
+var secretHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+type manifest struct {
+	Started  time.Time         `json:"started"`
+	Finished time.Time         `json:"finished"`
+	Version  string            `json:"version"`
+	Revision string            `json:"revision,omitempty"`
+	Args     []string          `json:"args"`
+	Flags    map[string]string `json:"flags"`
+	Config   config            `json:"config"`
+	Seeds    []string          `json:"seeds"`
+	Results  int64             `json:"results"`
+	Errors   errorCounts       `json:"errors"`
+}
+
+// NewManifest records how a crawl is started
+func NewManifest(seeds []string, conf config) *manifest {
+	m := &manifest{
+		Started: time.Now().UTC(),
+		Version: "(devel)",
+		Args:    append([]string{}, os.Args[1:]...),
+		Flags:   map[string]string{},
+		Config:  redactConfig(conf),
+		Seeds:   seeds,
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		m.Version = info.Main.Version
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				m.Revision = setting.Value
+			}
+		}
+	}
+
+	flag.VisitAll(func(f *flag.Flag) {
+		if secretFlags[f.Name] && len(f.Value.String()) != 0 {
+			m.Flags[f.Name] = "REDACTED"
+		} else {
+			m.Flags[f.Name] = f.Value.String()
+		}
+	})
+	for i, arg := range m.Args {
+		name := strings.TrimLeft(strings.SplitN(arg, "=", 2)[0], "-")
+		if secretFlags[name] && strings.Contains(arg, "=") {
+			m.Args[i] = strings.SplitN(arg, "=", 2)[0] + "=REDACTED"
+		} else if secretFlags[name] && i+1 < len(m.Args) {
+			m.Args[i+1] = "REDACTED"
+		}
+	}
+
+	return m
+}
+
+// Write finishes a manifest with the crawl summary and writes it to a file
+func (m *manifest) Write(path string, results int64, errors errorCounts) error {
+	data, err := m.Encode(results, errors)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Encode finishes a manifest with the crawl summary and encodes it as JSON
+func (m *manifest) Encode(results int64, errors errorCounts) ([]byte, error) {
+	m.Finished = time.Now().UTC()
+	m.Results = results
+	m.Errors = errors
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+func redactConfig(conf config) config {
+	rules := make([]headerRule, len(conf.Headers))
+	for i, rule := range conf.Headers {
+		rules[i] = headerRule{rule.Match, rule.UserAgent, redactHeaders(rule.Headers)}
+	}
+	conf.Headers = rules
+
+	crawlRules := make(crawlRules, len(conf.Rules))
+	for i, rule := range conf.Rules {
+		rule.Headers = redactHeaders(rule.Headers)
+		crawlRules[i] = rule
+	}
+	conf.Rules = crawlRules
+
+	return conf
+}
+
+func redactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+
+	redacted := map[string]string{}
+	for key, value := range headers {
+		redacted[key] = value
+		for _, secret := range secretHeaders {
+			if strings.EqualFold(key, secret) {
+				redacted[key] = "REDACTED"
+			}
+		}
+	}
+
+	return redacted
+}
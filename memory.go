@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ---------- Memory ----------
+
+type watchdog struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	limit   uint64
+	paused  bool
+	tripped bool
+	since   time.Time
+	verbose bool
+}
+
+// maxPause bounds how long the crawl waits for memory that in-flight sites
+// do not free, such as the visited set itself
+const maxPause = 30 * time.Second
+
+// NewWatchdog starts monitoring the heap against a limit in bytes. The
+// garbage collector is also told about the limit so it collects harder
+// before the limit is reached.
+func NewWatchdog(limit uint64, interval time.Duration, verbose bool) *watchdog {
+	if limit == 0 {
+		return nil
+	}
+
+	w := &watchdog{limit: limit, verbose: verbose}
+	w.cond = sync.NewCond(&w.mutex)
+	debug.SetMemoryLimit(int64(limit))
+
+	go func() {
+		for range time.Tick(interval) {
+			w.check()
+		}
+	}()
+
+	return w
+}
+
+// Wait blocks while memory use is too high to start crawling more sites
+func (w *watchdog) Wait() {
+	if w == nil {
+		return
+	}
+
+	w.mutex.Lock()
+	for w.paused {
+		w.cond.Wait()
+	}
+	w.mutex.Unlock()
+}
+
+func (w *watchdog) check() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	switch {
+	case !w.paused && stats.HeapAlloc >= w.limit/10*9:
+		w.paused = true
+		w.tripped = true
+		w.since = time.Now()
+		if w.verbose {
+			fmt.Fprintf(logOutput, "Pausing crawl, heap at %v of %v bytes\n", stats.HeapAlloc, w.limit)
+		}
+		debug.FreeOSMemory()
+	case w.paused && (stats.HeapAlloc < w.limit/4*3 || time.Since(w.since) > maxPause):
+		w.paused = false
+		if w.verbose {
+			fmt.Fprintf(logOutput, "Resuming crawl, heap at %v of %v bytes\n", stats.HeapAlloc, w.limit)
+		}
+		w.cond.Broadcast()
+	}
+}
+
+// Tripped reports whether memory ever ran short during the crawl
+func (w *watchdog) Tripped() bool {
+	if w == nil {
+		return false
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.tripped
+}
+
+// ---------- Spill ----------
+
+type spillSpan struct {
+	offset int64
+	body   int
+	text   int
+}
+
+// spill keeps bodies and texts of buffered results in a temp file
+type spill struct {
+	file   *os.File
+	offset int64
+	held   int
+	spans  map[string]spillSpan
+}
+
+func NewSpill() *spill {
+	return &spill{spans: map[string]spillSpan{}}
+}
+
+// Hold moves bodies and texts of results not yet held to the temp file
+func (s *spill) Hold(results []result) error {
+	if s.file == nil {
+		file, err := os.CreateTemp("", "gocrawler-spill-*")
+		if err != nil {
+			return err
+		}
+		s.file = file
+	}
+
+	for ; s.held < len(results); s.held++ {
+		res := &results[s.held]
+		data := append(append([]byte{}, res.body...), res.text...)
+		if _, err := s.file.WriteAt(data, s.offset); err != nil {
+			return err
+		}
+		s.spans[res.url] = spillSpan{s.offset, len(res.body), len(res.text)}
+		s.offset += int64(len(data))
+		res.body = nil
+		res.text = ""
+	}
+
+	return nil
+}
+
+// Restore reads back the body and text of a held result
+func (s *spill) Restore(res *result) error {
+	span, ok := s.spans[res.url]
+	if !ok {
+		return nil
+	}
+
+	data := make([]byte, span.body+span.text)
+	if _, err := s.file.ReadAt(data, span.offset); err != nil {
+		return err
+	}
+	if span.body > 0 {
+		res.body = data[:span.body]
+	}
+	res.text = string(data[span.body:])
+
+	return nil
+}
+
+// Close removes the temp file
+func (s *spill) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestSpill(t *testing.T) {
+	results := []result{
+		{url: "http://example.com/", body: []byte("<p>home</p>"), text: "home"},
+		{url: "http://example.com/empty"},
+		{url: "http://example.com/about", body: []byte("<p>about</p>"), text: "about"},
+	}
+	want := append([]result{}, results...)
+
+	spilled := NewSpill()
+	defer spilled.Close()
+
+	if err := spilled.Hold(results[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := spilled.Hold(results); err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range results {
+		if res.body != nil || len(res.text) != 0 {
+			t.Errorf("result %v still holds its body", i)
+		}
+	}
+
+	for i := len(results) - 1; i >= 0; i-- {
+		res := results[i]
+		if err := spilled.Restore(&res); err != nil {
+			t.Fatal(err)
+		}
+		if string(res.body) != string(want[i].body) || res.text != want[i].text {
+			t.Errorf("restored %v = %q, %q, want %q, %q", res.url, res.body, res.text, want[i].body, want[i].text)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ---------- Neo4j ----------
+
+const neo4jWritePage = `
+MERGE (p:Page {url: $url})
+SET p.error = $error
+WITH p
+UNWIND $links AS link
+MERGE (q:Page {url: link.url})
+MERGE (p)-[r:LINKS_TO]->(q)
+SET r.anchor = link.anchor`
+
+const neo4jWriteRanks = `
+UNWIND $ranks AS rank
+MATCH (p:Page {url: rank.url})
+SET p.pagerank = rank.pagerank, p.in_degree = rank.in_degree, p.out_degree = rank.out_degree`
+
+// neo4jOutput writes pages as they are crawled, and their PageRank and
+// degrees once the graph is complete
+type neo4jOutput struct {
+	ctx     context.Context
+	driver  neo4j.DriverWithContext
+	session neo4j.SessionWithContext
+	graph   linkGraph
+}
+
+// NewNeo4jOutput connects to Neo4j for writing the link graph
+func NewNeo4jOutput(uri string, user string, password string) (*neo4jOutput, error) {
+	ctx := context.Background()
+
+	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(user, password, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		driver.Close(ctx)
+		return nil, err
+	}
+
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+
+	return &neo4jOutput{ctx: ctx, driver: driver, session: session}, nil
+}
+
+// Write merges a Page node and its LINKS_TO relationships
+func (o *neo4jOutput) Write(res result) error {
+	o.graph.Add(res)
+
+	links := []interface{}{}
+	for _, a := range res.anchors {
+		links = append(links, map[string]interface{}{"url": a.url, "anchor": a.text})
+	}
+
+	params := map[string]interface{}{
+		"url":   res.url,
+		"error": string(res.errClass),
+		"links": links,
+	}
+
+	_, err := o.session.ExecuteWrite(o.ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return tx.Run(o.ctx, neo4jWritePage, params)
+	})
+
+	return err
+}
+
+// Close sets the pagerank, in_degree and out_degree of every crawled Page
+// node and closes the Neo4j session and driver
+func (o *neo4jOutput) Close() error {
+	ranks := []interface{}{}
+	for _, r := range o.graph.PageRank(0.85, 100) {
+		ranks = append(ranks, map[string]interface{}{
+			"url":        r.url,
+			"pagerank":   r.rank,
+			"in_degree":  r.in,
+			"out_degree": r.out,
+		})
+	}
+
+	_, err := o.session.ExecuteWrite(o.ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return tx.Run(o.ctx, neo4jWriteRanks, map[string]interface{}{"ranks": ranks})
+	})
+
+	o.session.Close(o.ctx)
+	if closeErr := o.driver.Close(o.ctx); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
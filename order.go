@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sort"
+)
+
+// ---------- Order ----------
+
+// OrderResults sorts results in breadth-first discovery order from the
+// seeds, following the links of every result in page order. This order only
+// depends on the pages crawled, not on which fetch finished first. Results
+// not reached from the seeds, such as pages reached through redirects, come
+// last in URL order.
+func OrderResults(results []result, seeds []string, normalize func(string) string) {
+	index := map[string]int{}
+	for i, res := range results {
+		index[URLKey(res.url)] = i
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = -1
+	}
+
+	next := 0
+	queue := []int{}
+	visit := func(url string) {
+		i, ok := index[URLKey(normalize(url))]
+		if ok && order[i] < 0 {
+			order[i] = next
+			next++
+			queue = append(queue, i)
+		}
+	}
+
+	for _, seed := range seeds {
+		visit(seed)
+	}
+	for len(queue) != 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, link := range results[i].links {
+			visit(link)
+		}
+	}
+
+	sorted := make([]int, len(results))
+	for i := range sorted {
+		sorted[i] = i
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := order[sorted[i]], order[sorted[j]]
+		switch {
+		case a >= 0 && b >= 0:
+			return a < b
+		case a >= 0 || b >= 0:
+			return a >= 0
+		}
+		return results[sorted[i]].url < results[sorted[j]].url
+	})
+
+	original := append([]result{}, results...)
+	for i, j := range sorted {
+		results[i] = original[j]
+	}
+}
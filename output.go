@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// ---------- Output ----------
+
+// logOutput receives the logs and reports printed by a crawl, stdout unless
+// results are streamed to stdout
+var logOutput io.Writer = os.Stdout
+
+// Output writes results
+type Output interface {
+	Write(res result) error
+	Close() error
+}
+
+type textOutput struct{}
+
+// Write prints a result
+func (o textOutput) Write(res result) error {
+	if res.errClass != errorNone {
+		_, err := fmt.Printf("Error: %v (%v)\n", res.url, res.errClass)
+		return err
+	}
+
+	if len(res.desktop) != 0 {
+		_, err := fmt.Printf("Result: %v (%v of %v)\n", res.url, res.variant, res.desktop)
+		return err
+	}
+
+	_, err := fmt.Printf("Result: %v\n", res.url)
+	return err
+}
+
+// Close does nothing for printed results
+func (o textOutput) Close() error {
+	return nil
+}
+
+// TemplateResult is the result passed to -template
+type TemplateResult struct {
+	URL         string
+	Status      int
+	Error       string
+	Title       string
+	Redirect    string
+	Canonical   string
+	ContentType string
+	Lang        string
+	Noindex     bool
+	Links       []string
+	Emails      []string
+	Phones      []string
+	IP          string
+	Provider    string
+	Meta        map[string]string
+	Extracted   map[string]string
+	BodySHA256  string
+}
+
+type templateOutput struct {
+	tmpl *template.Template
+	w    io.Writer
+}
+
+// NewTemplateOutput creates an output printing each result with a
+// text/template, followed by a newline
+func NewTemplateOutput(text string, w io.Writer) (*templateOutput, error) {
+	tmpl, err := template.New("result").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &templateOutput{tmpl, w}, nil
+}
+
+// Write prints a result with the template
+func (o *templateOutput) Write(res result) error {
+	data := TemplateResult{
+		URL:         res.url,
+		Status:      res.status,
+		Error:       string(res.errClass),
+		Title:       res.title,
+		Redirect:    res.redirect,
+		Canonical:   res.canonical,
+		ContentType: res.contentType,
+		Lang:        res.lang,
+		Noindex:     res.noindex,
+		Links:       res.links,
+		Emails:      res.emails,
+		Phones:      res.phones,
+		IP:          res.ip,
+		Provider:    res.provider,
+		Meta:        res.meta,
+		Extracted:   res.extracted,
+		BodySHA256:  res.bodyHash,
+	}
+
+	if err := o.tmpl.Execute(o.w, data); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(o.w)
+	return err
+}
+
+// Close does nothing for printed results
+func (o *templateOutput) Close() error {
+	return nil
+}
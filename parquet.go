@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ---------- Parquet ----------
+
+type parquetRow struct {
+	URL       string   `parquet:"url"`
+	Host      string   `parquet:"host"`
+	Error     string   `parquet:"error,optional"`
+	Canonical string   `parquet:"canonical,optional"`
+	Redirect  string   `parquet:"redirect,optional"`
+	Noindex   bool     `parquet:"noindex"`
+	Links     []string `parquet:"links,list"`
+	CrawledAt int64    `parquet:"crawled_at,timestamp(millisecond)"`
+}
+
+type parquetPartition struct {
+	file   *os.File
+	writer *parquet.GenericWriter[parquetRow]
+}
+
+type parquetOutput struct {
+	dir        string
+	partition  string
+	partitions map[string]*parquetPartition
+}
+
+// NewParquetOutput creates a Parquet output partitioned by host or date
+func NewParquetOutput(dir string, partition string) (*parquetOutput, error) {
+	if partition != "host" && partition != "date" {
+		return nil, fmt.Errorf("unknown partition: %v", partition)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &parquetOutput{dir, partition, map[string]*parquetPartition{}}, nil
+}
+
+// Write writes a result to the Parquet file of its partition
+func (o *parquetOutput) Write(res result) error {
+	now := time.Now()
+	row := parquetRow{
+		URL:       res.url,
+		Host:      hostOf(res.url),
+		Error:     string(res.errClass),
+		Canonical: res.canonical,
+		Redirect:  res.redirect,
+		Noindex:   res.noindex,
+		Links:     res.links,
+		CrawledAt: now.UnixNano() / int64(time.Millisecond),
+	}
+
+	key := "date=" + now.Format("2006-01-02")
+	if o.partition == "host" {
+		key = "host=" + row.Host
+	}
+
+	p, err := o.open(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.writer.Write([]parquetRow{row})
+	return err
+}
+
+// Close flushes and closes every partition file
+func (o *parquetOutput) Close() error {
+	var firstErr error
+	for _, p := range o.partitions {
+		if err := p.writer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := p.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (o *parquetOutput) open(key string) (*parquetPartition, error) {
+	if p, ok := o.partitions[key]; ok {
+		return p, nil
+	}
+
+	dir := filepath.Join(o.dir, key)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(filepath.Join(dir, "part-0.parquet"))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parquetPartition{file, parquet.NewGenericWriter[parquetRow](file)}
+	o.partitions[key] = p
+
+	return p, nil
+}
+
+func hostOf(link string) string {
+	u, err := neturl.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return u.Hostname()
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ---------- Parse links ----------
+
+// linkParsers maps file extensions to the content type they are parsed as
+var linkParsers = map[string]string{
+	".html": "text/html", ".htm": "text/html", ".xhtml": "application/xhtml+xml",
+	".css": "text/css", ".js": "text/javascript", ".mjs": "text/javascript",
+	".xml": "application/xml", ".json": "application/json", ".pdf": "application/pdf",
+}
+
+// RunParseLinks runs the parse-links subcommand, printing the links the
+// crawler extracts from a local file one per line in page order, so link
+// extraction can be checked against golden files
+func RunParseLinks(args []string) {
+	flags := flag.NewFlagSet("parse-links", flag.ExitOnError)
+	base := flags.String("base", "http://localhost/", "Set URL the file is parsed as being fetched from.")
+	contentType := flags.String("type", "", "Set content type to parse as, defaults to one by file extension or text/html.")
+	parseCSS := flags.Bool("parse-css", false, "Set to true to extract links from stylesheets and style attributes.")
+	parseImages := flags.Bool("parse-images", false, "Set to true to extract image links from img, srcset and picture sources.")
+	parseJS := flags.Bool("parse-js", false, "Set to true to extract URL and path literals from scripts.")
+	followVariants := flags.Bool("follow-variants", false, "Set to true to extract AMP and mobile alternate links.")
+	frames := flags.String("frames", "none", "Set how frame and iframe sources are extracted: none or separate.")
+	normalize := flags.Bool("normalize", true, "Set to false to print links without stripping tracking parameters.")
+	golden := flags.String("golden", "", "Set file of expected links to compare against, exiting with 1 on differences.")
+	update := flags.Bool("update", false, "Set to true to write the links to the -golden file instead of comparing.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: gocrawler parse-links [flags] <file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	fMode, err := ParseFrameMode(*frames)
+	if err == nil && fMode == framesMerged {
+		err = fmt.Errorf("merged frames need fetching and are not supported by parse-links")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	path := flags.Arg(0)
+	var body []byte
+	if path == "-" {
+		body, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		body, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if len(*contentType) == 0 {
+		*contentType = linkParsers[strings.ToLower(filepath.Ext(path))]
+	}
+	if len(*contentType) == 0 {
+		*contentType = "text/html"
+	}
+
+	opts := linkOptions{css: *parseCSS, images: *parseImages, variants: *followVariants, frames: fMode, js: *parseJS}
+	links := ParseLinks(*base, *contentType, body, opts)
+	if *normalize {
+		for i, link := range links {
+			links[i] = StripTracking(link)
+		}
+	}
+
+	switch {
+	case len(*golden) == 0:
+		for _, link := range links {
+			fmt.Fprintln(logOutput, link)
+		}
+	case *update:
+		data := strings.Join(links, "\n")
+		if len(links) != 0 {
+			data += "\n"
+		}
+		if err := ioutil.WriteFile(*golden, []byte(data), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		expected, err := ioutil.ReadFile(*golden)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if !CompareLinks(strings.Fields(string(expected)), links) {
+			os.Exit(1)
+		}
+	}
+}
+
+// CompareLinks prints the links missing from and unexpected in the actual
+// links, and the first difference in order, and reports whether they match
+func CompareLinks(expected []string, actual []string) bool {
+	want := map[string]int{}
+	for _, link := range expected {
+		want[link]++
+	}
+	got := map[string]int{}
+	for _, link := range actual {
+		got[link]++
+	}
+
+	match := true
+	for _, link := range expected {
+		if got[link] < want[link] {
+			fmt.Fprintf(logOutput, "Missing: %v\n", link)
+			got[link] = want[link]
+			match = false
+		}
+	}
+	for _, link := range actual {
+		if want[link] < got[link] {
+			fmt.Fprintf(logOutput, "Unexpected: %v\n", link)
+			want[link] = got[link]
+			match = false
+		}
+	}
+
+	if match {
+		for i := range expected {
+			if expected[i] != actual[i] {
+				fmt.Fprintf(logOutput, "Order: expected %v at %v, got %v\n", expected[i], i+1, actual[i])
+				return false
+			}
+		}
+	}
+
+	return match
+}
+
+// ParseLinks extracts the links of a body the way the fetcher does for its
+// content type
+func ParseLinks(baseURL string, contentType string, body []byte, opts linkOptions) []string {
+	switch {
+	case IsCSS(contentType):
+		return GetCSSLinks(baseURL, string(body))
+	case IsJavaScript(contentType):
+		return GetJSLinks(baseURL, string(body))
+	case IsJSON(contentType):
+		return GetJSONLinks(baseURL, http.Header{"Content-Type": {contentType}}, body)
+	case IsXML(contentType) && !IsXHTML(contentType):
+		links, _ := GetXMLLinks(baseURL, body)
+		return links
+	case IsPDF(contentType):
+		links, _ := ParsePDF(baseURL, body)
+		return links
+	}
+
+	links := ParseDocument(baseURL, bytes.NewReader(body), opts).links
+	if IsXHTML(contentType) {
+		xmlLinks, _ := GetXMLLinks(baseURL, body)
+		links = MergeLinks(links, xmlLinks)
+	}
+
+	return links
+}
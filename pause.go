@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ---------- Pause ----------
+
+type pauseGate struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	paused  bool
+	verbose bool
+}
+
+// NewPauseGate creates a gate that holds the crawl while paused
+func NewPauseGate(verbose bool) *pauseGate {
+	g := &pauseGate{verbose: verbose}
+	g.cond = sync.NewCond(&g.mutex)
+	return g
+}
+
+// Pause stops new sites from being crawled. Sites being fetched finish and
+// queued sites are kept.
+func (g *pauseGate) Pause() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if !g.paused && g.verbose {
+		fmt.Fprintln(logOutput, "Paused crawl")
+	}
+	g.paused = true
+}
+
+// Resume lets the crawl continue
+func (g *pauseGate) Resume() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.paused && g.verbose {
+		fmt.Fprintln(logOutput, "Resumed crawl")
+	}
+	g.paused = false
+	g.cond.Broadcast()
+}
+
+// Wait blocks while the crawl is paused
+func (g *pauseGate) Wait() {
+	if g == nil {
+		return
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for g.paused {
+		g.cond.Wait()
+	}
+}
+
+// ServeHTTP pauses on POST /debug/pause and resumes on POST /debug/resume
+func (g *pauseGate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/debug/pause":
+		g.Pause()
+	case "/debug/resume":
+		g.Resume()
+	default:
+		http.NotFound(w, r)
+	}
+}
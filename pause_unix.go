@@ -0,0 +1,25 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandlePauseSignals pauses the crawl on SIGUSR1 and resumes it on SIGUSR2
+func HandlePauseSignals(g *pauseGate) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGUSR1 {
+				g.Pause()
+			} else {
+				g.Resume()
+			}
+		}
+	}()
+}
@@ -0,0 +1,7 @@
+//go:build windows
+
+package main
+
+// HandlePauseSignals does nothing since Windows has no SIGUSR1 and SIGUSR2.
+// The debug server pause and resume endpoints still work.
+func HandlePauseSignals(g *pauseGate) {}
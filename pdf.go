@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ---------- PDF ----------
+
+var pdfURI = regexp.MustCompile(`/URI\s*\(((?:\\.|[^\\)])*)\)`)
+var pdfText = regexp.MustCompile(`\[((?:\\.|[^\]\\])*)\]\s*TJ|\(((?:\\.|[^()\\])*)\)\s*(?:Tj|'|")|\b(?:T\*|Td|TD|ET)\b`)
+var pdfArrayItem = regexp.MustCompile(`\(((?:\\.|[^()\\])*)\)|-?\d+(?:\.\d+)?`)
+
+// pdfSpace is the kerning in a TJ array above which a space is assumed
+const pdfSpace = 200
+
+// IsPDF reports whether a content type is a PDF document
+func IsPDF(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), "application/pdf")
+}
+
+// ParsePDF retrieves the link annotations and the text of a PDF. Only
+// uncompressed and Flate compressed streams are read and text is decoded as
+// literal strings, which covers documents using standard fonts.
+func ParsePDF(baseURL string, body []byte) ([]string, string) {
+	var links []string
+	var lines []string
+	var line strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); len(text) != 0 {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	for _, chunk := range pdfChunks(body) {
+		for _, match := range pdfURI.FindAllSubmatch(chunk, -1) {
+			if link := TrimLink(UnescapePDFString(string(match[1]))); len(link) != 0 {
+				links = append(links, FixLink(baseURL, link))
+			}
+		}
+
+		for _, match := range pdfText.FindAllSubmatch(chunk, -1) {
+			switch {
+			case match[1] != nil:
+				for _, item := range pdfArrayItem.FindAllSubmatch(match[1], -1) {
+					if item[1] != nil {
+						line.WriteString(UnescapePDFString(string(item[1])))
+					} else if kern, err := strconv.ParseFloat(string(item[0]), 64); err == nil && kern <= -pdfSpace {
+						line.WriteString(" ")
+					}
+				}
+			case match[2] != nil:
+				line.WriteString(UnescapePDFString(string(match[2])))
+			default:
+				flush()
+			}
+		}
+		flush()
+	}
+
+	return links, strings.Join(lines, "\n")
+}
+
+// pdfChunks returns the body of a PDF followed by its inflated streams
+func pdfChunks(body []byte) [][]byte {
+	chunks := [][]byte{body}
+	rest := body
+	for {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			return chunks
+		}
+		rest = rest[start+len("stream"):]
+		rest = bytes.TrimPrefix(rest, []byte("\r"))
+		rest = bytes.TrimPrefix(rest, []byte("\n"))
+
+		end := bytes.Index(rest, []byte("endstream"))
+		if end < 0 {
+			return chunks
+		}
+
+		if r, err := zlib.NewReader(bytes.NewReader(rest[:end])); err == nil {
+			if data, err := ioutil.ReadAll(r); err == nil || len(data) != 0 {
+				chunks = append(chunks, data)
+			}
+			r.Close()
+		}
+		rest = rest[end+len("endstream"):]
+	}
+}
+
+// UnescapePDFString decodes the escapes of a PDF literal string
+func UnescapePDFString(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case '\r', '\n':
+			if c == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		default:
+			if c >= '0' && c <= '7' {
+				n := 0
+				j := i
+				for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+					n = n*8 + int(s[j]-'0')
+				}
+				b.WriteByte(byte(n))
+				i = j - 1
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ---------- Phases ----------
+
+// Crawls can be split into a discovery phase writing a URL inventory and a
+// fetch phase crawling a filtered part of it. The inventory is a CSV file
+// with a url column, so the fetch phase reads it with -seeds and copies its
+// columns to results.
+const (
+	phaseAll      = "all"
+	phaseDiscover = "discover"
+	phaseFetch    = "fetch"
+)
+
+// ParsePhase parses a phase flag value
+func ParsePhase(phase string) (string, error) {
+	switch phase {
+	case phaseAll, phaseDiscover, phaseFetch:
+		return phase, nil
+	}
+
+	return phaseAll, fmt.Errorf("unknown phase: %v", phase)
+}
+
+// leafExtensions are extensions of files that hold no links to follow,
+// requested with HEAD in the discovery phase
+var leafExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".avif": true, ".ico": true,
+	".mp3": true, ".mp4": true, ".webm": true, ".ogg": true, ".wav": true, ".mov": true,
+	".zip": true, ".gz": true, ".tar": true, ".rar": true, ".7z": true, ".exe": true, ".dmg": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+}
+
+// IsLeafURL reports whether a URL looks like a file without links by its
+// extension
+func IsLeafURL(link string) bool {
+	u, err := neturl.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	return leafExtensions[strings.ToLower(path.Ext(u.Path))]
+}
+
+var inventoryColumns = []string{"url", "status", "content_type", "error", "redirect", "title", "links"}
+
+// inventoryWriter writes one CSV row per crawled URL, flushed as results come
+type inventoryWriter struct {
+	file *os.File
+	w    *csv.Writer
+}
+
+// CreateInventory creates a URL inventory file
+func CreateInventory(path string) (*inventoryWriter, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &inventoryWriter{file, csv.NewWriter(file)}
+	if err := i.w.Write(inventoryColumns); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// Write adds a result to the inventory
+func (i *inventoryWriter) Write(res result) error {
+	if i == nil {
+		return nil
+	}
+
+	status := ""
+	if res.status != 0 {
+		status = strconv.Itoa(res.status)
+	}
+	row := []string{res.url, status, res.contentType, string(res.errClass), res.redirect, res.title, strconv.Itoa(len(res.links))}
+	if err := i.w.Write(row); err != nil {
+		return err
+	}
+	i.w.Flush()
+
+	return i.w.Error()
+}
+
+// Close closes the inventory file
+func (i *inventoryWriter) Close() error {
+	if i == nil {
+		return nil
+	}
+
+	i.w.Flush()
+	if err := i.w.Error(); err != nil {
+		i.file.Close()
+		return err
+	}
+
+	return i.file.Close()
+}
+
+// seedFilter matches a metadata column of seeds against a pattern where *
+// matches any characters
+type seedFilter struct {
+	column  string
+	pattern *regexp.Regexp
+}
+
+// ParseSeedFilters parses comma separated column=pattern filters, such as
+// status=200,content_type=text/html*
+func ParseSeedFilters(list string) ([]seedFilter, error) {
+	var filters []seedFilter
+	for _, filter := range strings.Split(list, ",") {
+		if len(strings.TrimSpace(filter)) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(filter, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+			return nil, fmt.Errorf("invalid seed filter: %v", filter)
+		}
+
+		quoted := strings.Split(strings.TrimSpace(parts[1]), "*")
+		for i := range quoted {
+			quoted[i] = regexp.QuoteMeta(quoted[i])
+		}
+		pattern := regexp.MustCompile("^" + strings.Join(quoted, ".*") + "$")
+		filters = append(filters, seedFilter{strings.TrimSpace(parts[0]), pattern})
+	}
+
+	return filters, nil
+}
+
+// FilterSeeds keeps the seeds whose metadata matches every filter. The url
+// column matches the seed itself.
+func FilterSeeds(seeds []string, meta seedMetadata, filters []seedFilter) []string {
+	if len(filters) == 0 {
+		return seeds
+	}
+
+	var kept []string
+	for _, seed := range seeds {
+		values := meta.Lookup(StripTracking(seed))
+		match := true
+		for _, filter := range filters {
+			value := values[filter.column]
+			if filter.column == "url" {
+				value = seed
+			}
+			match = match && filter.pattern.MatchString(value)
+		}
+		if match {
+			kept = append(kept, seed)
+		}
+	}
+
+	return kept
+}
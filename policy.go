@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ---------- Policy cache ----------
+
+// maxRobotsSize is the size of robots.txt parsed, as crawlers must parse at
+// least 500 KiB
+const maxRobotsSize = 500 << 10
+
+// hostPolicy is the crawl policy of a host as fetched from its robots.txt
+type hostPolicy struct {
+	Origin      string             `json:"origin"`
+	Fetched     time.Time          `json:"fetched"`
+	Status      int                `json:"status"`
+	Sitemaps    []string           `json:"sitemaps,omitempty"`
+	CrawlDelays map[string]float64 `json:"crawl_delays,omitempty"`
+	Robots      string             `json:"robots"`
+}
+
+// NewHostPolicy describes the robots.txt fetched from a URL
+func NewHostPolicy(robotsURL string, status int, body string, r robots) hostPolicy {
+	policy := hostPolicy{
+		Origin:   strings.TrimSuffix(robotsURL, "/robots.txt"),
+		Fetched:  time.Now().UTC(),
+		Status:   status,
+		Sitemaps: r.sitemaps,
+		Robots:   body,
+	}
+	for _, group := range r.groups {
+		if group.delay <= 0 {
+			continue
+		}
+		if policy.CrawlDelays == nil {
+			policy.CrawlDelays = map[string]float64{}
+		}
+		for _, agent := range group.agents {
+			policy.CrawlDelays[agent] = group.delay.Seconds()
+		}
+	}
+
+	return policy
+}
+
+// policyStore keeps the robots.txt policies of hosts in the body store
+// directory, so re-crawls and resumed crawls do not fetch them again
+type policyStore struct {
+	dir string
+	ttl time.Duration
+}
+
+// policies is the policy store of the crawl, nil to always fetch robots.txt
+var policies *policyStore
+
+// NewPolicyStore creates a store of policies in the policies directory of
+// a body store directory, fresh for ttl
+func NewPolicyStore(storeDir string, ttl time.Duration) (*policyStore, error) {
+	if len(storeDir) == 0 || ttl <= 0 {
+		return nil, nil
+	}
+
+	dir := filepath.Join(storeDir, "policies")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &policyStore{dir, ttl}, nil
+}
+
+// policyName names the file of an origin's policy, such as
+// https_example.com.json or http_localhost_8080.json
+func policyName(origin string) string {
+	origin = strings.TrimSuffix(origin, "/robots.txt")
+	return strings.NewReplacer("://", "_", ":", "_", "/", "_").Replace(origin) + ".json"
+}
+
+// Get returns the stored policy of a robots.txt URL if it is fresh
+func (s *policyStore) Get(robotsURL string) (hostPolicy, bool) {
+	if s == nil {
+		return hostPolicy{}, false
+	}
+
+	policy, err := ReadPolicy(filepath.Join(s.dir, policyName(robotsURL)))
+	if err != nil || time.Since(policy.Fetched) > s.ttl {
+		return hostPolicy{}, false
+	}
+
+	return policy, true
+}
+
+// Put stores a policy
+func (s *policyStore) Put(policy hostPolicy) error {
+	if s == nil {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(s.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, policyName(policy.Origin)))
+}
+
+// ReadPolicy reads a stored policy file
+func ReadPolicy(path string) (hostPolicy, error) {
+	var policy hostPolicy
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return policy, err
+	}
+
+	return policy, json.Unmarshal(data, &policy)
+}
+
+// RunPolicy runs the policy subcommand, printing the stored robots.txt
+// policies of a host
+func RunPolicy(args []string) {
+	flags := flag.NewFlagSet("policy", flag.ExitOnError)
+	storeDir := flags.String("store-dir", "", "Set body store directory of the crawl the policies were stored by.")
+	agent := flags.String("agent", "Go-http-client", "Set user agent to print the crawl delay and rules of.")
+	raw := flags.Bool("raw", false, "Set to true to also print the stored robots.txt.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: gocrawler policy -store-dir <dir> [flags] <host>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 || len(*storeDir) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// Hosts are given as example.com, example.com:8080 or a URL on the host
+	host := flags.Arg(0)
+	if u, err := neturl.Parse(host); err == nil && len(u.Host) != 0 {
+		host = u.Host
+	}
+	host = strings.Replace(host, ":", "_", -1)
+
+	var paths []string
+	for _, pattern := range []string{"*_" + host + ".json", "*_" + host + "_*.json"} {
+		matches, _ := filepath.Glob(filepath.Join(*storeDir, "policies", pattern))
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+	if len(paths) == 0 {
+		fmt.Fprintf(os.Stderr, "no stored policy for %v\n", host)
+		os.Exit(1)
+	}
+
+	for i, path := range paths {
+		policy, err := ReadPolicy(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		PrintPolicy(policy, *agent, *raw)
+	}
+}
+
+// PrintPolicy prints a stored policy as it applies to a user agent
+func PrintPolicy(policy hostPolicy, agent string, raw bool) {
+	r := ParseRobots(strings.NewReader(policy.Robots))
+
+	fmt.Printf("Origin: %v\n", policy.Origin)
+	fmt.Printf("Fetched: %v (%v ago)\n", policy.Fetched.Format(time.RFC3339), time.Since(policy.Fetched).Round(time.Second))
+	fmt.Printf("Status: %v\n", policy.Status)
+	for _, sitemap := range policy.Sitemaps {
+		fmt.Printf("Sitemap: %v\n", sitemap)
+	}
+	if delay := r.CrawlDelay(agent); delay > 0 {
+		fmt.Printf("Crawl-delay: %v\n", delay)
+	}
+	if group := r.group(agent); group != nil {
+		fmt.Printf("Group: %v\n", strings.Join(group.agents, ", "))
+		for _, rule := range group.rules {
+			if rule.allow {
+				fmt.Printf("  Allow: %v\n", rule.pattern)
+			} else {
+				fmt.Printf("  Disallow: %v\n", rule.pattern)
+			}
+		}
+	} else {
+		fmt.Printf("Group: none for %v, everything allowed\n", agent)
+	}
+	if raw {
+		fmt.Println()
+		fmt.Print(policy.Robots)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ---------- Presets ----------
+
+// defaultUserAgent identifies the crawler for presets that identify it
+const defaultUserAgent = "GoCrawler/1.0 (+https://github.com/tobiasbrodd/GoCrawler)"
+
+// presets are coherent bundles of flag values
+var presets = map[string]map[string]string{
+	// polite crawls one request per second per host, obeys robots.txt,
+	// identifies itself and reuses responses that are still fresh
+	"polite": {
+		"host-delay":    "1s",
+		"robots":        "true",
+		"identify":      defaultUserAgent,
+		"cache-headers": "true",
+	},
+	// aggressive crawls without delays or robots.txt and follows every kind
+	// of link
+	"aggressive": {
+		"host-delay":      "0s",
+		"robots":          "false",
+		"workers":         "0",
+		"parse-css":       "true",
+		"parse-images":    "true",
+		"follow-variants": "true",
+		"frames":          "separate",
+	},
+	// archive keeps full pages and their assets in a WARC file and the body
+	// store, politely
+	"archive": {
+		"host-delay":      "1s",
+		"robots":          "true",
+		"identify":        defaultUserAgent,
+		"parse-css":       "true",
+		"parse-images":    "true",
+		"follow-variants": "true",
+		"frames":          "separate",
+		"warc-output":     "crawl.warc.gz",
+		"store-dir":       "store",
+	},
+}
+
+// ApplyPreset sets the flags of a preset that were not set on the command
+// line
+func ApplyPreset(name string) error {
+	if len(name) == 0 {
+		return nil
+	}
+
+	values, ok := presets[name]
+	if !ok {
+		return fmt.Errorf("unknown preset: %v (%v)", name, strings.Join(PresetNames(), ", "))
+	}
+
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	for key, value := range values {
+		if set[key] {
+			continue
+		}
+		if err := flag.Set(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PresetNames returns the names of the presets, sorted
+func PresetNames() []string {
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ---------- Proto ----------
+
+// ResultSchemaVersion is the version of the result schema in result.proto
+// and JSONL output
+const ResultSchemaVersion = 2
+
+type protoOutput struct {
+	w io.WriteCloser
+}
+
+// NewProtoOutput creates an output writing length-delimited Result messages
+func NewProtoOutput(w io.WriteCloser) *protoOutput {
+	return &protoOutput{w}
+}
+
+// Write writes a result as a varint length-prefixed message, in one write
+// so results are flushed as they come and never split between segments
+func (o *protoOutput) Write(res result) error {
+	message := MarshalResult(res)
+	record := protowire.AppendVarint(nil, uint64(len(message)))
+
+	_, err := o.w.Write(append(record, message...))
+	return err
+}
+
+// Close closes the underlying writer
+func (o *protoOutput) Close() error {
+	return o.w.Close()
+}
+
+// MarshalResult encodes a result as a Result message from result.proto
+func MarshalResult(res result) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, ResultSchemaVersion)
+	b = appendString(b, 2, res.url)
+	b = appendString(b, 3, string(res.errClass))
+	b = appendString(b, 4, res.redirect)
+	b = appendString(b, 5, res.canonical)
+	if res.noindex {
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	for _, link := range res.links {
+		b = protowire.AppendTag(b, 7, protowire.BytesType)
+		b = protowire.AppendString(b, link)
+	}
+	for _, link := range res.linkStatuses {
+		var status []byte
+		status = appendString(status, 1, link.url)
+		status = appendString(status, 2, link.status)
+		b = protowire.AppendTag(b, 8, protowire.BytesType)
+		b = protowire.AppendBytes(b, status)
+	}
+	b = appendString(b, 9, res.amp)
+	b = appendString(b, 10, res.mobile)
+	b = appendString(b, 11, res.variant)
+	b = appendString(b, 12, res.desktop)
+	for _, icon := range res.icons {
+		b = protowire.AppendTag(b, 13, protowire.BytesType)
+		b = protowire.AppendString(b, icon)
+	}
+	b = appendString(b, 14, res.manifest)
+	b = appendString(b, 15, res.lang)
+	b = appendString(b, 16, res.bodyHash)
+	if lifetime, ok := FreshnessLifetime(res.header); ok {
+		b = protowire.AppendTag(b, 17, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(lifetime/time.Second))
+	}
+	b = appendString(b, 18, FormatDate(res.published))
+	b = appendString(b, 19, FormatDate(res.modified))
+	b = appendString(b, 20, res.ip)
+	b = appendString(b, 21, res.reverseDNS)
+	b = appendString(b, 22, res.asn)
+	b = appendString(b, 23, res.provider)
+	for _, f := range res.forms {
+		var message []byte
+		message = appendString(message, 1, f.action)
+		message = appendString(message, 2, f.method)
+		for _, input := range f.inputs {
+			var field []byte
+			field = appendString(field, 1, input.name)
+			field = appendString(field, 2, input.kind)
+			message = protowire.AppendTag(message, 3, protowire.BytesType)
+			message = protowire.AppendBytes(message, field)
+		}
+		if f.csrf {
+			message = protowire.AppendTag(message, 4, protowire.VarintType)
+			message = protowire.AppendVarint(message, 1)
+		}
+		b = protowire.AppendTag(b, 24, protowire.BytesType)
+		b = protowire.AppendBytes(b, message)
+	}
+	b = appendMap(b, 25, res.meta)
+	b = appendStrings(b, 26, res.emails)
+	b = appendStrings(b, 27, res.phones)
+	b = appendMap(b, 28, res.extracted)
+	b = appendStrings(b, 29, res.navLinks)
+	b = appendString(b, 30, res.contentType)
+	if res.status != 0 {
+		b = protowire.AppendTag(b, 31, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(res.status))
+	}
+	b = appendString(b, 32, res.title)
+	b = appendString(b, 33, res.description)
+
+	return b
+}
+
+// appendStrings appends a repeated string field
+func appendStrings(b []byte, num protowire.Number, values []string) []byte {
+	for _, s := range values {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+
+	return b
+}
+
+// appendMap appends a map field as entries sorted by key, so equal results
+// encode the same
+func appendMap(b []byte, num protowire.Number, m map[string]string) []byte {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, key)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, m[key])
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+
+	return b
+}
+
+// appendString appends a string field, leaving out empty proto3 defaults
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if len(s) == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
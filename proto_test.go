@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeFields decodes a message into its values by field number, with
+// varints formatted as decimal strings
+func decodeFields(t *testing.T, b []byte) map[protowire.Number][]string {
+	t.Helper()
+
+	fields := map[protowire.Number][]string{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("bad tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				t.Fatalf("bad varint in field %v: %v", num, protowire.ParseError(n))
+			}
+			fields[num] = append(fields[num], strconv.FormatUint(v, 10))
+			b = b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatalf("bad bytes in field %v: %v", num, protowire.ParseError(n))
+			}
+			fields[num] = append(fields[num], string(v))
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %v in field %v", typ, num)
+		}
+	}
+
+	return fields
+}
+
+func decodeMap(t *testing.T, entries []string) map[string]string {
+	m := map[string]string{}
+	for _, entry := range entries {
+		fields := decodeFields(t, []byte(entry))
+		m[fields[1][0]] = fields[2][0]
+	}
+
+	return m
+}
+
+func TestMarshalResult(t *testing.T) {
+	res := result{
+		url:          "http://example.com/",
+		errClass:     errorHTTP4xx,
+		canonical:    "http://example.com/home",
+		noindex:      true,
+		links:        []string{"http://example.com/a", "http://example.com/b"},
+		linkStatuses: []linkStatus{{"http://example.com/a", "ok"}},
+		lang:         "sv",
+		header:       http.Header{"Cache-Control": {"max-age=60"}},
+		forms:        []form{{action: "/search", method: "get", inputs: []formInput{{"q", "text"}}}},
+		meta:         map[string]string{"label": "home", "priority": "1"},
+		emails:       []string{"info@example.com"},
+		phones:       []string{"+46 8 123 456"},
+		extracted:    map[string]string{"price": "10"},
+		navLinks:     []string{"http://example.com/b"},
+		contentType:  "text/html",
+		status:       404,
+		title:        "Not found",
+		description:  "The page is gone",
+	}
+
+	fields := decodeFields(t, MarshalResult(res))
+
+	stringFields := map[protowire.Number][]string{
+		2:  {res.url},
+		3:  {string(res.errClass)},
+		5:  {res.canonical},
+		7:  res.links,
+		15: {res.lang},
+		26: res.emails,
+		27: res.phones,
+		29: res.navLinks,
+		30: {res.contentType},
+		32: {res.title},
+		33: {res.description},
+	}
+	for num, want := range stringFields {
+		if got := fields[num]; !reflect.DeepEqual(got, want) {
+			t.Errorf("field %v = %q, want %q", num, got, want)
+		}
+	}
+
+	if got := fields[1]; len(got) != 1 || got[0] != strconv.Itoa(ResultSchemaVersion) {
+		t.Errorf("schema_version = %q, want %v", got, ResultSchemaVersion)
+	}
+	if got := fields[31]; len(got) != 1 || got[0] != "404" {
+		t.Errorf("status = %q, want 404", got)
+	}
+	if got := fields[6]; len(got) != 1 || got[0] != "1" {
+		t.Errorf("noindex = %q, want true", got)
+	}
+	if got := fields[17]; len(got) != 1 || got[0] != "60" {
+		t.Errorf("fresh_for = %q, want 60", got)
+	}
+	if _, ok := fields[4]; ok {
+		t.Errorf("empty redirect was encoded")
+	}
+
+	status := decodeFields(t, []byte(fields[8][0]))
+	if status[1][0] != "http://example.com/a" || status[2][0] != "ok" {
+		t.Errorf("link status = %q", status)
+	}
+
+	f := decodeFields(t, []byte(fields[24][0]))
+	input := decodeFields(t, []byte(f[3][0]))
+	if f[1][0] != "/search" || f[2][0] != "get" || input[1][0] != "q" || input[2][0] != "text" {
+		t.Errorf("form = %q, input = %q", f, input)
+	}
+
+	if got := decodeMap(t, fields[25]); !reflect.DeepEqual(got, res.meta) {
+		t.Errorf("meta = %v, want %v", got, res.meta)
+	}
+	if got := decodeMap(t, fields[28]); !reflect.DeepEqual(got, res.extracted) {
+		t.Errorf("extracted = %v, want %v", got, res.extracted)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sync"
+)
+
+// ---------- Quotas ----------
+
+// quotas bounds the goroutines and buffer memory of the crawl run by this
+// process. Open sockets are bounded by LimitConnections on its client.
+type quotas struct {
+	slots   chan bool
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	buffers int64
+	used    int64
+}
+
+// NewQuotas creates quotas of at most goroutines sites crawled at once and
+// buffers bytes of bodies held while downloading and parsing, where zero
+// means no limit
+func NewQuotas(goroutines int, buffers int64) *quotas {
+	if goroutines <= 0 && buffers <= 0 {
+		return nil
+	}
+
+	q := &quotas{buffers: buffers}
+	q.cond = sync.NewCond(&q.mutex)
+	if goroutines > 0 {
+		q.slots = make(chan bool, goroutines)
+	}
+
+	return q
+}
+
+// Acquire blocks until a site may be crawled
+func (q *quotas) Acquire() {
+	if q == nil || q.slots == nil {
+		return
+	}
+
+	q.slots <- true
+}
+
+// Release frees the goroutine slot of a finished site
+func (q *quotas) Release() {
+	if q == nil || q.slots == nil {
+		return
+	}
+
+	<-q.slots
+}
+
+// Reserve blocks until n more bytes of buffers fit in the quota. A buffer
+// larger than the whole quota is let through once nothing else is held.
+func (q *quotas) Reserve(n int64) {
+	if q == nil || q.buffers <= 0 || n <= 0 {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for q.used != 0 && q.used+n > q.buffers {
+		q.cond.Wait()
+	}
+	q.used += n
+}
+
+// Free returns n bytes of buffers to the quota
+func (q *quotas) Free(n int64) {
+	if q == nil || q.buffers <= 0 || n <= 0 {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.used -= n
+	q.cond.Broadcast()
+}
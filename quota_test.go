@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// linkFetcher serves a start page linking to n pages without links
+type linkFetcher struct {
+	n int
+}
+
+func (f linkFetcher) Fetch(req fetchRequest) (response, error) {
+	resp := response{url: req.url, urls: []string{}}
+	if req.url == "http://example.com/" {
+		for i := 0; i < f.n; i++ {
+			resp.urls = append(resp.urls, fmt.Sprintf("http://example.com/%v", i))
+		}
+	}
+
+	return resp, nil
+}
+
+// TestHelperQuotaCrawl crawls more links than goroutine slots. It runs in a
+// process of its own since the crawl state is global.
+func TestHelperQuotaCrawl(t *testing.T) {
+	if os.Getenv("GOCRAWLER_HELPER_QUOTA") != "1" {
+		return
+	}
+
+	limit, err := NewDepthLimit("http://example.com/", 2, depthHops)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts := crawlOptions{limit: limit, quotas: NewQuotas(1, 0)}
+	go Crawl([]string{"http://example.com/"}, opts, linkFetcher{20}, false)
+
+	pages := 0
+	for range responses {
+		pages++
+	}
+	if pages != 21 {
+		fmt.Fprintf(os.Stderr, "crawled %v pages, want 21\n", pages)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestGoroutineQuota(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperQuotaCrawl$")
+	cmd.Env = append(os.Environ(), "GOCRAWLER_HELPER_QUOTA=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("crawl failed: %v: %s", err, stderr.String())
+		}
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Errorf("crawl with more links than goroutine slots did not finish")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ---------- Redirects ----------
+
+// maxRedirects bounds the redirects followed for a fetch, as net/http does
+const maxRedirects = 10
+
+type redirectHop struct {
+	from   string
+	to     string
+	status int
+}
+
+type redirectScope int
+
+const (
+	redirectsAnyHost redirectScope = iota
+	redirectsSameHost
+)
+
+// ParseRedirectScope parses a redirect scope flag value
+func ParseRedirectScope(scope string) (redirectScope, error) {
+	switch scope {
+	case "any":
+		return redirectsAnyHost, nil
+	case "same-host":
+		return redirectsSameHost, nil
+	}
+
+	return redirectsAnyHost, fmt.Errorf("unknown redirect scope: %v", scope)
+}
+
+type redirectChainKey struct{}
+
+// WithRedirectChain returns a context recording the redirects followed by
+// requests sent with it
+func WithRedirectChain(ctx context.Context, chain *[]redirectHop) context.Context {
+	return context.WithValue(ctx, redirectChainKey{}, chain)
+}
+
+// ApplyRedirectPolicy makes a client record the redirects it follows and,
+// with a same-host scope, return redirects to other hosts instead of
+// following them
+func ApplyRedirectPolicy(client *http.Client, scope redirectScope) {
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %v redirects", maxRedirects)
+		}
+		if scope == redirectsSameHost && req.URL.Host != via[0].URL.Host {
+			return http.ErrUseLastResponse
+		}
+
+		if chain, ok := req.Context().Value(redirectChainKey{}).(*[]redirectHop); ok && req.Response != nil {
+			*chain = append(*chain, redirectHop{via[len(via)-1].URL.String(), req.URL.String(), req.Response.StatusCode})
+		}
+
+		return nil
+	}
+}
+
+// IsRedirect reports whether a status code is a redirect with a location
+func IsRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+
+	return false
+}
+
+// IsPermanentRedirect reports whether a status code is a permanent redirect
+func IsPermanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
+
+// redirectChecker finds links to URLs that redirect permanently
+type redirectChecker struct {
+	redirects map[string]redirectHop
+	pages     []result
+}
+
+// Add records the redirects and links of a crawled page
+func (c *redirectChecker) Add(res result) {
+	if c.redirects == nil {
+		c.redirects = map[string]redirectHop{}
+	}
+
+	if len(res.redirects) != 0 && IsPermanentRedirect(res.redirects[0].status) {
+		hop := res.redirects[0]
+		hop.to = res.redirect
+		c.redirects[URLKey(res.url)] = hop
+	}
+	c.pages = append(c.pages, result{url: res.url, links: res.links})
+}
+
+// Print prints every link to a permanently redirecting URL, which should be
+// updated to the URL it redirects to
+func (c *redirectChecker) Print() {
+	sort.Slice(c.pages, func(i, j int) bool {
+		return c.pages[i].url < c.pages[j].url
+	})
+
+	for _, page := range c.pages {
+		seen := map[string]bool{}
+		for _, link := range page.links {
+			key := URLKey(link)
+			hop, ok := c.redirects[key]
+			if !ok || seen[key] {
+				continue
+			}
+			seen[key] = true
+			fmt.Fprintf(logOutput, "Update link: %v -> %v (%v to %v)\n", page.url, link, hop.status, hop.to)
+		}
+	}
+}
+
+// finalURLs claims the URLs crawled so a page reached through redirects is
+// only crawled once, under the URL it finally resolves to
+type finalURLs struct {
+	mutex sync.Mutex
+	keys  map[string]bool
+}
+
+// NewFinalURLs creates an empty set of claimed URLs
+func NewFinalURLs() *finalURLs {
+	return &finalURLs{keys: map[string]bool{}}
+}
+
+// Claim reports whether a URL was not claimed before and claims it. Every
+// URL is new without a set.
+func (u *finalURLs) Claim(url string) bool {
+	if u == nil {
+		return true
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	key := URLKey(url)
+	if u.keys[key] {
+		return false
+	}
+	u.keys[key] = true
+
+	return true
+}
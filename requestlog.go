@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ---------- Request log ----------
+
+// rotatingFile appends to a file, renaming it with a timestamp suffix and
+// starting a new one once it reaches maxSize bytes. Rotated files are kept.
+type rotatingFile struct {
+	mutex   sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+// OpenRotatingFile opens a file for appending, rotating it at maxSize bytes
+// if maxSize > 0
+func OpenRotatingFile(path string, maxSize int64) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends p, rotating the file first if p would not fit
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	rotated := fmt.Sprintf("%v.%v", f.path, time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+
+	return f.open()
+}
+
+// Close closes the current file
+func (f *rotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.file.Close()
+}
+
+type requestLogEntry struct {
+	Time   string `json:"time"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Status int    `json:"status,omitempty"`
+	Bytes  int64  `json:"bytes"`
+	Error  string `json:"error,omitempty"`
+}
+
+// requestLog writes one JSON line for every HTTP request a client sends
+type requestLog struct {
+	w io.Writer
+}
+
+// Log writes an entry for a request
+func (l requestLog) Log(start time.Time, req *http.Request, status int, bytes int64, err error) {
+	entry := requestLogEntry{
+		Time:   start.UTC().Format(time.RFC3339Nano),
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: status,
+		Bytes:  bytes,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	data, _ := json.Marshal(entry)
+	if _, err := l.w.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on request log: %v\n", err)
+	}
+}
+
+type loggingTransport struct {
+	base http.RoundTripper
+	log  requestLog
+}
+
+type loggedBody struct {
+	io.ReadCloser
+	once  sync.Once
+	bytes int64
+	done  func(bytes int64, err error)
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	if err != nil && err != io.EOF {
+		b.once.Do(func() { b.done(b.bytes, err) })
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	b.once.Do(func() { b.done(b.bytes, nil) })
+	return b.ReadCloser.Close()
+}
+
+// RoundTrip sends a request and logs it once its body is closed, with the
+// number of body bytes read
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.log.Log(start, req, 0, 0, err)
+		return resp, err
+	}
+
+	resp.Body = &loggedBody{ReadCloser: resp.Body, done: func(bytes int64, err error) {
+		t.log.Log(start, req, resp.StatusCode, bytes, err)
+	}}
+
+	return resp, nil
+}
+
+// LogRequests makes a client log every request it sends, including
+// redirects, to a writer
+func LogRequests(client *http.Client, w io.Writer) {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	client.Transport = loggingTransport{base, requestLog{w}}
+}
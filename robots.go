@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------- Robots ----------
+
+type robots struct {
+	sitemaps []string
+	groups   []robotsGroup
+}
+
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+	delay  time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// RobotsURL returns the robots.txt location for a URL's host
+func RobotsURL(url string) string {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return ""
+	}
+
+	return u.Scheme + "://" + u.Host + "/robots.txt"
+}
+
+// FetchRobots fetches and parses the robots.txt of a URL's host, or reads
+// it from the policy store if stored there recently. A missing robots.txt
+// results in empty rules.
+func FetchRobots(client *http.Client, url string) (robots, error) {
+	robotsURL := RobotsURL(url)
+	if policy, ok := policies.Get(robotsURL); ok {
+		return ParseRobots(strings.NewReader(policy.Robots)), nil
+	}
+
+	resp, err := client.Get(robotsURL)
+	if err != nil {
+		return robots{}, err
+	}
+	defer resp.Body.Close()
+
+	// Server errors may be temporary, so they are not stored
+	if resp.StatusCode >= 500 {
+		return robots{}, nil
+	}
+
+	var body []byte
+	if resp.StatusCode < 400 {
+		body, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+		if err != nil {
+			return robots{}, err
+		}
+	}
+
+	r := ParseRobots(bytes.NewReader(body))
+	if err := policies.Put(NewHostPolicy(robotsURL, resp.StatusCode, string(body), r)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error on policy store: %v\n", err)
+	}
+
+	return r, nil
+}
+
+// ParseRobots parses the sitemaps and the user-agent groups of a robots.txt
+func ParseRobots(body io.Reader) robots {
+	var r robots
+	var group *robotsGroup
+	inAgents := false
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := strings.SplitN(scanner.Text(), "#", 2)[0]
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+		switch key {
+		case "sitemap":
+			if len(value) != 0 {
+				r.sitemaps = append(r.sitemaps, value)
+			}
+		case "user-agent":
+			if !inAgents {
+				r.groups = append(r.groups, robotsGroup{})
+				group = &r.groups[len(r.groups)-1]
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if group != nil && len(value) != 0 {
+				group.rules = append(group.rules, robotsRule{key == "allow", value})
+			}
+		case "crawl-delay":
+			inAgents = false
+			seconds, err := strconv.ParseFloat(value, 64)
+			if group != nil && err == nil && seconds > 0 {
+				group.delay = time.Duration(seconds * float64(time.Second))
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	return r
+}
+
+// Allowed reports whether a robots.txt allows a user agent to fetch a URL.
+// The group naming the longest part of the user agent applies, otherwise
+// the * group, and the longest matching rule decides, allow winning ties.
+func (r robots) Allowed(agent string, link string) bool {
+	u, err := neturl.Parse(link)
+	if err != nil {
+		return true
+	}
+	path := u.EscapedPath()
+	if len(path) == 0 {
+		path = "/"
+	}
+	if len(u.RawQuery) != 0 {
+		path += "?" + u.RawQuery
+	}
+
+	match := r.group(agent)
+	if match == nil {
+		return true
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range match.rules {
+		if !MatchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.pattern)
+		}
+	}
+
+	return allowed
+}
+
+// CrawlDelay returns the Crawl-delay of the group applying to a user agent,
+// or 0 if it sets none
+func (r robots) CrawlDelay(agent string) time.Duration {
+	if match := r.group(agent); match != nil {
+		return match.delay
+	}
+
+	return 0
+}
+
+// group returns the group naming the longest part of a user agent, or the
+// * group, or nil if neither exists
+func (r robots) group(agent string) *robotsGroup {
+	agent = strings.ToLower(agent)
+	var match *robotsGroup
+	best := -1
+	for i, group := range r.groups {
+		for _, name := range group.agents {
+			length := len(name)
+			if name == "*" {
+				length = 0
+			} else if !strings.Contains(agent, name) {
+				continue
+			}
+			if length > best {
+				match, best = &r.groups[i], length
+			}
+		}
+	}
+
+	return match
+}
+
+// MatchRobotsPattern reports whether a path starts with a robots.txt rule
+// pattern, where * matches any characters and a trailing $ ends the path
+func MatchRobotsPattern(pattern string, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+
+	if anchored && len(rest) != 0 {
+		if len(parts) == 1 || !strings.HasSuffix(path, parts[len(parts)-1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// robotsPolicy fetches and caches the robots.txt of every crawled host
+type robotsPolicy struct {
+	client *http.Client
+	agent  string
+	mutex  sync.Mutex
+	hosts  map[string]*hostRobots
+}
+
+// hostRobots is the robots.txt of an origin, fetched once
+type hostRobots struct {
+	once   sync.Once
+	robots robots
+}
+
+// NewRobotsPolicy creates a policy obeying robots.txt for a user agent
+func NewRobotsPolicy(client *http.Client, agent string) *robotsPolicy {
+	return &robotsPolicy{client: client, agent: agent, hosts: map[string]*hostRobots{}}
+}
+
+// Allowed reports whether the robots.txt of a URL's host allows fetching it.
+// Hosts whose robots.txt cannot be fetched allow everything. Each robots.txt
+// is fetched once, without holding up the URLs of other hosts.
+func (p *robotsPolicy) Allowed(link string) bool {
+	if p == nil {
+		return true
+	}
+
+	origin := RobotsURL(link)
+	p.mutex.Lock()
+	h, ok := p.hosts[origin]
+	if !ok {
+		h = &hostRobots{}
+		p.hosts[origin] = h
+	}
+	p.mutex.Unlock()
+
+	h.once.Do(func() {
+		h.robots, _ = FetchRobots(p.client, link)
+	})
+
+	return h.robots.Allowed(p.agent, link)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRobotsPolicyFetchesOutsideLock(t *testing.T) {
+	release := make(chan struct{})
+	var slowFetches atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowFetches.Add(1)
+		<-release
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /admin\n"))
+	}))
+	defer fast.Close()
+
+	var releaseOnce sync.Once
+	unblock := func() { releaseOnce.Do(func() { close(release) }) }
+	defer unblock()
+
+	p := NewRobotsPolicy(slow.Client(), "GoCrawler")
+
+	var wg sync.WaitGroup
+	slowAllowed := make([]bool, 3)
+	for i := range slowAllowed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slowAllowed[i] = p.Allowed(slow.URL + "/private/page")
+		}()
+	}
+
+	for slowFetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A host with a slow robots.txt does not hold up other hosts
+	allowed := make(chan bool)
+	go func() {
+		allowed <- p.Allowed(fast.URL + "/admin/page")
+	}()
+	select {
+	case ok := <-allowed:
+		if ok {
+			t.Errorf("Allowed(/admin/page) = true, want false")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Allowed on another host waited for a slow robots.txt")
+	}
+
+	unblock()
+	wg.Wait()
+	for i, ok := range slowAllowed {
+		if ok {
+			t.Errorf("Allowed(/private/page) #%v = true, want false", i)
+		}
+	}
+	if n := slowFetches.Load(); n != 1 {
+		t.Errorf("robots.txt fetched %v times, want once", n)
+	}
+}
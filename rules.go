@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	neturl "net/url"
+	"regexp"
+	"time"
+)
+
+// ---------- Rules ----------
+
+// crawlRule sets how URLs matching a pattern are crawled. Unset fields keep
+// the behavior of earlier rules or of the flags.
+type crawlRule struct {
+	Match     string            `json:"match"`
+	Depth     *int              `json:"depth,omitempty"`
+	Delay     string            `json:"delay,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Accept    string            `json:"accept,omitempty"`
+	Language  string            `json:"accept_language,omitempty"`
+	Method    string            `json:"method,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Parser    string            `json:"parser,omitempty"`
+	StoreBody *bool             `json:"store_body,omitempty"`
+	delay     time.Duration
+}
+
+type crawlRules []crawlRule
+
+// ruleBehavior is the combined behavior of the rules matching a URL
+type ruleBehavior struct {
+	depth     *int
+	delay     *time.Duration
+	headers   map[string]string
+	method    string
+	body      string
+	parser    string
+	storeBody *bool
+}
+
+// parsers are the parsers a rule may force, regardless of content type
+var parsers = map[string]bool{"auto": true, "html": true, "css": true, "js": true, "none": true}
+
+// validMethod matches upper case HTTP methods, such as POST
+var validMethod = regexp.MustCompile(`^[A-Z]+$`)
+
+// compile validates rules and parses their delays
+func (r crawlRules) compile() error {
+	for i := range r {
+		rule := &r[i]
+		if len(rule.Match) == 0 {
+			return fmt.Errorf("rule %v has no match pattern", i+1)
+		}
+		if rule.Depth != nil && *rule.Depth < 0 {
+			return fmt.Errorf("invalid depth in rule %v: %v", rule.Match, *rule.Depth)
+		}
+		if len(rule.Method) != 0 && !validMethod.MatchString(rule.Method) {
+			return fmt.Errorf("invalid method in rule %v: %v", rule.Match, rule.Method)
+		}
+		if len(rule.Parser) != 0 && !parsers[rule.Parser] {
+			return fmt.Errorf("unknown parser in rule %v: %v", rule.Match, rule.Parser)
+		}
+		if len(rule.Delay) != 0 {
+			delay, err := time.ParseDuration(rule.Delay)
+			if err != nil || delay < 0 {
+				return fmt.Errorf("invalid delay in rule %v: %v", rule.Match, rule.Delay)
+			}
+			rule.delay = delay
+		}
+	}
+
+	return nil
+}
+
+// For combines the rules matching a URL, later rules overriding earlier
+// ones
+func (r crawlRules) For(url string) ruleBehavior {
+	var behavior ruleBehavior
+	if len(r) == 0 {
+		return behavior
+	}
+
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return behavior
+	}
+
+	for i := range r {
+		rule := &r[i]
+		if !MatchPattern(rule.Match, u) {
+			continue
+		}
+
+		if rule.Depth != nil {
+			behavior.depth = rule.Depth
+		}
+		if len(rule.Delay) != 0 {
+			behavior.delay = &rule.delay
+		}
+		if len(rule.Headers) != 0 && behavior.headers == nil {
+			behavior.headers = map[string]string{}
+		}
+		for key, value := range rule.Headers {
+			behavior.headers[key] = value
+		}
+		if len(rule.Accept) != 0 || len(rule.Language) != 0 {
+			behavior.headers = negotiate(behavior.headers, rule.Accept, rule.Language)
+		}
+		if len(rule.Method) != 0 {
+			behavior.method = rule.Method
+		}
+		if len(rule.Body) != 0 {
+			behavior.body = rule.Body
+		}
+		if len(rule.Parser) != 0 {
+			behavior.parser = rule.Parser
+		}
+		if rule.StoreBody != nil {
+			behavior.storeBody = rule.StoreBody
+		}
+	}
+
+	return behavior
+}
+
+// negotiate returns headers with Accept and Accept-Language set, where
+// given, so servers negotiating content return the wanted variant
+func negotiate(headers map[string]string, accept string, language string) map[string]string {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	if len(accept) != 0 {
+		headers["Accept"] = accept
+	}
+	if len(language) != 0 {
+		headers["Accept-Language"] = language
+	}
+
+	return headers
+}
+
+// Paced reports whether any rule sets a delay
+func (r crawlRules) Paced() bool {
+	for _, rule := range r {
+		if len(rule.Delay) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ---------- Sampling ----------
+
+type sampler struct {
+	rate float64
+	walk int
+	mu   sync.Mutex
+	rng  *rand.Rand
+}
+
+// NewSampler creates a sampler following each link with probability rate
+// and, with walk > 0, at most walk random links per page. A fixed seed makes
+// the sample reproducible.
+func NewSampler(rate float64, walk int, seed int64) (*sampler, error) {
+	if rate <= 0 || rate > 1 {
+		return nil, fmt.Errorf("sample rate must be in (0, 1]: %v", rate)
+	}
+	if rate == 1 && walk <= 0 {
+		return nil, nil
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &sampler{rate: rate, walk: walk, rng: rand.New(rand.NewSource(seed))}, nil
+}
+
+// Pick returns the links of a page to follow
+func (s *sampler) Pick(links []string) []string {
+	if s == nil {
+		return links
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var picked []string
+	seen := map[string]bool{}
+	for _, link := range links {
+		if seen[URLKey(link)] {
+			continue
+		}
+		seen[URLKey(link)] = true
+		if s.rate == 1 || s.rng.Float64() < s.rate {
+			picked = append(picked, link)
+		}
+	}
+
+	if s.walk > 0 && len(picked) > s.walk {
+		s.rng.Shuffle(len(picked), func(i, j int) {
+			picked[i], picked[j] = picked[j], picked[i]
+		})
+		picked = picked[:s.walk]
+	}
+
+	return picked
+}
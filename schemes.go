@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/textproto"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+// ---------- Schemes ----------
+
+// SchemeHandler fetches or verifies URLs of a scheme other than http and
+// https. A handler returns the final URL, a status code in HTTP terms, such
+// as 200 for found and 404 for missing, headers and, when fetched, the body.
+type SchemeHandler interface {
+	Fetch(url string) (entry, error)
+}
+
+// LimitedSchemeHandler is a SchemeHandler that reads bodies and stops at a
+// body size limit, if any, like fetches of http and https links
+type LimitedSchemeHandler interface {
+	SchemeHandler
+	FetchLimited(url string, maxBody int64) (entry, error)
+}
+
+type schemeFunc func(url string) (entry, error)
+
+// Fetch calls the function
+func (f schemeFunc) Fetch(url string) (entry, error) {
+	return f(url)
+}
+
+// SchemeHandlers maps schemes to the handlers fetching their links. Register
+// a handler to crawl links of another scheme. Links of schemes that are
+// neither http, https nor registered, such as mailto and ftp by default, are
+// kept in results but not crawled.
+var SchemeHandlers = map[string]SchemeHandler{}
+
+// builtinSchemes are the handlers enabled with -schemes
+var builtinSchemes = map[string]SchemeHandler{
+	"ftp": schemeFunc(CheckFTP),
+	"s3":  bucketScheme{},
+	"gs":  bucketScheme{},
+}
+
+// EnableSchemes registers the built-in handlers of comma separated schemes
+func EnableSchemes(list string) error {
+	for _, scheme := range strings.Split(list, ",") {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if len(scheme) == 0 {
+			continue
+		}
+
+		handler, ok := builtinSchemes[scheme]
+		if !ok {
+			return fmt.Errorf("unknown scheme handler: %v", scheme)
+		}
+		SchemeHandlers[scheme] = handler
+	}
+
+	return nil
+}
+
+// schemeOf returns the lowercased scheme of a URL
+func schemeOf(link string) string {
+	u, err := neturl.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Scheme)
+}
+
+// IsCrawlable reports whether a URL has a scheme the crawler can fetch
+func IsCrawlable(link string) bool {
+	scheme := schemeOf(link)
+	_, ok := SchemeHandlers[scheme]
+	return scheme == "http" || scheme == "https" || ok
+}
+
+// ftpTimeout bounds an FTP check
+const ftpTimeout = 15 * time.Second
+
+// CheckFTP verifies that a file exists on an FTP server by logging in
+// anonymously, or with the URL's user info, and asking for its size. The
+// file itself is not downloaded.
+func CheckFTP(link string) (entry, error) {
+	u, err := neturl.Parse(link)
+	if err != nil {
+		return entry{}, err
+	}
+
+	host := u.Host
+	if len(u.Port()) == 0 {
+		host = net.JoinHostPort(u.Hostname(), "21")
+	}
+	conn, err := net.DialTimeout("tcp", host, ftpTimeout)
+	if err != nil {
+		return entry{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ftpTimeout))
+
+	c := textproto.NewConn(conn)
+	if _, _, err := c.ReadResponse(220); err != nil {
+		return entry{}, err
+	}
+
+	user, password := "anonymous", "anonymous@"
+	if u.User != nil {
+		user = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			password = p
+		}
+	}
+	code, _, err := ftpCommand(c, "USER "+user)
+	if err == nil && code == 331 {
+		code, _, err = ftpCommand(c, "PASS "+password)
+	}
+	if err != nil {
+		return entry{}, err
+	}
+	if code != 230 {
+		return entry{}, statusError{http.StatusForbidden}
+	}
+
+	ftpCommand(c, "TYPE I")
+	code, size, err := ftpCommand(c, "SIZE "+u.Path)
+	ftpCommand(c, "QUIT")
+	if err != nil {
+		return entry{}, err
+	}
+
+	switch {
+	case code == 213:
+		header := http.Header{}
+		if _, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64); err == nil {
+			header.Set("Content-Length", strings.TrimSpace(size))
+		}
+		return entry{link, http.StatusOK, header, nil, nil}, nil
+	case code == 550:
+		return entry{}, statusError{http.StatusNotFound}
+	}
+
+	return entry{}, fmt.Errorf("unexpected FTP reply: %v %v", code, size)
+}
+
+// ftpCommand sends an FTP command and reads its reply
+func ftpCommand(c *textproto.Conn, command string) (int, string, error) {
+	if err := c.PrintfLine("%s", command); err != nil {
+		return 0, "", err
+	}
+
+	code, message, err := c.ReadResponse(0)
+	if _, ok := err.(*textproto.Error); ok {
+		err = nil
+	}
+
+	return code, message, err
+}
+
+// buckets are the S3 and GCS buckets opened by FetchBucketObject
+var buckets = struct {
+	sync.Mutex
+	open map[string]*blob.Bucket
+}{open: map[string]*blob.Bucket{}}
+
+type bucketScheme struct{}
+
+// Fetch fetches a bucket object without a body size limit
+func (bucketScheme) Fetch(link string) (entry, error) {
+	return FetchBucketObject(link, 0)
+}
+
+// FetchLimited fetches a bucket object of at most maxBody bytes if > 0
+func (bucketScheme) FetchLimited(link string, maxBody int64) (entry, error) {
+	return FetchBucketObject(link, maxBody)
+}
+
+// FetchBucketObject fetches an object of an s3://bucket/key or
+// gs://bucket/key URL, with the credentials of the environment. Objects
+// larger than maxBody bytes, if > 0, fail without being read in full.
+func FetchBucketObject(link string, maxBody int64) (entry, error) {
+	u, err := neturl.Parse(link)
+	if err != nil {
+		return entry{}, err
+	}
+
+	ctx := context.Background()
+	location := u.Scheme + "://" + u.Host
+	buckets.Lock()
+	bucket, ok := buckets.open[location]
+	if !ok {
+		bucket, err = blob.OpenBucket(ctx, location)
+		if err == nil {
+			buckets.open[location] = bucket
+		}
+	}
+	buckets.Unlock()
+	if err != nil {
+		return entry{}, err
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+	reader, err := bucket.NewReader(ctx, key, nil)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return entry{}, statusError{http.StatusNotFound}
+	}
+	if err != nil {
+		return entry{}, err
+	}
+	defer reader.Close()
+
+	if maxBody > 0 && reader.Size() > maxBody {
+		return entry{}, tooLargeError{maxBody}
+	}
+
+	var limited io.Reader = reader
+	if maxBody > 0 {
+		limited = io.LimitReader(reader, maxBody+1)
+	}
+
+	body, err := ioutil.ReadAll(limited)
+	if err != nil {
+		return entry{}, err
+	}
+
+	if maxBody > 0 && int64(len(body)) > maxBody {
+		return entry{}, tooLargeError{maxBody}
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", reader.ContentType())
+	header.Set("Last-Modified", reader.ModTime().UTC().Format(http.TimeFormat))
+
+	return entry{link, http.StatusOK, header, body, nil}, nil
+}
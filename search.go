@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ---------- Search ----------
+
+type searchMatch struct {
+	line    int
+	context []string
+}
+
+// SearchText returns the lines of a text matching a pattern along with
+// up to context lines around each match
+func SearchText(re *regexp.Regexp, text string, context int) []searchMatch {
+	var matches []searchMatch
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+
+		from, to := i-context, i+context+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(lines) {
+			to = len(lines)
+		}
+		matches = append(matches, searchMatch{i + 1, lines[from:to]})
+	}
+
+	return matches
+}
+
+// PrintMatches prints the lines of a page matching a pattern
+func PrintMatches(re *regexp.Regexp, res result, context int) {
+	matches := SearchText(re, res.text, context)
+	if len(matches) == 0 {
+		return
+	}
+
+	fmt.Fprintf(logOutput, "Match: %v (%v lines)\n", res.url, len(matches))
+	for _, m := range matches {
+		fmt.Fprintf(logOutput, "  %v: %v\n", m.line, strings.Join(m.context, " | "))
+	}
+}
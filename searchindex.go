@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ---------- Search index ----------
+
+type searchDocument struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Lang  string `json:"lang,omitempty"`
+	Body  string `json:"body"`
+}
+
+// searchIndex collects the title and text of crawled pages as a JSON array
+// of documents. Client-side search libraries such as Lunr, MiniSearch and
+// Fuse index it in the browser with url as the reference and title and
+// body as fields.
+type searchIndex struct {
+	documents map[string]searchDocument
+	limit     int
+}
+
+// NewSearchIndex creates an index keeping up to limit bytes of text per
+// page, or all of it if limit is 0
+func NewSearchIndex(limit int) *searchIndex {
+	return &searchIndex{documents: map[string]searchDocument{}, limit: limit}
+}
+
+// Add adds a page to the index. Failed, noindex and redirecting pages and
+// pages with a canonical URL elsewhere are left out.
+func (i *searchIndex) Add(res result) {
+	if i == nil || res.errClass != errorNone || res.noindex || IsRedirect(res.status) {
+		return
+	}
+
+	url := res.url
+	if len(res.redirect) != 0 {
+		url = res.redirect
+	}
+	if len(res.canonical) != 0 && URLKey(res.canonical) != URLKey(url) {
+		return
+	}
+
+	body := strings.Join(strings.Fields(res.text), " ")
+	if len(body) == 0 && len(res.title) == 0 {
+		return
+	}
+	if i.limit > 0 && len(body) > i.limit {
+		body = body[:i.limit]
+		for !utf8.ValidString(body) {
+			body = body[:len(body)-1]
+		}
+	}
+
+	i.documents[URLKey(url)] = searchDocument{URL: url, Title: res.title, Lang: res.lang, Body: body}
+}
+
+// Write writes the documents in URL order to a file
+func (i *searchIndex) Write(path string) error {
+	if i == nil {
+		return nil
+	}
+
+	documents := make([]searchDocument, 0, len(i.documents))
+	for _, doc := range i.documents {
+		documents = append(documents, doc)
+	}
+	sort.Slice(documents, func(a, b int) bool {
+		return documents[a].URL < documents[b].URL
+	})
+
+	data, err := json.Marshal(documents)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ---------- Seeds ----------
+
+// seedMetadata maps the URL keys of seeds to their metadata columns
+type seedMetadata map[string]map[string]string
+
+// LoadSeeds reads seed URLs from a file. A file whose first line is a CSV
+// header with a url column is read as CSV and its other columns are kept as
+// metadata of each seed, otherwise the file lists one URL per line.
+func LoadSeeds(path string) ([]string, seedMetadata, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	first, err := reader.Peek(4096)
+	if len(first) == 0 {
+		return nil, nil, fmt.Errorf("no seeds in %v", path)
+	}
+
+	header := strings.ToLower(strings.SplitN(string(first), "\n", 2)[0])
+	for _, column := range strings.Split(header, ",") {
+		if strings.Trim(strings.TrimSpace(column), `"`) == "url" {
+			return readSeedCSV(reader)
+		}
+	}
+
+	var seeds []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) != 0 && !strings.HasPrefix(line, "#") {
+			seeds = append(seeds, line)
+		}
+	}
+
+	return seeds, nil, scanner.Err()
+}
+
+func readSeedCSV(reader *bufio.Reader) ([]string, seedMetadata, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := records[0]
+	urlColumn := 0
+	for i, column := range header {
+		if strings.EqualFold(strings.TrimSpace(column), "url") {
+			urlColumn = i
+		}
+	}
+
+	var seeds []string
+	meta := seedMetadata{}
+	for _, record := range records[1:] {
+		seed := strings.TrimSpace(record[urlColumn])
+		if len(seed) == 0 {
+			continue
+		}
+		seeds = append(seeds, seed)
+
+		values := map[string]string{}
+		for i, value := range record {
+			if i != urlColumn {
+				values[header[i]] = value
+			}
+		}
+		meta[URLKey(StripTracking(seed))] = values
+	}
+
+	return seeds, meta, nil
+}
+
+// Lookup returns the metadata of a seed URL
+func (m seedMetadata) Lookup(url string) map[string]string {
+	return m[URLKey(url)]
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ---------- Segments ----------
+
+// segmentSuffix marks the segment still being written
+const segmentSuffix = ".part"
+
+// segmentedFile writes records to numbered segments of a file, such as
+// results-0001.jsonl and results-0002.jsonl, starting a new segment once
+// one holds maxSize bytes or maxRecords records. Each Write is one record.
+// The segment being written ends in .part and is renamed once complete, so
+// other jobs can process complete segments while the crawl goes on.
+type segmentedFile struct {
+	mutex      sync.Mutex
+	base       string
+	ext        string
+	maxSize    int64
+	maxRecords int
+	index      int
+	file       *os.File
+	size       int64
+	records    int
+}
+
+// CreateSegmentedFile creates the first segment of a file, rotating at
+// maxSize bytes and maxRecords records where they are > 0
+func CreateSegmentedFile(path string, maxSize int64, maxRecords int) (*segmentedFile, error) {
+	ext := filepath.Ext(path)
+	f := &segmentedFile{base: strings.TrimSuffix(path, ext), ext: ext, maxSize: maxSize, maxRecords: maxRecords}
+	if err := f.create(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// name returns the path of the current segment once complete
+func (f *segmentedFile) name() string {
+	return fmt.Sprintf("%v-%04d%v", f.base, f.index, f.ext)
+}
+
+func (f *segmentedFile) create() error {
+	f.index++
+	file, err := os.Create(f.name() + segmentSuffix)
+	if err != nil {
+		return err
+	}
+
+	f.file, f.size, f.records = file, 0, 0
+	return nil
+}
+
+// complete syncs, closes and renames the current segment
+func (f *segmentedFile) complete() error {
+	if err := f.file.Sync(); err != nil {
+		f.file.Close()
+		return err
+	}
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.name()+segmentSuffix, f.name())
+}
+
+// Write writes a record, starting a new segment first if the record would
+// not fit in the current one
+func (f *segmentedFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	full := (f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize) ||
+		(f.maxRecords > 0 && f.records >= f.maxRecords)
+	if full {
+		if err := f.complete(); err != nil {
+			return 0, err
+		}
+		if err := f.create(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	f.records++
+	return n, err
+}
+
+// Close completes the last segment
+func (f *segmentedFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.complete()
+}
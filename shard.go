@@ -0,0 +1,95 @@
+package main
+
+import (
+	"hash/fnv"
+	neturl "net/url"
+	"sync"
+	"sync/atomic"
+)
+
+// ---------- Shards ----------
+
+type shard struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	queue  []site
+	nav    []site
+	closed bool
+}
+
+// NewShard creates an unbounded site queue for one worker
+func NewShard() *shard {
+	s := &shard{}
+	s.cond = sync.NewCond(&s.mutex)
+	return s
+}
+
+// Push queues a site without blocking. Navigation sites wait until no
+// other sites are queued.
+func (s *shard) Push(st site) {
+	s.mutex.Lock()
+	if st.nav {
+		s.nav = append(s.nav, st)
+	} else {
+		s.queue = append(s.queue, st)
+	}
+	s.mutex.Unlock()
+	atomic.AddInt64(&queuedSites, 1)
+	s.cond.Signal()
+}
+
+// Pop waits for the next site, returning false once the shard is closed
+// and empty
+func (s *shard) Pop() (site, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for len(s.queue) == 0 && len(s.nav) == 0 && !s.closed {
+		s.cond.Wait()
+	}
+
+	if len(s.queue) == 0 && len(s.nav) != 0 {
+		s.queue, s.nav = s.nav, nil
+	}
+	if len(s.queue) == 0 {
+		return site{}, false
+	}
+
+	st := s.queue[0]
+	s.queue = s.queue[1:]
+	atomic.AddInt64(&queuedSites, -1)
+	return st, true
+}
+
+// Peek returns up to n of the next queued sites
+func (s *shard) Peek(n int) []site {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	queued := append(append([]site{}, s.queue...), s.nav...)
+	if n > len(queued) {
+		n = len(queued)
+	}
+
+	return queued[:n]
+}
+
+// Close wakes the worker once the queue is drained
+func (s *shard) Close() {
+	s.mutex.Lock()
+	s.closed = true
+	s.mutex.Unlock()
+	s.cond.Broadcast()
+}
+
+// HostShard returns the shard index of a URL's host
+func HostShard(url string, shards int) int {
+	host := url
+	if u, err := neturl.Parse(url); err == nil {
+		host = u.Host
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(host))
+	return int(h.Sum32() % uint32(shards))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strings"
+)
+
+// ---------- Sitemap ----------
+
+type sitemapDocument struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// GetSitemapURLs retrieves all page URLs from sitemaps, following sitemap
+// indexes
+func GetSitemapURLs(sitemaps []string) ([]string, error) {
+	var urls []string
+	seen := map[string]bool{}
+	queue := append([]string{}, sitemaps...)
+	for len(queue) != 0 {
+		sitemap := queue[0]
+		queue = queue[1:]
+		if seen[sitemap] {
+			continue
+		}
+		seen[sitemap] = true
+
+		doc, err := FetchSitemap(sitemap)
+		if err != nil {
+			return urls, err
+		}
+
+		for _, url := range doc.URLs {
+			urls = append(urls, strings.TrimSpace(url))
+		}
+		for _, url := range doc.Sitemaps {
+			queue = append(queue, strings.TrimSpace(url))
+		}
+	}
+
+	return urls, nil
+}
+
+// FetchSitemap fetches and decodes a sitemap or sitemap index
+func FetchSitemap(url string) (sitemapDocument, error) {
+	var doc sitemapDocument
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return doc, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return doc, statusError{resp.StatusCode}
+	}
+
+	var body io.Reader = resp.Body
+	if strings.HasSuffix(resp.Request.URL.Path, ".gz") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return doc, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	err = xml.NewDecoder(body).Decode(&doc)
+	return doc, err
+}
+
+// DefaultSitemap returns the conventional sitemap location for a URL's host
+func DefaultSitemap(url string) string {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return ""
+	}
+
+	return u.Scheme + "://" + u.Host + "/sitemap.xml"
+}
+
+// ---------- Orphans ----------
+
+type orphanChecker struct {
+	crawled map[string]string
+}
+
+// Add records a successfully crawled page
+func (c *orphanChecker) Add(res result) {
+	if c.crawled == nil {
+		c.crawled = map[string]string{}
+	}
+
+	if res.errClass == errorNone {
+		c.crawled[URLKey(res.url)] = res.url
+	}
+}
+
+// Compare returns URLs only listed in the sitemaps and URLs only found by
+// crawling, limited to hosts present in the sitemaps
+func (c *orphanChecker) Compare(sitemapURLs []string) ([]string, []string) {
+	listed := map[string]bool{}
+	hosts := map[string]bool{}
+	var sitemapOnly, crawlOnly []string
+
+	for _, url := range sitemapURLs {
+		key := URLKey(url)
+		if listed[key] {
+			continue
+		}
+		listed[key] = true
+
+		if u, err := neturl.Parse(key); err == nil {
+			hosts[u.Host] = true
+		}
+
+		if _, ok := c.crawled[key]; !ok {
+			sitemapOnly = append(sitemapOnly, url)
+		}
+	}
+
+	for key, url := range c.crawled {
+		u, err := neturl.Parse(key)
+		if err == nil && hosts[u.Host] && !listed[key] {
+			crawlOnly = append(crawlOnly, url)
+		}
+	}
+
+	sort.Strings(sitemapOnly)
+	sort.Strings(crawlOnly)
+
+	return sitemapOnly, crawlOnly
+}
+
+// Print prints orphaned and unlisted pages
+func (c *orphanChecker) Print(sitemapURLs []string) {
+	sitemapOnly, crawlOnly := c.Compare(sitemapURLs)
+	for _, url := range sitemapOnly {
+		fmt.Fprintf(logOutput, "Orphan: %v (in sitemap, not linked)\n", url)
+	}
+	for _, url := range crawlOnly {
+		fmt.Fprintf(logOutput, "Unlisted: %v (linked, not in sitemap)\n", url)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ---------- Soft 404 ----------
+
+// soft404Similarity is the share of words a page must have in common with
+// the 404 page of its host to count as a soft 404
+const soft404Similarity = 0.9
+
+var notFoundTitles = []string{"404", "not found", "page not found", "no longer available", "does not exist"}
+
+type soft404Detector struct {
+	client     *http.Client
+	signatures map[string]map[string]bool
+}
+
+// NewSoft404Detector creates a detector that learns the 404 page of every
+// host by fetching a random URL that does not exist
+func NewSoft404Detector(client *http.Client) *soft404Detector {
+	return &soft404Detector{client: client, signatures: map[string]map[string]bool{}}
+}
+
+// Check returns why a successfully fetched HTML page looks like an error
+// page, or an empty string
+func (d *soft404Detector) Check(res result) string {
+	if res.errClass != errorNone || !strings.Contains(res.contentType, "html") {
+		return ""
+	}
+
+	title := strings.ToLower(res.title)
+	for _, phrase := range notFoundTitles {
+		if strings.Contains(title, phrase) {
+			return fmt.Sprintf("title %q", res.title)
+		}
+	}
+
+	signature := d.signature(res.url)
+	if len(signature) == 0 {
+		return ""
+	}
+
+	if similarity := wordSimilarity(signature, wordSet(res.text, "")); similarity >= soft404Similarity {
+		return fmt.Sprintf("%.0f%% like the 404 page", similarity*100)
+	}
+
+	return ""
+}
+
+// signature returns the words of the 404 page of a URL's host
+func (d *soft404Detector) signature(link string) map[string]bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil
+	}
+
+	origin := u.Scheme + "://" + u.Host
+	if signature, ok := d.signatures[origin]; ok {
+		return signature
+	}
+	d.signatures[origin] = nil
+
+	token := make([]byte, 8)
+	rand.Read(token)
+	random := hex.EncodeToString(token)
+
+	resp, err := d.client.Get(origin + "/" + random + "-not-found")
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil
+	}
+
+	doc := ParseDocument(resp.Request.URL.String(), strings.NewReader(string(body)), linkOptions{})
+	d.signatures[origin] = wordSet(doc.text, random)
+
+	return d.signatures[origin]
+}
+
+// wordSet returns the lower case words of a text, leaving out words
+// containing skip, such as a requested path echoed by an error page
+func wordSet(text string, skip string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		if len(skip) == 0 || !strings.Contains(word, skip) {
+			words[word] = true
+		}
+	}
+
+	return words
+}
+
+// wordSimilarity returns the Jaccard similarity of two word sets
+func wordSimilarity(a map[string]bool, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(a)+len(b)-common)
+}
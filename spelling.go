@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ---------- Spelling ----------
+
+type dictionary map[string]bool
+
+type misspelling struct {
+	word    string
+	context string
+}
+
+var wordPattern = regexp.MustCompile(`[\p{L}][\p{L}'’]*`)
+
+// dictionaryDirs are searched for dictionaries given by name
+var dictionaryDirs = []string{"/usr/share/hunspell", "/usr/share/myspell", "/usr/share/myspell/dicts", "/usr/share/dict"}
+
+// spellingSuffixes are stripped from words not found in the dictionary
+var spellingSuffixes = []string{"'s", "’s", "s", "es", "ed", "d", "ing", "ly", "er", "est"}
+
+// LoadDictionary loads a word list by path or name, e.g. en_US, along with
+// an optional file of custom words. Hunspell .dic files are read without
+// their affix flags.
+func LoadDictionary(name string, custom string) (dictionary, error) {
+	candidates := []string{name}
+	for _, dir := range dictionaryDirs {
+		candidates = append(candidates, filepath.Join(dir, name+".dic"), filepath.Join(dir, name))
+	}
+
+	d := dictionary{}
+	found := false
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		if err := d.read(path); err != nil {
+			return nil, err
+		}
+		found = true
+		break
+	}
+	if !found {
+		return nil, fmt.Errorf("dictionary not found: %v", name)
+	}
+
+	if len(custom) != 0 {
+		if err := d.read(custom); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+func (d dictionary) read(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for first := true; scanner.Scan(); first = false {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexAny(line, "/\t"); i >= 0 {
+			line = line[:i]
+		}
+		if len(line) == 0 || strings.HasPrefix(line, "#") || (first && isNumber(line)) {
+			continue
+		}
+		d[strings.ToLower(line)] = true
+	}
+
+	return scanner.Err()
+}
+
+// Known reports whether a word or its stem is in the dictionary. Words
+// written in capitals, such as acronyms, are always known.
+func (d dictionary) Known(word string) bool {
+	if len([]rune(word)) < 2 || strings.ToUpper(word) == word {
+		return true
+	}
+
+	word = strings.ToLower(word)
+	if d[word] {
+		return true
+	}
+	for _, suffix := range spellingSuffixes {
+		stem := strings.TrimSuffix(word, suffix)
+		if stem != word && (d[stem] || d[stem+"e"]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SpellCheck returns the first occurrence of each unknown word in a text
+// along with the line it occurs on
+func (d dictionary) SpellCheck(text string) []misspelling {
+	var found []misspelling
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		for _, word := range wordPattern.FindAllString(line, -1) {
+			word = strings.TrimRight(word, "'’")
+			if d.Known(word) || seen[strings.ToLower(word)] || hasInnerUpper(word) {
+				continue
+			}
+			seen[strings.ToLower(word)] = true
+			found = append(found, misspelling{word, wordContext(line, word)})
+		}
+	}
+
+	return found
+}
+
+// PrintMisspellings prints the unknown words of a page with context
+func PrintMisspellings(d dictionary, res result) {
+	for _, m := range d.SpellCheck(res.text) {
+		fmt.Fprintf(logOutput, "Spelling: %v %q (%v)\n", res.url, m.word, m.context)
+	}
+}
+
+// wordContext returns up to 40 characters around a word in a line
+func wordContext(line string, word string) string {
+	i := strings.Index(line, word)
+	runes := []rune(line)
+	start := len([]rune(line[:i]))
+	end := start + len([]rune(word))
+
+	prefix, suffix := "", ""
+	if start > 40 {
+		start, prefix = start-40, "…"
+	} else {
+		start = 0
+	}
+	if end+40 < len(runes) {
+		end, suffix = end+40, "…"
+	} else {
+		end = len(runes)
+	}
+
+	return prefix + string(runes[start:end]) + suffix
+}
+
+func hasInnerUpper(word string) bool {
+	for i, r := range word {
+		if i > 0 && unicode.IsUpper(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ---------- SQLite ----------
+
+// sqliteSchema keeps every crawl as its own run. The diff views compare
+// every pair of runs and are queried with old_run and new_run, e.g.
+// SELECT * FROM page_diff WHERE old_run = 1 AND new_run = 2
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	seed TEXT NOT NULL,
+	started_at TEXT NOT NULL,
+	finished_at TEXT
+);
+CREATE TABLE IF NOT EXISTS pages (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	url TEXT NOT NULL,
+	error TEXT NOT NULL,
+	redirect TEXT NOT NULL,
+	canonical TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	noindex INTEGER NOT NULL,
+	lang TEXT NOT NULL,
+	body_sha256 TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (run_id, url)
+);
+CREATE TABLE IF NOT EXISTS links (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	source TEXT NOT NULL,
+	target TEXT NOT NULL,
+	anchor TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS links_run_source ON links (run_id, source);
+CREATE VIEW IF NOT EXISTS page_diff AS
+	SELECT a.id AS old_run, b.id AS new_run, p.url AS url, 'added' AS change
+	FROM runs a JOIN runs b ON a.id <> b.id JOIN pages p ON p.run_id = b.id
+	WHERE NOT EXISTS (SELECT 1 FROM pages q WHERE q.run_id = a.id AND q.url = p.url)
+	UNION ALL
+	SELECT a.id, b.id, p.url, 'removed'
+	FROM runs a JOIN runs b ON a.id <> b.id JOIN pages p ON p.run_id = a.id
+	WHERE NOT EXISTS (SELECT 1 FROM pages q WHERE q.run_id = b.id AND q.url = p.url)
+	UNION ALL
+	SELECT p.run_id, q.run_id, p.url, 'changed'
+	FROM pages p JOIN pages q ON p.url = q.url AND p.run_id <> q.run_id
+	WHERE p.error <> q.error OR p.redirect <> q.redirect OR p.canonical <> q.canonical OR p.noindex <> q.noindex;
+CREATE VIEW IF NOT EXISTS link_diff AS
+	SELECT a.id AS old_run, b.id AS new_run, l.source AS source, l.target AS target, 'added' AS change
+	FROM runs a JOIN runs b ON a.id <> b.id JOIN links l ON l.run_id = b.id
+	WHERE NOT EXISTS (SELECT 1 FROM links m WHERE m.run_id = a.id AND m.source = l.source AND m.target = l.target)
+	UNION ALL
+	SELECT a.id, b.id, l.source, l.target, 'removed'
+	FROM runs a JOIN runs b ON a.id <> b.id JOIN links l ON l.run_id = a.id
+	WHERE NOT EXISTS (SELECT 1 FROM links m WHERE m.run_id = b.id AND m.source = l.source AND m.target = l.target);
+`
+
+type sqliteOutput struct {
+	db    *sql.DB
+	tx    *sql.Tx
+	runID int64
+}
+
+// NewSQLiteOutput opens a SQLite database and starts a new run in it
+func NewSQLiteOutput(path string, seed string) (*sqliteOutput, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	// Databases created before body hashes were stored lack the column
+	db.Exec("ALTER TABLE pages ADD COLUMN body_sha256 TEXT NOT NULL DEFAULT ''")
+
+	res, err := db.Exec("INSERT INTO runs (seed, started_at) VALUES (?, ?)", seed, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	runID, err := res.LastInsertId()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteOutput{db, tx, runID}, nil
+}
+
+// Write inserts a page and its links into the current run
+func (o *sqliteOutput) Write(res result) error {
+	_, err := o.tx.Exec("INSERT OR REPLACE INTO pages VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		o.runID, res.url, string(res.errClass), res.redirect, res.canonical, res.contentType, res.noindex, res.lang, res.bodyHash)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range res.anchors {
+		if _, err := o.tx.Exec("INSERT INTO links VALUES (?, ?, ?, ?)", o.runID, res.url, a.url, a.text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close commits the run, marks it finished and closes the database
+func (o *sqliteOutput) Close() error {
+	if err := o.tx.Commit(); err != nil {
+		o.db.Close()
+		return err
+	}
+
+	_, err := o.db.Exec("UPDATE runs SET finished_at = ? WHERE id = ?", time.Now().UTC().Format(time.RFC3339), o.runID)
+	if err != nil {
+		o.db.Close()
+		return err
+	}
+	fmt.Fprintf(logOutput, "Run: %v\n", o.runID)
+
+	return o.db.Close()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ---------- Srcset ----------
+
+// ParseSrcset returns the image URLs of a srcset attribute, skipping the
+// width and density descriptors
+func ParseSrcset(srcset string) []string {
+	var urls []string
+	s := srcset
+	for {
+		s = strings.TrimLeftFunc(s, func(r rune) bool {
+			return unicode.IsSpace(r) || r == ','
+		})
+		if len(s) == 0 {
+			return urls
+		}
+
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			end = len(s)
+		}
+		url := s[:end]
+		s = s[end:]
+
+		if strings.HasSuffix(url, ",") {
+			url = strings.TrimRight(url, ",")
+		} else {
+			s = skipDescriptors(s)
+		}
+
+		if len(url) != 0 {
+			urls = append(urls, url)
+		}
+	}
+}
+
+func skipDescriptors(s string) string {
+	depth := 0
+	for i, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case r == ',' && depth == 0:
+			return s[i+1:]
+		}
+	}
+
+	return ""
+}
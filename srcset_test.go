@@ -0,0 +1,30 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset string
+		urls   []string
+	}{
+		{"", nil},
+		{"a.jpg", []string{"a.jpg"}},
+		{"a.jpg 1x, b.jpg 2x", []string{"a.jpg", "b.jpg"}},
+		{"a.jpg 480w,b.jpg 800w", []string{"a.jpg", "b.jpg"}},
+		{"  a.jpg   480w ,\n b.jpg\t800w  ", []string{"a.jpg", "b.jpg"}},
+		{"a.jpg,b.jpg", []string{"a.jpg,b.jpg"}},
+		{"a.jpg, b.jpg 2x", []string{"a.jpg", "b.jpg"}},
+		{"img.php?w=1,2 1x, b.jpg 2x", []string{"img.php?w=1,2", "b.jpg"}},
+		{"a.jpg (min-width: 1px, max-width: 2px) 1x, b.jpg", []string{"a.jpg", "b.jpg"}},
+		{",, a.jpg 1x,,", []string{"a.jpg"}},
+	}
+
+	for _, test := range tests {
+		if urls := ParseSrcset(test.srcset); !reflect.DeepEqual(urls, test.urls) {
+			t.Errorf("ParseSrcset(%q) = %q, want %q", test.srcset, urls, test.urls)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ---------- Stages ----------
+
+// stages times every page through the queue, fetch, parse and output stages
+var stages = stageMetrics{timings: map[string]*stageTiming{}}
+
+var stageNames = []string{"queue", "fetch", "parse", "output"}
+
+type stageTiming struct {
+	count int64
+	total time.Duration
+	max   time.Duration
+}
+
+type stageMetrics struct {
+	mutex   sync.Mutex
+	timings map[string]*stageTiming
+}
+
+// Add records the time a page spent in a stage
+func (m *stageMetrics) Add(stage string, d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	t, ok := m.timings[stage]
+	if !ok {
+		t = &stageTiming{}
+		m.timings[stage] = t
+	}
+	t.count++
+	t.total += d
+	if d > t.max {
+		t.max = d
+	}
+}
+
+// Mean returns the mean time pages spent in a stage
+func (m *stageMetrics) Mean(stage string) time.Duration {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	t, ok := m.timings[stage]
+	if !ok || t.count == 0 {
+		return 0
+	}
+
+	return t.total / time.Duration(t.count)
+}
+
+// Bottleneck returns the stage pages spent the most time in along with a
+// suggestion for speeding it up
+func (m *stageMetrics) Bottleneck(workers int, throttled bool, sequential bool) (string, string) {
+	slowest, mean := "", time.Duration(0)
+	for _, stage := range stageNames {
+		if d := m.Mean(stage); d > mean {
+			slowest, mean = stage, d
+		}
+	}
+
+	switch slowest {
+	case "queue":
+		if workers > 0 {
+			return slowest, "increase -workers"
+		}
+		return slowest, "raise -max-memory or resume a paused crawl"
+	case "fetch":
+		if throttled {
+			return slowest, "raise -max-bandwidth"
+		}
+		if workers > 0 {
+			return slowest, "increase -workers to fetch more hosts at once"
+		}
+		return slowest, "fetching is bound by the response times of the crawled servers"
+	case "parse":
+		if sequential {
+			return slowest, "drop -deterministic to parse concurrently"
+		}
+		return slowest, "disable costly parsing such as -parse-css, -parse-images or -frames merged"
+	case "output":
+		return slowest, "write to a local -output-file or a faster -output"
+	}
+
+	return slowest, ""
+}
+
+// Print prints the time pages spent in each stage and the bottleneck
+func (m *stageMetrics) Print(workers int, throttled bool, sequential bool) {
+	for _, stage := range stageNames {
+		m.mutex.Lock()
+		t, ok := m.timings[stage]
+		var timing stageTiming
+		if ok {
+			timing = *t
+		}
+		m.mutex.Unlock()
+		if !ok {
+			continue
+		}
+
+		mean := timing.total / time.Duration(timing.count)
+		fmt.Fprintf(logOutput, "Stage (%v): mean %v, max %v, %v pages\n", stage, mean.Round(time.Microsecond), timing.max.Round(time.Microsecond), timing.count)
+	}
+
+	if stage, suggestion := m.Bottleneck(workers, throttled, sequential); len(stage) != 0 {
+		fmt.Fprintf(logOutput, "Bottleneck: %v (%v)\n", stage, suggestion)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ---------- Body store ----------
+
+// blobStore stores bodies by the SHA-256 of their content, so identical
+// bodies of different pages, runs and caches are stored once
+type blobStore struct {
+	dir string
+}
+
+// NewBlobStore creates a content-addressed body store in a directory
+func NewBlobStore(dir string) (*blobStore, error) {
+	if len(dir) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &blobStore{dir}, nil
+}
+
+// Put stores a body unless it is already stored and returns its hash
+func (s *blobStore) Put(body []byte) (string, error) {
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	path := s.path(hash)
+
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "tmp-")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return hash, os.Rename(tmp.Name(), path)
+}
+
+// Get returns a stored body by hash
+func (s *blobStore) Get(hash string) ([]byte, error) {
+	if len(hash) != sha256.Size*2 {
+		return nil, fmt.Errorf("invalid body hash: %v", hash)
+	}
+
+	return ioutil.ReadFile(s.path(hash))
+}
+
+func (s *blobStore) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash[2:])
+}
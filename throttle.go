@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------- Throttle ----------
+
+type throttle struct {
+	mutex  sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+type throttledReader struct {
+	reader   io.Reader
+	throttle *throttle
+}
+
+var byteUnits = []struct {
+	suffix string
+	size   float64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+	{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9},
+	{"k", 1e3}, {"m", 1e6}, {"g", 1e9}, {"b", 1},
+}
+
+// ParseBandwidth parses a rate such as 5MB/s or 500KiB/s into bytes per
+// second
+func ParseBandwidth(bandwidth string) (float64, error) {
+	n, err := ParseBytes(strings.TrimSuffix(strings.TrimSpace(bandwidth), "/s"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth: %v", bandwidth)
+	}
+
+	return n, nil
+}
+
+// ParseBytes parses a size such as 512MB or 1GiB into bytes
+func ParseBytes(bytes string) (float64, error) {
+	s := strings.ToLower(strings.TrimSpace(bytes))
+
+	size := 1.0
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			size = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %v", bytes)
+	}
+
+	return n * size, nil
+}
+
+// NewThrottle creates a token bucket allowing rate bytes per second with a
+// burst of one second
+func NewThrottle(rate float64) *throttle {
+	if rate <= 0 {
+		return nil
+	}
+
+	return &throttle{rate: rate, tokens: rate, last: time.Now()}
+}
+
+// Wait takes n bytes from the bucket, sleeping until they are available
+func (t *throttle) Wait(n int) {
+	if t == nil || n <= 0 {
+		return
+	}
+
+	t.mutex.Lock()
+	now := time.Now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.rate {
+		t.tokens = t.rate
+	}
+	t.last = now
+	t.tokens -= float64(n)
+	delay := time.Duration(-t.tokens / t.rate * float64(time.Second))
+	t.mutex.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// Reader wraps a reader so that reads are limited by the throttle
+func (t *throttle) Reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+
+	return throttledReader{r, t}
+}
+
+// Read reads at most one second worth of bytes and waits for them
+func (r throttledReader) Read(p []byte) (int, error) {
+	if max := int(r.throttle.rate); max > 0 && len(p) > max {
+		p = p[:max]
+	}
+
+	n, err := r.reader.Read(p)
+	r.throttle.Wait(n)
+	return n, err
+}
+
+// hostPacer spaces out the requests to every host
+type hostPacer struct {
+	mutex sync.Mutex
+	delay time.Duration
+	next  map[string]time.Time
+}
+
+// NewHostPacer creates a pacer waiting delay between requests to a host, or
+// nil for no delay
+func NewHostPacer(delay time.Duration) *hostPacer {
+	if delay <= 0 {
+		return nil
+	}
+
+	return &hostPacer{delay: delay, next: map[string]time.Time{}}
+}
+
+// Wait blocks until a request to a host may be sent
+func (p *hostPacer) Wait(host string) {
+	if p == nil {
+		return
+	}
+
+	p.WaitFor(host, p.delay)
+}
+
+// WaitFor blocks until a request to a host may be sent, keeping the next
+// request delay after this one
+func (p *hostPacer) WaitFor(host string, delay time.Duration) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	at := p.next[host]
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	p.next[host] = at.Add(delay)
+	p.mutex.Unlock()
+
+	time.Sleep(time.Until(at))
+}
@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		bandwidth string
+		rate      float64
+		ok        bool
+	}{
+		{"512", 512, true},
+		{"512B/s", 512, true},
+		{"5MB/s", 5e6, true},
+		{"500KiB/s", 500 << 10, true},
+		{"1.5gb/s", 1.5e9, true},
+		{" 2 mib/s ", 2 << 20, true},
+		{"", 0, false},
+		{"MB/s", 0, false},
+		{"-1MB/s", 0, false},
+		{"fast", 0, false},
+	}
+
+	for _, test := range tests {
+		rate, err := ParseBandwidth(test.bandwidth)
+		if rate != test.rate || (err == nil) != test.ok {
+			t.Errorf("ParseBandwidth(%q) = %v, %v, want %v, ok %v", test.bandwidth, rate, err, test.rate, test.ok)
+		}
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		size  string
+		bytes float64
+		ok    bool
+	}{
+		{"0", 0, true},
+		{"512", 512, true},
+		{"512B", 512, true},
+		{"1k", 1e3, true},
+		{"1KB", 1e3, true},
+		{"1KiB", 1 << 10, true},
+		{"2MB", 2e6, true},
+		{"2MiB", 2 << 20, true},
+		{"1.5GB", 1.5e9, true},
+		{"1gib", 1 << 30, true},
+		{" 10 mb ", 10e6, true},
+		{"", 0, false},
+		{"MB", 0, false},
+		{"-1MB", 0, false},
+		{"ten", 0, false},
+		{"1TB", 0, false},
+	}
+
+	for _, test := range tests {
+		bytes, err := ParseBytes(test.size)
+		if bytes != test.bytes || (err == nil) != test.ok {
+			t.Errorf("ParseBytes(%q) = %v, %v, want %v, ok %v", test.size, bytes, err, test.bytes, test.ok)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ---------- Trackers ----------
+
+// KnownTrackers maps tracker domains to their vendor
+var KnownTrackers = map[string]string{
+	"google-analytics.com":  "Google Analytics",
+	"googletagmanager.com":  "Google Tag Manager",
+	"doubleclick.net":       "Google Ads",
+	"googlesyndication.com": "Google Ads",
+	"googleadservices.com":  "Google Ads",
+	"facebook.net":          "Meta Pixel",
+	"facebook.com":          "Meta",
+	"hotjar.com":            "Hotjar",
+	"clarity.ms":            "Microsoft Clarity",
+	"bat.bing.com":          "Microsoft Advertising",
+	"linkedin.com":          "LinkedIn",
+	"licdn.com":             "LinkedIn Insight",
+	"ads-twitter.com":       "X Ads",
+	"analytics.tiktok.com":  "TikTok Pixel",
+	"segment.com":           "Segment",
+	"segment.io":            "Segment",
+	"mixpanel.com":          "Mixpanel",
+	"amplitude.com":         "Amplitude",
+	"hubspot.com":           "HubSpot",
+	"hs-scripts.com":        "HubSpot",
+	"hs-analytics.net":      "HubSpot",
+	"newrelic.com":          "New Relic",
+	"nr-data.net":           "New Relic",
+	"criteo.com":            "Criteo",
+	"criteo.net":            "Criteo",
+	"taboola.com":           "Taboola",
+	"outbrain.com":          "Outbrain",
+	"quantserve.com":        "Quantcast",
+	"scorecardresearch.com": "Comscore",
+	"adnxs.com":             "Xandr",
+	"yandex.ru":             "Yandex Metrica",
+	"matomo.cloud":          "Matomo",
+	"fullstory.com":         "FullStory",
+	"intercom.io":           "Intercom",
+	"optimizely.com":        "Optimizely",
+	"cookielaw.org":         "OneTrust",
+	"cookiebot.com":         "Cookiebot",
+	"youtube.com":           "YouTube",
+	"youtube-nocookie.com":  "YouTube",
+	"vimeo.com":             "Vimeo",
+	"pinterest.com":         "Pinterest",
+	"pinimg.com":            "Pinterest",
+}
+
+var secondLevelSuffixes = []string{"co.uk", "org.uk", "ac.uk", "gov.uk", "com.au", "net.au", "org.au", "co.jp", "co.nz", "com.br", "co.in"}
+
+type trackerInventory map[string]map[string]bool
+
+// Add records the third-party hosts referenced by a page
+func (t trackerInventory) Add(res result) {
+	site := SiteDomain(hostOf(res.url))
+	for _, resource := range res.resources {
+		host := hostOf(resource)
+		if len(host) == 0 || SiteDomain(host) == site {
+			continue
+		}
+
+		if t[host] == nil {
+			t[host] = map[string]bool{}
+		}
+		t[host][res.url] = true
+	}
+}
+
+// Print prints every third-party host, its vendor and the pages loading it
+func (t trackerInventory) Print() {
+	hosts := map[string]bool{}
+	for host := range t {
+		hosts[host] = true
+	}
+
+	for _, host := range sortedKeys(hosts) {
+		vendor := TrackerVendor(host)
+		if len(vendor) == 0 {
+			vendor = "unknown"
+		}
+		fmt.Fprintf(logOutput, "Third-party: %v (%v) on %v pages\n", host, vendor, len(t[host]))
+		for _, page := range sortedKeys(t[host]) {
+			fmt.Fprintf(logOutput, "  %v\n", page)
+		}
+	}
+}
+
+// TrackerVendor returns the vendor of a known tracker host
+func TrackerVendor(host string) string {
+	host = strings.ToLower(host)
+	for {
+		if vendor, ok := KnownTrackers[host]; ok {
+			return vendor
+		}
+
+		i := strings.Index(host, ".")
+		if i < 0 {
+			return ""
+		}
+		host = host[i+1:]
+	}
+}
+
+// SiteDomain returns the registrable part of a host name
+func SiteDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	labels := strings.Split(host, ".")
+	n := 2
+	for _, suffix := range secondLevelSuffixes {
+		if strings.HasSuffix(host, "."+suffix) {
+			n = 3
+		}
+	}
+
+	if len(labels) <= n {
+		return host
+	}
+
+	return strings.Join(labels[len(labels)-n:], ".")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	neturl "net/url"
+	"strings"
+)
+
+// ---------- Traps ----------
+
+// maxSegmentRepeats is how often a path segment may occur in a URL, as
+// relative links resolved against their own page lead to ever deeper
+// paths such as /a/b/a/b/a/b/
+const maxSegmentRepeats = 2
+
+// maxURLLength is the length of the longest URL crawled, as longer URLs
+// are mostly generated by session or calendar traps
+const maxURLLength = 2048
+
+// IsTrapURL reports whether a URL looks like a crawler trap, and why
+func IsTrapURL(link string) (string, bool) {
+	if len(link) > maxURLLength {
+		return fmt.Sprintf("longer than %v characters", maxURLLength), true
+	}
+
+	u, err := neturl.Parse(link)
+	if err != nil {
+		return "", false
+	}
+
+	counts := map[string]int{}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if len(segment) == 0 {
+			continue
+		}
+		counts[segment]++
+		if counts[segment] > maxSegmentRepeats {
+			return fmt.Sprintf("path segment %q repeated", segment), true
+		}
+	}
+
+	return "", false
+}
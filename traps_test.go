@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsTrapURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		trap bool
+	}{
+		{"http://example.com/", false},
+		{"http://example.com/2024/01/01/post", false},
+		{"http://example.com/a/b/a/b/", false},
+		{"http://example.com/a/b/a/b/a/b/", true},
+		{"http://example.com/x/x/x", true},
+		{"http://example.com/?q=" + strings.Repeat("a", maxURLLength), true},
+	}
+
+	for _, test := range tests {
+		if _, trap := IsTrapURL(test.url); trap != test.trap {
+			t.Errorf("IsTrapURL(%v) = %v, want %v", test.url, trap, test.trap)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ---------- Validation ----------
+
+type markupFacts struct {
+	doctype  bool
+	started  bool
+	open     []string
+	ids      map[string]int
+	unclosed map[string]int
+	stray    map[string]int
+}
+
+// voidElements never have end tags
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// optionalEndElements may have their end tags omitted
+var optionalEndElements = map[string]bool{
+	"html": true, "head": true, "body": true, "p": true, "li": true, "dt": true, "dd": true,
+	"option": true, "optgroup": true, "rt": true, "rp": true, "caption": true, "colgroup": true,
+	"thead": true, "tbody": true, "tfoot": true, "tr": true, "td": true, "th": true,
+}
+
+// Doctype records a doctype declaration
+func (f *markupFacts) Doctype() {
+	f.doctype = f.doctype || !f.started
+}
+
+// Token records the open elements and ids of a tag
+func (f *markupFacts) Token(tokenType html.TokenType, token html.Token) {
+	f.started = true
+	if tokenType != html.EndTagToken {
+		if id := strings.TrimSpace(GetAttr(token, "id")); len(id) != 0 {
+			if f.ids == nil {
+				f.ids = map[string]int{}
+			}
+			f.ids[id]++
+		}
+	}
+
+	switch {
+	case tokenType == html.StartTagToken && !voidElements[token.Data]:
+		f.open = append(f.open, token.Data)
+	case tokenType == html.EndTagToken && !voidElements[token.Data]:
+		for i := len(f.open) - 1; i >= 0; i-- {
+			if f.open[i] == token.Data {
+				for _, name := range f.open[i+1:] {
+					f.unclose(name)
+				}
+				f.open = f.open[:i]
+				return
+			}
+		}
+		if f.stray == nil {
+			f.stray = map[string]int{}
+		}
+		f.stray[token.Data]++
+	}
+}
+
+func (f *markupFacts) unclose(name string) {
+	if optionalEndElements[name] {
+		return
+	}
+	if f.unclosed == nil {
+		f.unclosed = map[string]int{}
+	}
+	f.unclosed[name]++
+}
+
+// Findings returns the markup problems found once the document has ended
+func (f markupFacts) Findings() []string {
+	unclosed := map[string]int{}
+	for name, n := range f.unclosed {
+		unclosed[name] = n
+	}
+	for _, name := range f.open {
+		if !optionalEndElements[name] {
+			unclosed[name]++
+		}
+	}
+
+	var findings []string
+	if !f.doctype {
+		findings = append(findings, "missing doctype")
+	}
+	for _, name := range sortedCounts(unclosed) {
+		findings = append(findings, fmt.Sprintf("unclosed <%v> (%v)", name, unclosed[name]))
+	}
+	for _, name := range sortedCounts(f.stray) {
+		findings = append(findings, fmt.Sprintf("stray </%v> (%v)", name, f.stray[name]))
+	}
+	for _, id := range sortedCounts(f.ids) {
+		if f.ids[id] > 1 {
+			findings = append(findings, fmt.Sprintf("duplicate id %q (%v)", id, f.ids[id]))
+		}
+	}
+
+	return findings
+}
+
+func sortedCounts(counts map[string]int) []string {
+	var keys []string
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// PrintValidation prints the markup findings of a page
+func PrintValidation(res result) {
+	for _, finding := range res.markup {
+		fmt.Fprintf(logOutput, "HTML: %v %v\n", res.url, finding)
+	}
+}
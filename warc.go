@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------- WARC ----------
+
+// maxArchiveRedirects bounds the redirects followed within an archive
+const maxArchiveRedirects = 10
+
+type warcRecord struct {
+	path    string
+	offset  int64
+	gzipped bool
+}
+
+// warcArchive indexes the response records of WARC files by URL key so
+// fetches can be replayed from the archive without the network
+type warcArchive struct {
+	records map[string]warcRecord
+}
+
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// OpenWARC indexes the response records of WARC files, compressed per
+// record (.warc.gz) or not. Later records of a URL replace earlier ones.
+func OpenWARC(paths []string) (*warcArchive, error) {
+	a := &warcArchive{records: map[string]warcRecord{}}
+	for _, path := range paths {
+		if err := a.index(path); err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *warcArchive) index(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gzipped := strings.HasSuffix(path, ".gz")
+	cr := &countingReader{r: bufio.NewReader(file)}
+	for {
+		offset := cr.n
+		var r *bufio.Reader
+		if gzipped {
+			if _, err := cr.r.Peek(1); err == io.EOF {
+				return nil
+			}
+			gz, err := gzip.NewReader(cr)
+			if err != nil {
+				return err
+			}
+			gz.Multistream(false)
+			r = bufio.NewReader(gz)
+		} else {
+			r = cr.r
+			if err := skipBlankLines(r, cr); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			offset = cr.n
+		}
+
+		for {
+			header, err := readWARCHeader(r, countIf(!gzipped, cr))
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid record length: %v", header.Get("Content-Length"))
+			}
+			if _, err := io.CopyN(ioutil.Discard, readerCounting(r, !gzipped, cr), length); err != nil {
+				return err
+			}
+
+			if header.Get("WARC-Type") == "response" {
+				target := strings.Trim(header.Get("WARC-Target-URI"), "<>")
+				a.records[URLKey(target)] = warcRecord{path, offset, gzipped}
+			}
+
+			if gzipped {
+				io.Copy(ioutil.Discard, r)
+				break
+			}
+			if err := skipBlankLines(r, cr); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			offset = cr.n
+		}
+	}
+}
+
+// Get replays the response archived for a URL, following archived redirects
+func (a *warcArchive) Get(url string) (entry, error) {
+	for i := 0; i <= maxArchiveRedirects; i++ {
+		record, ok := a.records[URLKey(url)]
+		if !ok {
+			return entry{}, notArchivedError{url}
+		}
+
+		resp, body, err := record.read(url)
+		if err != nil {
+			return entry{}, err
+		}
+
+		location := resp.Header.Get("Location")
+		if resp.StatusCode >= 300 && resp.StatusCode < 400 && len(location) != 0 {
+			url = FixLink(url, location)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			return entry{}, statusError{resp.StatusCode}
+		}
+
+		return entry{url, resp.StatusCode, resp.Header, body, nil}, nil
+	}
+
+	return entry{}, fmt.Errorf("too many archived redirects: %v", url)
+}
+
+func (record warcRecord) read(url string) (*http.Response, []byte, error) {
+	file, err := os.Open(record.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(record.offset, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	var r io.Reader = file
+	if record.gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		gz.Multistream(false)
+		r = gz
+	}
+
+	br := bufio.NewReader(r)
+	header, err := readWARCHeader(br, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	block := io.LimitReader(br, length)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(block), req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	return resp, body, err
+}
+
+// readWARCHeader reads a WARC version line and the record headers
+func readWARCHeader(r *bufio.Reader, cr *countingReader) (textproto.MIMEHeader, error) {
+	line, err := r.ReadString('\n')
+	if cr != nil {
+		cr.n += int64(len(line))
+	}
+	if err != nil {
+		if len(strings.TrimSpace(line)) == 0 {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "WARC/") {
+		return nil, fmt.Errorf("invalid record: %q", strings.TrimSpace(line))
+	}
+
+	var raw bytes.Buffer
+	for {
+		line, err := r.ReadString('\n')
+		if cr != nil {
+			cr.n += int64(len(line))
+		}
+		raw.WriteString(line)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+	}
+
+	return textproto.NewReader(bufio.NewReader(&raw)).ReadMIMEHeader()
+}
+
+// skipBlankLines skips the line breaks between records
+func skipBlankLines(r *bufio.Reader, cr *countingReader) error {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return err
+		}
+		if b[0] != '\r' && b[0] != '\n' {
+			return nil
+		}
+		r.ReadByte()
+		cr.n++
+	}
+}
+
+func countIf(count bool, cr *countingReader) *countingReader {
+	if count {
+		return cr
+	}
+	return nil
+}
+
+func readerCounting(r *bufio.Reader, count bool, cr *countingReader) io.Reader {
+	if count {
+		return cr
+	}
+	return r
+}
+
+// warcWriter appends the responses of a crawl to a WARC file as response
+// records, compressed per record if the path ends in .gz
+type warcWriter struct {
+	mutex   sync.Mutex
+	file    io.WriteCloser
+	gzipped bool
+}
+
+// CreateWARC creates a WARC file to write responses to
+func CreateWARC(path string) (*warcWriter, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	file, err := CreateFile(path, "application/warc")
+	if err != nil {
+		return nil, err
+	}
+
+	return &warcWriter{file: file, gzipped: strings.HasSuffix(path, ".gz")}, nil
+}
+
+// Write appends a response with its body as read. Responses are recorded
+// under their final URL after redirects.
+func (w *warcWriter) Write(resp *http.Response, body []byte) error {
+	if w == nil {
+		return nil
+	}
+
+	var block bytes.Buffer
+	fmt.Fprintf(&block, "HTTP/1.1 %v\r\n", resp.Status)
+	header := resp.Header.Clone()
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	header.Del("Content-Encoding")
+	header.Write(&block)
+	block.WriteString("\r\n")
+	block.Write(body)
+
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	var record bytes.Buffer
+	record.WriteString("WARC/1.0\r\n")
+	record.WriteString("WARC-Type: response\r\n")
+	fmt.Fprintf(&record, "WARC-Record-ID: <urn:uuid:%x-%x-%x-%x-%x>\r\n", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+	fmt.Fprintf(&record, "WARC-Date: %v\r\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&record, "WARC-Target-URI: %v\r\n", resp.Request.URL)
+	record.WriteString("Content-Type: application/http; msgtype=response\r\n")
+	fmt.Fprintf(&record, "Content-Length: %v\r\n\r\n", block.Len())
+	record.Write(block.Bytes())
+	record.WriteString("\r\n\r\n")
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.gzipped {
+		_, err := w.file.Write(record.Bytes())
+		return err
+	}
+
+	gz := gzip.NewWriter(w.file)
+	if _, err := gz.Write(record.Bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Close closes the WARC file
+func (w *warcWriter) Close() error {
+	if w == nil {
+		return nil
+	}
+
+	return w.file.Close()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// ---------- XML ----------
+
+// xmlLinkAttrs are the attributes, in any namespace, holding links
+var xmlLinkAttrs = map[string]bool{"href": true, "src": true, "url": true, "uri": true, "resource": true}
+
+// xmlLinkElements are the elements whose text is a link, as in RSS and sitemaps
+var xmlLinkElements = map[string]bool{"link": true, "loc": true, "url": true, "uri": true, "guid": true}
+
+// IsXML reports whether a content type is an XML document, including XHTML
+func IsXML(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// IsXHTML reports whether a content type is XHTML
+func IsXHTML(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), "application/xhtml+xml")
+}
+
+// GetXMLLinks retrieves the links and text of an XML document. The decoder is
+// lenient so a document that is not well-formed yields what precedes the error.
+func GetXMLLinks(baseURL string, body []byte) ([]string, string) {
+	var links []string
+	var lines []string
+	var elements []string
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return links, strings.Join(lines, "\n")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			elements = append(elements, strings.ToLower(t.Name.Local))
+			for _, attr := range t.Attr {
+				if !xmlLinkAttrs[strings.ToLower(attr.Name.Local)] {
+					continue
+				}
+				if link := TrimLink(attr.Value); len(link) != 0 && !strings.HasPrefix(link, "data:") {
+					links = append(links, FixLink(baseURL, link))
+				}
+			}
+		case xml.EndElement:
+			if len(elements) != 0 {
+				elements = elements[:len(elements)-1]
+			}
+		case xml.CharData:
+			text := strings.Join(strings.Fields(string(t)), " ")
+			if len(text) == 0 {
+				continue
+			}
+			if len(elements) != 0 && xmlLinkElements[elements[len(elements)-1]] && IsURLShaped(text) {
+				links = append(links, FixLink(baseURL, TrimLink(text)))
+				continue
+			}
+			lines = append(lines, text)
+		}
+	}
+}
+
+// IsURLShaped reports whether a text looks like an absolute or root-relative URL
+func IsURLShaped(text string) bool {
+	lower := strings.ToLower(text)
+	return !strings.ContainsAny(text, " \t\n") &&
+		(strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(text, "/"))
+}
+
+// MergeLinks appends the links of extra missing from links
+func MergeLinks(links []string, extra []string) []string {
+	seen := map[string]bool{}
+	for _, link := range links {
+		seen[link] = true
+	}
+	for _, link := range extra {
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+
+	return links
+}